@@ -0,0 +1,43 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/dzoba/github-actions-watcher/internal/gh"
+)
+
+// backendUsage documents the --backend flag.
+const backendUsage = "How to poll GitHub: cli (run gh), api (call the REST API with conditional requests and an on-disk cache) or graphql (fetch every repo in one query per poll)"
+
+// newBackend returns the backend named by --backend. The api and graphql
+// backends cache REST responses in cacheDir, or the default cache directory
+// if it is empty.
+func newBackend(name, cacheDir string) (gh.Backend, error) {
+	switch name {
+	case "", "cli":
+		return gh.CLI{}, nil
+	case "api":
+		cache, err := openCache(cacheDir)
+		if err != nil {
+			return nil, err
+		}
+		return gh.NewAPI(cache)
+	case "graphql":
+		cache, err := openCache(cacheDir)
+		if err != nil {
+			return nil, err
+		}
+		return gh.NewGraphQL(cache)
+	}
+	return nil, fmt.Errorf("unknown backend %q, want cli, api or graphql", name)
+}
+
+func openCache(dir string) (*gh.Cache, error) {
+	if dir == "" {
+		var err error
+		if dir, err = gh.DefaultCacheDir(); err != nil {
+			return nil, err
+		}
+	}
+	return gh.NewCache(dir)
+}
@@ -8,18 +8,114 @@ import (
 
 	tea "github.com/charmbracelet/bubbletea"
 
+	"github.com/dzoba/github-actions-watcher/internal/cost"
 	"github.com/dzoba/github-actions-watcher/internal/model"
+	"github.com/dzoba/github-actions-watcher/internal/stats"
+	"github.com/dzoba/github-actions-watcher/internal/store"
 )
 
 func main() {
+	if len(os.Args) > 1 {
+		var run func([]string) error
+		switch os.Args[1] {
+		case "stats":
+			run = runStats
+		case "serve":
+			run = runServe
+		case "push":
+			run = runPush
+		}
+		if run != nil {
+			if err := run(os.Args[2:]); err != nil {
+				fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+				os.Exit(1)
+			}
+			return
+		}
+	}
+
 	interval := flag.Int("i", 10, "Polling interval in seconds")
 	flag.IntVar(interval, "interval", 10, "Polling interval in seconds")
+	historyDir := flag.String("history-dir", "", "Directory for the local run history (default: user cache dir)")
+	noHistory := flag.Bool("no-history", false, "Don't record run history")
+	windowsFlag := flag.String("windows", "24h,7d,30d", "Comma-separated periods the stats view covers")
+	ratesFlag := flag.String("rates", "", "Price per billable minute by runner OS, e.g. ubuntu=0.008,macos=0.08")
+	org := flag.String("org", "", "Watch the recently active repos of an org, or of a team as org/team-slug")
+	orgActive := flag.String("org-active", "7d", "With --org, watch repos pushed to within this period")
+	backendName := flag.String("backend", "cli", backendUsage)
+	cacheDir := flag.String("cache-dir", "", "Directory for the REST response cache of the api and graphql backends (default: user cache dir)")
+	here := flag.Bool("here", false, "Show the runs of the local branch, those of the checked-out commit first")
+	offline := flag.Bool("offline", false, "Browse the run history recorded earlier without fetching")
+	queueThreshold := flag.Duration("queue-threshold", model.DefaultQueueThreshold, "Flag jobs waiting for a runner longer than this")
 	flag.Parse()
 
-	m := model.New(time.Duration(*interval) * time.Second)
+	if *here && *org != "" {
+		fmt.Fprintln(os.Stderr, "Error: --here watches the repo checked out in the current directory; it can't be used with --org")
+		os.Exit(2)
+	}
+	if *offline && *noHistory {
+		fmt.Fprintln(os.Stderr, "Error: --offline browses the run history; it can't be used with --no-history")
+		os.Exit(2)
+	}
+	windows, err := stats.ParseWindows(*windowsFlag)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(2)
+	}
+	rates, err := cost.ParseRates(*ratesFlag)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(2)
+	}
+	active, err := stats.ParseDuration(*orgActive)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: --org-active: %v\n", err)
+		os.Exit(2)
+	}
+	backend, err := newBackend(*backendName, *cacheDir)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(2)
+	}
+	opts := model.Options{
+		Backend:        backend,
+		Interval:       time.Duration(*interval) * time.Second,
+		Windows:        windows,
+		QueueThreshold: *queueThreshold,
+		Rates:          rates,
+		Org:            *org,
+		OrgActive:      active,
+		Offline:        *offline,
+		Here:           *here,
+	}
+	if !*noHistory {
+		st, err := openStore(*historyDir)
+		if err != nil && *offline {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: run history disabled: %v\n", err)
+		}
+		opts.Store = st
+	}
+
+	m := model.New(opts)
 	p := tea.NewProgram(m, tea.WithAltScreen())
 	if _, err := p.Run(); err != nil {
 		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
 		os.Exit(1)
 	}
 }
+
+// openStore opens the run history in dir, or in the default location if dir
+// is empty.
+func openStore(dir string) (*store.Store, error) {
+	if dir == "" {
+		var err error
+		if dir, err = store.DefaultDir(); err != nil {
+			return nil, err
+		}
+	}
+	return store.Open(dir, store.Options{})
+}
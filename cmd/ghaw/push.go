@@ -0,0 +1,220 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"os/signal"
+	"syscall"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	"github.com/dzoba/github-actions-watcher/internal/format"
+	"github.com/dzoba/github-actions-watcher/internal/gh"
+	"github.com/dzoba/github-actions-watcher/internal/model"
+	"github.com/dzoba/github-actions-watcher/internal/types"
+)
+
+// runPush implements `ghaw push`: run git push, wait for the runs of the
+// pushed commit to start, then watch them in the TUI or, headless, until
+// they finish. Arguments after the flags are passed on to git push.
+func runPush(args []string) error {
+	fs := flag.NewFlagSet("push", flag.ExitOnError)
+	interval := fs.Int("i", 10, "Polling interval in seconds")
+	fs.IntVar(interval, "interval", 10, "Polling interval in seconds")
+	timeout := fs.Duration("timeout", 2*time.Minute, "How long to wait for runs of the pushed commit to start")
+	headless := fs.Bool("headless", false, "Print the outcome of the runs instead of opening the TUI; exit 1 if any failed")
+	backendName := fs.String("backend", "cli", backendUsage)
+	cacheDir := fs.String("cache-dir", "", "Directory for the REST response cache of the api and graphql backends (default: user cache dir)")
+	fs.Parse(args)
+
+	repo, err := gh.DetectRepo()
+	if err != nil {
+		return err
+	}
+	head, err := gh.LocalHead()
+	if err != nil {
+		return err
+	}
+	backend, err := newBackend(*backendName, *cacheDir)
+	if err != nil {
+		return err
+	}
+
+	push := exec.Command("git", append([]string{"push"}, fs.Args()...)...)
+	push.Stdin, push.Stdout, push.Stderr = os.Stdin, os.Stdout, os.Stderr
+	if err := push.Run(); err != nil {
+		return fmt.Errorf("git push failed: %w", err)
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	every := time.Duration(*interval) * time.Second
+	fmt.Fprintf(os.Stderr, "Waiting for runs of %s in %s...\n", head.SHA[:7], repo)
+	wait, cancel := context.WithTimeout(ctx, *timeout)
+	defer cancel()
+	runs, err := waitForRuns(wait, backend, repo, head.SHA, every)
+	if err != nil {
+		if errors.Is(err, context.DeadlineExceeded) {
+			return fmt.Errorf("no runs of %s started within %s; does a workflow run on this push?", head.SHA[:7], format.DurationOf(*timeout))
+		}
+		return err
+	}
+
+	if *headless {
+		return watchRuns(ctx, backend, repo, head.SHA, runs, every, lateRuns, os.Stdout)
+	}
+	opts := model.Options{
+		Backend:  backend,
+		Interval: every,
+		Here:     true,
+	}
+	st, err := openStore("")
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: run history disabled: %v\n", err)
+	}
+	opts.Store = st
+	_, err = tea.NewProgram(model.New(opts), tea.WithAltScreen()).Run()
+	return err
+}
+
+// lateRuns is how long headless push keeps looking for runs of the pushed
+// commit after the first one started, for workflows that start later.
+const lateRuns = 30 * time.Second
+
+// runsOf returns the runs of commit sha, fetching them from b.
+func runsOf(ctx context.Context, b gh.Backend, repo, sha string) ([]types.WorkflowRun, error) {
+	all, err := b.FetchRuns(ctx, repo)
+	if err != nil {
+		return nil, err
+	}
+	var runs []types.WorkflowRun
+	for _, run := range all {
+		if run.HeadSha == sha {
+			runs = append(runs, run)
+		}
+	}
+	return runs, nil
+}
+
+// pollRuns calls fn with the runs of sha every interval until it returns
+// true, ctx is done or fetching, by pollRuns or fn, fails for any other
+// reason than the network.
+func pollRuns(ctx context.Context, b gh.Backend, repo, sha string, every time.Duration, fn func([]types.WorkflowRun) (bool, error)) error {
+	for {
+		runs, err := runsOf(ctx, b, repo, sha)
+		if err == nil {
+			var done bool
+			if done, err = fn(runs); done && err == nil {
+				return nil
+			}
+		}
+		switch {
+		case err == nil:
+		case ctx.Err() != nil:
+			return ctx.Err()
+		case gh.KindOf(err) != gh.ErrNetwork:
+			return err
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(every):
+		}
+	}
+}
+
+// waitForRuns returns the runs of sha once there are any.
+func waitForRuns(ctx context.Context, b gh.Backend, repo, sha string, every time.Duration) ([]types.WorkflowRun, error) {
+	var found []types.WorkflowRun
+	err := pollRuns(ctx, b, repo, sha, every, func(runs []types.WorkflowRun) (bool, error) {
+		found = runs
+		return len(runs) > 0, nil
+	})
+	return found, err
+}
+
+// watchRuns prints the runs of sha to w as they finish, starting with runs,
+// and returns once all have and none started for settle. Runs are followed
+// by ID, as those of a busy repo drop off its latest runs before they
+// finish. It fails if any run didn't succeed, or if there were none.
+func watchRuns(ctx context.Context, b gh.Backend, repo, sha string, runs []types.WorkflowRun, every, settle time.Duration, w io.Writer) error {
+	latest := make(map[int]types.WorkflowRun)
+	var ids []int
+	track := func(run types.WorkflowRun) bool {
+		_, known := latest[run.DatabaseID]
+		if !known {
+			ids = append(ids, run.DatabaseID)
+		}
+		latest[run.DatabaseID] = run
+		return !known
+	}
+	for _, run := range runs {
+		track(run)
+	}
+	lastNew := time.Now()
+	reported := make(map[int]bool)
+	var failed int
+	err := pollRuns(ctx, b, repo, sha, every, func(listed []types.WorkflowRun) (bool, error) {
+		inList := make(map[int]bool)
+		for _, run := range listed {
+			if track(run) {
+				lastNew = time.Now()
+			}
+			inList[run.DatabaseID] = true
+		}
+		for _, id := range ids {
+			if reported[id] {
+				continue
+			}
+			run := latest[id]
+			if !inList[id] {
+				d, err := b.FetchRunDetail(ctx, repo, id)
+				if err != nil {
+					return false, err
+				}
+				run = d.WorkflowRun
+				latest[id] = run
+			}
+			if run.Status != types.StatusCompleted {
+				continue
+			}
+			reported[id] = true
+			if !passed(run.Conclusion) {
+				failed++
+			}
+			start := run.StartedAt
+			if start == "" {
+				start = run.CreatedAt
+			}
+			text, _ := format.StatusBadge(run.Status, run.Conclusion)
+			fmt.Fprintf(w, "%-13s %s #%d in %s  %s\n", text, run.WorkflowName, run.Number, format.Duration(start, run.UpdatedAt), run.URL)
+		}
+		return len(reported) == len(ids) && time.Since(lastNew) >= settle, nil
+	})
+	if err != nil {
+		return err
+	}
+	if len(ids) == 0 {
+		return fmt.Errorf("no runs of %s were seen", sha[:7])
+	}
+	if failed > 0 {
+		return fmt.Errorf("%d of %d runs of %s failed", failed, len(ids), sha[:7])
+	}
+	return nil
+}
+
+// passed reports whether a run that finished with c didn't fail.
+func passed(c types.RunConclusion) bool {
+	switch c {
+	case types.ConclusionSuccess, types.ConclusionSkipped, types.ConclusionNeutral:
+		return true
+	}
+	return false
+}
@@ -0,0 +1,124 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/dzoba/github-actions-watcher/internal/gh"
+	"github.com/dzoba/github-actions-watcher/internal/types"
+)
+
+// scriptedBackend returns the next of its run lists each fetch, repeating
+// the last one, and the runs in details by ID.
+type scriptedBackend struct {
+	gh.CLI
+	lists   [][]types.WorkflowRun
+	errs    []error
+	details map[int]types.WorkflowRun
+}
+
+func (b *scriptedBackend) FetchRunDetail(ctx context.Context, repo string, runID int) (*types.RunDetail, error) {
+	run, ok := b.details[runID]
+	if !ok {
+		return nil, &gh.Error{Kind: gh.ErrNotFound, Message: "HTTP 404"}
+	}
+	return &types.RunDetail{WorkflowRun: run}, nil
+}
+
+func (b *scriptedBackend) FetchRuns(ctx context.Context, repo string) ([]types.WorkflowRun, error) {
+	if len(b.errs) > 0 {
+		err := b.errs[0]
+		b.errs = b.errs[1:]
+		return nil, err
+	}
+	runs := b.lists[0]
+	if len(b.lists) > 1 {
+		b.lists = b.lists[1:]
+	}
+	return runs, nil
+}
+
+func TestWatchRuns(t *testing.T) {
+	const sha = "abcdef0123"
+	run := func(id int, status types.RunStatus, c types.RunConclusion) types.WorkflowRun {
+		return types.WorkflowRun{DatabaseID: id, HeadSha: sha, WorkflowName: "CI", Number: id, Status: status, Conclusion: c}
+	}
+	other := types.WorkflowRun{DatabaseID: 9, HeadSha: "0000000", Status: types.StatusCompleted, Conclusion: types.ConclusionFailure}
+	b := &scriptedBackend{
+		errs: []error{&gh.Error{Kind: gh.ErrNetwork, Message: "error connecting to api.github.com"}},
+		lists: [][]types.WorkflowRun{
+			{other},
+			{run(1, types.StatusInProgress, ""), run(2, types.StatusQueued, ""), other},
+			{run(1, types.StatusCompleted, types.ConclusionSuccess), run(2, types.StatusInProgress, ""), other},
+			{run(1, types.StatusCompleted, types.ConclusionSuccess), run(2, types.StatusCompleted, types.ConclusionFailure), other},
+		},
+	}
+	ctx := context.Background()
+	runs, err := waitForRuns(ctx, b, "o/r", sha, time.Millisecond)
+	if err != nil || len(runs) != 2 {
+		t.Fatalf("waitForRuns() = %v, %v after a network error", runs, err)
+	}
+	var out bytes.Buffer
+	err = watchRuns(ctx, b, "o/r", sha, runs, time.Millisecond, 0, &out)
+	if err == nil || err.Error() != "1 of 2 runs of abcdef0 failed" {
+		t.Errorf("watchRuns() = %v", err)
+	}
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	if len(lines) != 2 || !strings.HasPrefix(lines[0], "+ passed      CI #1") || !strings.HasPrefix(lines[1], "x failed      CI #2") {
+		t.Errorf("output:\n%s", out.String())
+	}
+
+	// Errors other than the network's end the wait.
+	b = &scriptedBackend{errs: []error{&gh.Error{Kind: gh.ErrAuth, Message: "HTTP 401"}}}
+	if _, err := waitForRuns(ctx, b, "o/r", sha, time.Millisecond); gh.KindOf(err) != gh.ErrAuth {
+		t.Errorf("waitForRuns() = %v, want the auth error", err)
+	}
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Millisecond)
+	defer cancel()
+	b = &scriptedBackend{lists: [][]types.WorkflowRun{{other}}}
+	if _, err := waitForRuns(ctx, b, "o/r", sha, time.Millisecond); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("waitForRuns() = %v, want a timeout", err)
+	}
+}
+
+func TestWatchRunsByID(t *testing.T) {
+	const sha = "abcdef0123"
+	run := func(id int, status types.RunStatus, c types.RunConclusion) types.WorkflowRun {
+		return types.WorkflowRun{DatabaseID: id, HeadSha: sha, WorkflowName: "CI", Number: id, Status: status, Conclusion: c}
+	}
+	ctx := context.Background()
+
+	// On a busy repo the pushed runs drop off the latest runs while they
+	// run; they are followed by ID.
+	busy := []types.WorkflowRun{{DatabaseID: 9, HeadSha: "0000000", Status: types.StatusInProgress}}
+	b := &scriptedBackend{
+		lists:   [][]types.WorkflowRun{busy},
+		details: map[int]types.WorkflowRun{1: run(1, types.StatusCompleted, types.ConclusionFailure)},
+	}
+	var out bytes.Buffer
+	err := watchRuns(ctx, b, "o/r", sha, []types.WorkflowRun{run(1, types.StatusInProgress, "")}, time.Millisecond, 0, &out)
+	if err == nil || err.Error() != "1 of 1 runs of abcdef0 failed" || !strings.HasPrefix(out.String(), "x failed      CI #1") {
+		t.Errorf("watchRuns() = %v, output:\n%s", err, out.String())
+	}
+
+	// Runs that start later are waited for too.
+	b = &scriptedBackend{lists: [][]types.WorkflowRun{
+		{run(1, types.StatusCompleted, types.ConclusionSuccess)},
+		{run(1, types.StatusCompleted, types.ConclusionSuccess), run(2, types.StatusCompleted, types.ConclusionFailure)},
+	}}
+	out.Reset()
+	err = watchRuns(ctx, b, "o/r", sha, []types.WorkflowRun{run(1, types.StatusInProgress, "")}, time.Millisecond, 200*time.Millisecond, &out)
+	if err == nil || err.Error() != "1 of 2 runs of abcdef0 failed" {
+		t.Errorf("watchRuns() = %v with a late run, output:\n%s", err, out.String())
+	}
+
+	// No runs are no success.
+	b = &scriptedBackend{lists: [][]types.WorkflowRun{busy}}
+	if err := watchRuns(ctx, b, "o/r", sha, nil, time.Millisecond, 0, &out); err == nil {
+		t.Error("watchRuns() succeeded without any runs")
+	}
+}
@@ -0,0 +1,161 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/dzoba/github-actions-watcher/internal/gh"
+	"github.com/dzoba/github-actions-watcher/internal/metrics"
+	"github.com/dzoba/github-actions-watcher/internal/otlp"
+	"github.com/dzoba/github-actions-watcher/internal/poller"
+	"github.com/dzoba/github-actions-watcher/internal/types"
+	"github.com/dzoba/github-actions-watcher/internal/web"
+)
+
+// repoList is a flag that can be repeated or comma-separated.
+type repoList []string
+
+func (r *repoList) String() string { return strings.Join(*r, ",") }
+
+func (r *repoList) Set(s string) error {
+	for _, repo := range strings.Split(s, ",") {
+		if repo = strings.TrimSpace(repo); repo != "" {
+			if !strings.Contains(repo, "/") {
+				return fmt.Errorf("invalid repo %q, want owner/repo", repo)
+			}
+			*r = append(*r, repo)
+		}
+	}
+	return nil
+}
+
+// headerList is a repeatable key=value flag.
+type headerList map[string]string
+
+func (h *headerList) String() string {
+	var parts []string
+	for k, v := range *h {
+		parts = append(parts, k+"="+v)
+	}
+	return strings.Join(parts, ",")
+}
+
+func (h *headerList) Set(s string) error {
+	k, v, ok := strings.Cut(s, "=")
+	if !ok || strings.TrimSpace(k) == "" {
+		return fmt.Errorf("invalid header %q, want key=value", s)
+	}
+	if *h == nil {
+		*h = headerList{}
+	}
+	(*h)[strings.TrimSpace(k)] = strings.TrimSpace(v)
+	return nil
+}
+
+// runServe implements `ghaw serve`: poll repos without a terminal and serve
+// what is seen to other tools.
+func runServe(args []string) error {
+	fs := flag.NewFlagSet("serve", flag.ExitOnError)
+	var repos repoList
+	fs.Var(&repos, "repo", "Repository to poll as owner/repo; repeat or comma-separate for more (default: detected from the git remote)")
+	interval := fs.Int("i", 30, "Polling interval in seconds")
+	fs.IntVar(interval, "interval", 30, "Polling interval in seconds")
+	metricsAddr := fs.String("metrics", "", "Serve Prometheus metrics at /metrics on this address, e.g. :9090")
+	httpAddr := fs.String("http", "", "Serve a live HTML dashboard on this address, e.g. :8080")
+	otlpEndpoint := fs.String("otlp", "", "Export finished runs as traces to this OTLP/HTTP endpoint, e.g. http://localhost:4318")
+	backendName := fs.String("backend", "cli", backendUsage)
+	cacheDir := fs.String("cache-dir", "", "Directory for the REST response cache of the api and graphql backends (default: user cache dir)")
+	var otlpHeaders headerList
+	fs.Var(&otlpHeaders, "otlp-header", "Header to send with trace exports as key=value; repeatable")
+	fs.Parse(args)
+
+	if *httpAddr == "" && *metricsAddr == "" && *otlpEndpoint == "" {
+		return errors.New("nothing to serve: use --http, --metrics or --otlp")
+	}
+	if len(repos) == 0 {
+		repo, err := gh.DetectRepo()
+		if err != nil {
+			return err
+		}
+		repos = repoList{repo}
+	}
+
+	backend, err := newBackend(*backendName, *cacheDir)
+	if err != nil {
+		return err
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	p := poller.New(backend, repos, time.Duration(*interval)*time.Second)
+	if *otlpEndpoint != "" {
+		exp := &otlp.Exporter{Endpoint: *otlpEndpoint, Headers: otlpHeaders}
+		p.OnCompleted(func(repo string, d *types.RunDetail) {
+			if err := exp.Export(ctx, otlp.Trace(repo, d)); err != nil {
+				fmt.Fprintf(os.Stderr, "%s run %d: %v\n", repo, d.DatabaseID, err)
+			}
+		})
+		fmt.Fprintf(os.Stderr, "Exporting finished runs of %s to %s\n", repos.String(), exp.URL())
+	}
+	// Endpoints by address; --metrics and --http may share one.
+	muxes := make(map[string]*http.ServeMux)
+	mux := func(addr string) *http.ServeMux {
+		if muxes[addr] == nil {
+			muxes[addr] = http.NewServeMux()
+		}
+		return muxes[addr]
+	}
+	if *metricsAddr != "" {
+		mux(*metricsAddr).Handle("/metrics", metrics.Handler(p))
+		fmt.Fprintf(os.Stderr, "Serving metrics for %s on %s/metrics\n", repos.String(), *metricsAddr)
+	}
+	if *httpAddr != "" {
+		mux(*httpAddr).Handle("/", web.Handler(p))
+		fmt.Fprintf(os.Stderr, "Serving the dashboard of %s on %s\n", repos.String(), *httpAddr)
+	}
+	if len(muxes) == 0 {
+		p.Run(ctx)
+		return nil
+	}
+	go p.Run(ctx)
+
+	errs := make(chan error, len(muxes))
+	for addr, mux := range muxes {
+		srv := &http.Server{
+			Addr:    addr,
+			Handler: mux,
+			// Cancels event streams on shutdown.
+			BaseContext: func(net.Listener) context.Context { return ctx },
+		}
+		go func() {
+			<-ctx.Done()
+			shutdown, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			defer cancel()
+			srv.Shutdown(shutdown)
+		}()
+		go func() {
+			if err := srv.ListenAndServe(); !errors.Is(err, http.ErrServerClosed) {
+				errs <- err
+				return
+			}
+			errs <- nil
+		}()
+	}
+	for range muxes {
+		if err := <-errs; err != nil {
+			stop()
+			return err
+		}
+	}
+	return nil
+}
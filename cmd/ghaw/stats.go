@@ -0,0 +1,208 @@
+package main
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"text/tabwriter"
+	"time"
+
+	"github.com/dzoba/github-actions-watcher/internal/gh"
+	"github.com/dzoba/github-actions-watcher/internal/stats"
+)
+
+// runStats implements `ghaw stats`: the success-rate dashboard of a repo,
+// printed as a table or exported as JSON or CSV.
+func runStats(args []string) error {
+	fs := flag.NewFlagSet("stats", flag.ExitOnError)
+	repo := fs.String("repo", "", "Repository as owner/repo (default: detected from the git remote)")
+	windowsFlag := fs.String("windows", "24h,7d,30d", "Comma-separated periods to compute rates over")
+	output := fs.String("output", "text", "Output format: text, json or csv")
+	fs.Parse(args)
+
+	windows, err := stats.ParseWindows(*windowsFlag)
+	if err != nil {
+		return err
+	}
+	switch *output {
+	case "text", "json", "csv":
+	default:
+		return fmt.Errorf("unknown output format %q (want text, json or csv)", *output)
+	}
+	if *repo == "" {
+		if *repo, err = gh.DetectRepo(); err != nil {
+			return err
+		}
+	}
+
+	ctx := context.Background()
+	now := time.Now()
+	defaultBranch, err := gh.FetchDefaultBranch(ctx, *repo)
+	if err != nil {
+		return err
+	}
+	runs, err := gh.FetchRunsSince(ctx, *repo, stats.Since(windows, now), stats.MaxRuns)
+	if err != nil {
+		return err
+	}
+	d := stats.BuildDashboard(runs, windows, defaultBranch, now)
+
+	switch *output {
+	case "json":
+		return writeStatsJSON(os.Stdout, *repo, now, d)
+	case "csv":
+		return writeStatsCSV(os.Stdout, d)
+	}
+	return writeStatsText(os.Stdout, *repo, d)
+}
+
+type rateJSON struct {
+	Window      string  `json:"window"`
+	Runs        int     `json:"runs"`
+	Success     int     `json:"success"`
+	Failure     int     `json:"failure"`
+	Cancelled   int     `json:"cancelled"`
+	SuccessRate float64 `json:"successRate"`
+	FailureRate float64 `json:"failureRate"`
+	CancelRate  float64 `json:"cancelRate"`
+	Partial     bool    `json:"partial,omitempty"`
+}
+
+type rowJSON struct {
+	Workflow string       `json:"workflow,omitempty"`
+	Branch   string       `json:"branch,omitempty"`
+	Rates    []rateJSON   `json:"rates"`
+	Streak   stats.Streak `json:"streak"`
+}
+
+type statsJSON struct {
+	Repo          string    `json:"repo"`
+	GeneratedAt   time.Time `json:"generatedAt"`
+	DefaultBranch string    `json:"defaultBranch"`
+	MTTRSeconds   float64   `json:"mttrSeconds"`
+	Recoveries    int       `json:"recoveries"`
+	PartialSince  time.Time `json:"partialSince,omitzero"`
+	Workflows     []rowJSON `json:"workflows"`
+	Branches      []rowJSON `json:"branches"`
+}
+
+func rowsJSON(d stats.Dashboard, rows []stats.RateRow) []rowJSON {
+	out := make([]rowJSON, 0, len(rows))
+	for _, r := range rows {
+		row := rowJSON{Workflow: r.Workflow, Branch: r.Branch, Streak: r.Streak}
+		for i, rates := range r.Windows {
+			row.Rates = append(row.Rates, rateJSON{
+				Window:      d.Windows[i].Label,
+				Runs:        rates.Runs,
+				Success:     rates.Success,
+				Failure:     rates.Failure,
+				Cancelled:   rates.Cancelled,
+				SuccessRate: rates.SuccessRate(),
+				FailureRate: rates.FailureRate(),
+				CancelRate:  rates.CancelRate(),
+				Partial:     d.Partial[i],
+			})
+		}
+		out = append(out, row)
+	}
+	return out
+}
+
+func writeStatsJSON(w io.Writer, repo string, now time.Time, d stats.Dashboard) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(statsJSON{
+		Repo:          repo,
+		GeneratedAt:   now.UTC(),
+		DefaultBranch: d.DefaultBranch,
+		MTTRSeconds:   d.MTTR.Seconds(),
+		Recoveries:    d.Recoveries,
+		PartialSince:  d.Since.UTC(),
+		Workflows:     rowsJSON(d, d.Workflows),
+		Branches:      rowsJSON(d, d.Branches),
+	})
+}
+
+// writeStatsCSV writes one line per workflow or branch and window. Streaks
+// are signed: positive for green, negative for red. partial is true for
+// windows older than the runs fetched.
+func writeStatsCSV(w io.Writer, d stats.Dashboard) error {
+	cw := csv.NewWriter(w)
+	cw.Write([]string{"kind", "name", "window", "runs", "success", "failure", "cancelled", "success_rate", "streak", "partial"})
+	write := func(kind string, rows []stats.RateRow) {
+		for _, r := range rows {
+			name := r.Workflow
+			if kind == "branch" {
+				name = r.Branch
+			}
+			streak := r.Streak.Runs
+			if !r.Streak.Green {
+				streak = -streak
+			}
+			for i, rates := range r.Windows {
+				cw.Write([]string{
+					kind, name, d.Windows[i].Label,
+					strconv.Itoa(rates.Runs),
+					strconv.Itoa(rates.Success),
+					strconv.Itoa(rates.Failure),
+					strconv.Itoa(rates.Cancelled),
+					strconv.FormatFloat(rates.SuccessRate(), 'f', 4, 64),
+					strconv.Itoa(streak),
+					strconv.FormatBool(d.Partial[i]),
+				})
+			}
+		}
+	}
+	write("workflow", d.Workflows)
+	write("branch", d.Branches)
+	cw.Flush()
+	return cw.Error()
+}
+
+func writeStatsText(w io.Writer, repo string, d stats.Dashboard) error {
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	fmt.Fprintf(tw, "%s (default branch %s)\n", repo, d.DefaultBranch)
+	if d.Recoveries > 0 {
+		fmt.Fprintf(tw, "Mean time to recovery: %s over %d recoveries\n", d.MTTR.Round(time.Second), d.Recoveries)
+	}
+	table := func(title string, rows []stats.RateRow) {
+		fmt.Fprintf(tw, "\n%s", title)
+		for _, win := range d.Windows {
+			fmt.Fprintf(tw, "\t%s", win.Label)
+		}
+		fmt.Fprint(tw, "\tSTREAK\n")
+		for _, r := range rows {
+			name := r.Workflow + r.Branch
+			fmt.Fprint(tw, name)
+			for i, rates := range r.Windows {
+				switch {
+				case rates.Runs == 0:
+					fmt.Fprint(tw, "\t-")
+				case d.Partial[i]:
+					fmt.Fprintf(tw, "\t%.0f%% of ≥%d", rates.SuccessRate()*100, rates.Runs)
+				default:
+					fmt.Fprintf(tw, "\t%.0f%% of %d", rates.SuccessRate()*100, rates.Runs)
+				}
+			}
+			switch {
+			case r.Streak.Runs == 0:
+				fmt.Fprint(tw, "\t-\n")
+			case r.Streak.Green:
+				fmt.Fprintf(tw, "\t%d green\n", r.Streak.Runs)
+			default:
+				fmt.Fprintf(tw, "\t%d red\n", r.Streak.Runs)
+			}
+		}
+	}
+	table("WORKFLOW", d.Workflows)
+	table("BRANCH", d.Branches)
+	if !d.Since.IsZero() {
+		fmt.Fprintf(tw, "\n≥: partial, only the latest %d runs were fetched, since %s\n", stats.MaxRuns, d.Since.Local().Format("2006-01-02 15:04"))
+	}
+	return tw.Flush()
+}
@@ -0,0 +1,89 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/dzoba/github-actions-watcher/internal/stats"
+	"github.com/dzoba/github-actions-watcher/internal/types"
+)
+
+func testDashboard() stats.Dashboard {
+	now := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	run := func(id int, c types.RunConclusion) types.WorkflowRun {
+		created := now.Add(-time.Duration(id) * time.Hour)
+		return types.WorkflowRun{
+			DatabaseID: id, WorkflowName: "CI", HeadBranch: "main",
+			Status: types.StatusCompleted, Conclusion: c,
+			CreatedAt: created.Format(time.RFC3339), UpdatedAt: created.Add(time.Minute).Format(time.RFC3339),
+		}
+	}
+	runs := []types.WorkflowRun{run(1, types.ConclusionSuccess), run(2, types.ConclusionFailure)}
+	return stats.BuildDashboard(runs, stats.DefaultWindows[:1], "main", now)
+}
+
+func TestWriteStatsCSV(t *testing.T) {
+	var buf bytes.Buffer
+	if err := writeStatsCSV(&buf, testDashboard()); err != nil {
+		t.Fatal(err)
+	}
+	want := `kind,name,window,runs,success,failure,cancelled,success_rate,streak,partial
+workflow,CI,24h,2,1,1,0,0.5000,1,false
+branch,main,24h,2,1,1,0,0.5000,1,false
+`
+	if buf.String() != want {
+		t.Errorf("got:\n%s\nwant:\n%s", buf.String(), want)
+	}
+}
+
+func TestWriteStatsJSON(t *testing.T) {
+	var buf bytes.Buffer
+	if err := writeStatsJSON(&buf, "o/r", time.Now(), testDashboard()); err != nil {
+		t.Fatal(err)
+	}
+	var got statsJSON
+	if err := json.Unmarshal(buf.Bytes(), &got); err != nil {
+		t.Fatal(err)
+	}
+	if got.Repo != "o/r" || got.Recoveries != 1 || got.MTTRSeconds != 3600 {
+		t.Errorf("got %+v", got)
+	}
+	if len(got.Workflows) != 1 || got.Workflows[0].Rates[0].Window != "24h" || got.Workflows[0].Rates[0].SuccessRate != 0.5 {
+		t.Errorf("workflows = %+v", got.Workflows)
+	}
+	for _, want := range []string{`"successRate": 0.5`, `"streak": {`, `"green": true`} {
+		if !strings.Contains(buf.String(), want) {
+			t.Errorf("JSON lacks %s:\n%s", want, buf.String())
+		}
+	}
+	if strings.Contains(buf.String(), "partial") {
+		t.Errorf("complete windows are marked partial:\n%s", buf.String())
+	}
+}
+
+func TestWriteStatsPartial(t *testing.T) {
+	d := testDashboard()
+	d.Partial[0], d.Since = true, time.Date(2024, 3, 1, 6, 0, 0, 0, time.UTC)
+
+	var buf bytes.Buffer
+	if err := writeStatsJSON(&buf, "o/r", time.Now(), d); err != nil {
+		t.Fatal(err)
+	}
+	var got statsJSON
+	if err := json.Unmarshal(buf.Bytes(), &got); err != nil {
+		t.Fatal(err)
+	}
+	if !got.PartialSince.Equal(d.Since) || !got.Workflows[0].Rates[0].Partial {
+		t.Errorf("partial since %v, rates %+v", got.PartialSince, got.Workflows[0].Rates)
+	}
+	buf.Reset()
+	if err := writeStatsText(&buf, "o/r", d); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(buf.String(), "50% of ≥2") || !strings.Contains(buf.String(), "only the latest 1000 runs") {
+		t.Errorf("text doesn't mark the window partial:\n%s", buf.String())
+	}
+}
@@ -0,0 +1,202 @@
+// Package cost turns the billable time of runs into minutes and an estimated
+// price, to find the workflows that use up an Actions budget.
+package cost
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/dzoba/github-actions-watcher/internal/types"
+)
+
+// Runner OS names as the timing API reports them.
+const (
+	Ubuntu  = "UBUNTU"
+	Windows = "WINDOWS"
+	MacOS   = "MACOS"
+)
+
+// Rates is the price of a billable minute per runner OS.
+type Rates map[string]float64
+
+// DefaultRates are GitHub's list prices for standard hosted runners, in USD.
+var DefaultRates = Rates{Ubuntu: 0.008, Windows: 0.016, MacOS: 0.08}
+
+// ParseRates parses rates such as "ubuntu=0.008,macos=0.08". OSes not listed
+// keep their default rate.
+func ParseRates(s string) (Rates, error) {
+	rates := make(Rates, len(DefaultRates))
+	for os, r := range DefaultRates {
+		rates[os] = r
+	}
+	for _, part := range strings.Split(s, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		os, price, ok := strings.Cut(part, "=")
+		if !ok {
+			return nil, fmt.Errorf("invalid rate %q, want os=price", part)
+		}
+		p, err := strconv.ParseFloat(strings.TrimSpace(price), 64)
+		if err != nil || p < 0 {
+			return nil, fmt.Errorf("invalid price in %q", part)
+		}
+		rates[strings.ToUpper(strings.TrimSpace(os))] = p
+	}
+	return rates, nil
+}
+
+// Usage is billable minutes per runner OS and their estimated price.
+type Usage struct {
+	Minutes map[string]int
+	Cost    float64
+}
+
+// Total returns the billable minutes over all OSes.
+func (u Usage) Total() int {
+	n := 0
+	for _, m := range u.Minutes {
+		n += m
+	}
+	return n
+}
+
+// OSes returns the OSes with billable minutes, in name order.
+func (u Usage) OSes() []string {
+	var out []string
+	for os, m := range u.Minutes {
+		if m > 0 {
+			out = append(out, os)
+		}
+	}
+	sort.Strings(out)
+	return out
+}
+
+func (u *Usage) add(o Usage) {
+	if u.Minutes == nil {
+		u.Minutes = make(map[string]int)
+	}
+	for os, m := range o.Minutes {
+		u.Minutes[os] += m
+	}
+	u.Cost += o.Cost
+}
+
+// minutes rounds a job's time up to whole minutes, as GitHub bills it.
+func minutes(ms int64) int {
+	return int((ms + 59_999) / 60_000)
+}
+
+// RunUsage computes the billable minutes and price of one run. Each job is
+// rounded up to a whole minute; without per-job times the OS total is.
+func RunUsage(t *types.RunTiming, rates Rates) Usage {
+	u := Usage{Minutes: make(map[string]int)}
+	if t == nil {
+		return u
+	}
+	for os, ot := range t.Billable {
+		m := 0
+		if len(ot.JobMS) > 0 {
+			for _, ms := range ot.JobMS {
+				m += minutes(ms)
+			}
+		} else {
+			m = minutes(ot.TotalMS)
+		}
+		u.Minutes[os] += m
+		u.Cost += float64(m) * rates[os]
+	}
+	return u
+}
+
+// RunCost is the usage of one run.
+type RunCost struct {
+	Run types.WorkflowRun
+	Usage
+}
+
+// WorkflowCost is the usage of all the runs of one workflow.
+type WorkflowCost struct {
+	Workflow string
+	Runs     int
+	Usage
+}
+
+// Summary is the usage of a repo over a period.
+type Summary struct {
+	Total     Usage
+	Workflows []WorkflowCost // most expensive first
+	Runs      []RunCost      // most expensive first
+}
+
+// Summarize totals the usage of runs per workflow and for the repo. timings
+// maps run IDs to their timing; runs without one are skipped.
+func Summarize(runs []types.WorkflowRun, timings map[int]*types.RunTiming, rates Rates) Summary {
+	var s Summary
+	s.Total.Minutes = make(map[string]int)
+	byWorkflow := make(map[string]*WorkflowCost)
+	seen := make(map[int]bool)
+	for _, r := range runs {
+		t, ok := timings[r.DatabaseID]
+		if !ok || seen[r.DatabaseID] {
+			continue
+		}
+		seen[r.DatabaseID] = true
+		u := RunUsage(t, rates)
+		s.Runs = append(s.Runs, RunCost{Run: r, Usage: u})
+		s.Total.add(u)
+		wc, ok := byWorkflow[r.WorkflowName]
+		if !ok {
+			wc = &WorkflowCost{Workflow: r.WorkflowName}
+			byWorkflow[r.WorkflowName] = wc
+		}
+		wc.Runs++
+		wc.add(u)
+	}
+	for _, wc := range byWorkflow {
+		s.Workflows = append(s.Workflows, *wc)
+	}
+	sort.Slice(s.Workflows, func(i, j int) bool {
+		a, b := s.Workflows[i], s.Workflows[j]
+		if a.Cost != b.Cost {
+			return a.Cost > b.Cost
+		}
+		if a.Total() != b.Total() {
+			return a.Total() > b.Total()
+		}
+		return a.Workflow < b.Workflow
+	})
+	sort.SliceStable(s.Runs, func(i, j int) bool {
+		if s.Runs[i].Cost != s.Runs[j].Cost {
+			return s.Runs[i].Cost > s.Runs[j].Cost
+		}
+		return s.Runs[i].Total() > s.Runs[j].Total()
+	})
+	return s
+}
+
+// Describe summarizes usage in one line, e.g. "12 min (UBUNTU 10, MACOS 2)
+// ≈ $0.24".
+func Describe(u Usage) string {
+	oses := u.OSes()
+	if len(oses) == 0 {
+		return "0 min"
+	}
+	var b strings.Builder
+	fmt.Fprintf(&b, "%d min", u.Total())
+	if len(oses) > 1 {
+		parts := make([]string, len(oses))
+		for i, os := range oses {
+			parts[i] = fmt.Sprintf("%s %d", strings.ToLower(os), u.Minutes[os])
+		}
+		fmt.Fprintf(&b, " (%s)", strings.Join(parts, ", "))
+	} else {
+		fmt.Fprintf(&b, " %s", strings.ToLower(oses[0]))
+	}
+	fmt.Fprintf(&b, " ≈ $%.2f", u.Cost)
+	return b.String()
+}
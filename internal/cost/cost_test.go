@@ -0,0 +1,61 @@
+package cost
+
+import (
+	"math"
+	"testing"
+
+	"github.com/dzoba/github-actions-watcher/internal/types"
+)
+
+func TestParseRates(t *testing.T) {
+	rates, err := ParseRates("macos=0.1, linux-arm=0.005")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rates[MacOS] != 0.1 || rates["LINUX-ARM"] != 0.005 || rates[Ubuntu] != DefaultRates[Ubuntu] {
+		t.Errorf("rates = %v", rates)
+	}
+	for _, bad := range []string{"macos", "macos=x", "macos=-1"} {
+		if _, err := ParseRates(bad); err == nil {
+			t.Errorf("ParseRates(%q) succeeded, want error", bad)
+		}
+	}
+}
+
+func TestRunUsage(t *testing.T) {
+	timing := &types.RunTiming{Billable: map[string]types.OSTiming{
+		// two jobs of 30s and 61s bill as 1 + 2 minutes
+		Ubuntu: {TotalMS: 91_000, Jobs: 2, JobMS: []int64{30_000, 61_000}},
+		MacOS:  {TotalMS: 120_000, Jobs: 1},
+	}}
+	u := RunUsage(timing, DefaultRates)
+	if u.Minutes[Ubuntu] != 3 || u.Minutes[MacOS] != 2 || u.Total() != 5 {
+		t.Errorf("minutes = %v", u.Minutes)
+	}
+	if want := 3*0.008 + 2*0.08; math.Abs(u.Cost-want) > 1e-9 {
+		t.Errorf("cost = %v, want %v", u.Cost, want)
+	}
+	if got := Describe(u); got != "5 min (macos 2, ubuntu 3) ≈ $0.18" {
+		t.Errorf("Describe() = %q", got)
+	}
+}
+
+func TestSummarize(t *testing.T) {
+	run := func(id int, wf string) types.WorkflowRun { return types.WorkflowRun{DatabaseID: id, WorkflowName: wf} }
+	linux := func(ms int64) *types.RunTiming {
+		return &types.RunTiming{Billable: map[string]types.OSTiming{Ubuntu: {TotalMS: ms}}}
+	}
+	runs := []types.WorkflowRun{run(1, "CI"), run(2, "CI"), run(3, "Docs"), run(4, "Docs"), run(1, "CI")}
+	timings := map[int]*types.RunTiming{1: linux(60_000), 2: linux(600_000), 3: linux(60_000)}
+	s := Summarize(runs, timings, DefaultRates)
+
+	if s.Total.Total() != 12 {
+		t.Errorf("total minutes = %d, want 12", s.Total.Total())
+	}
+	if len(s.Workflows) != 2 || s.Workflows[0].Workflow != "CI" || s.Workflows[0].Runs != 2 || s.Workflows[0].Total() != 11 {
+		t.Errorf("workflows = %+v", s.Workflows)
+	}
+	if len(s.Runs) != 3 || s.Runs[0].Run.DatabaseID != 2 {
+		t.Errorf("runs = %+v, want run 2 first", s.Runs)
+	}
+}
@@ -0,0 +1,139 @@
+// Package dag reads the job dependency graph (`needs:`) of a workflow file
+// and lays it out as a left-to-right diagram for the terminal.
+package dag
+
+import (
+	"fmt"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/dzoba/github-actions-watcher/internal/matrix"
+)
+
+// Job is a job declared in a workflow file.
+type Job struct {
+	ID    string
+	Name  string // the job's name: key, if any
+	Needs []string
+}
+
+// Workflow is the job graph of a workflow file, with jobs in file order.
+type Workflow struct {
+	Name string
+	Jobs []Job
+}
+
+// Parse reads the jobs and their needs from a workflow file.
+func Parse(data []byte) (*Workflow, error) {
+	var doc yaml.Node
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return nil, fmt.Errorf("failed to parse workflow: %w", err)
+	}
+	if len(doc.Content) == 0 || doc.Content[0].Kind != yaml.MappingNode {
+		return nil, fmt.Errorf("failed to parse workflow: not a mapping")
+	}
+	root := doc.Content[0]
+
+	wf := &Workflow{}
+	if name := mappingValue(root, "name"); name != nil {
+		wf.Name = name.Value
+	}
+	jobs := mappingValue(root, "jobs")
+	if jobs == nil || jobs.Kind != yaml.MappingNode {
+		return nil, fmt.Errorf("failed to parse workflow: no jobs")
+	}
+	// Mapping nodes hold alternating keys and values, in file order.
+	for i := 0; i+1 < len(jobs.Content); i += 2 {
+		job := Job{ID: jobs.Content[i].Value}
+		body := jobs.Content[i+1]
+		if name := mappingValue(body, "name"); name != nil {
+			job.Name = name.Value
+		}
+		if needs := mappingValue(body, "needs"); needs != nil {
+			switch needs.Kind {
+			case yaml.ScalarNode:
+				job.Needs = []string{needs.Value}
+			case yaml.SequenceNode:
+				for _, n := range needs.Content {
+					job.Needs = append(job.Needs, n.Value)
+				}
+			}
+		}
+		wf.Jobs = append(wf.Jobs, job)
+	}
+	return wf, nil
+}
+
+func mappingValue(n *yaml.Node, key string) *yaml.Node {
+	if n.Kind != yaml.MappingNode {
+		return nil
+	}
+	for i := 0; i+1 < len(n.Content); i += 2 {
+		if n.Content[i].Value == key {
+			return n.Content[i+1]
+		}
+	}
+	return nil
+}
+
+// DisplayName is the name GitHub shows for the job: its name: key, or its id.
+func (j Job) DisplayName() string {
+	if j.Name != "" {
+		return j.Name
+	}
+	return j.ID
+}
+
+// Matches reports whether runJob, the name of a job in a run, was created
+// from this workflow job. Matrix jobs ("test (ubuntu, 1.22)"), names built
+// from expressions ("Test ${{ matrix.os }}") and reusable workflow calls
+// ("deploy / apply") all match their declaring job.
+func (j Job) Matches(runJob string) bool {
+	name := j.DisplayName()
+	if runJob == name || strings.HasPrefix(runJob, name+" / ") {
+		return true
+	}
+	if base, _, ok := matrix.Parse(runJob); ok && base == name {
+		return true
+	}
+	if i := strings.Index(name, "${{"); i >= 0 {
+		prefix := strings.TrimSpace(name[:i])
+		return prefix != "" && strings.HasPrefix(runJob, prefix)
+	}
+	return false
+}
+
+// Layers assigns each job to a column by the longest chain of needs leading
+// to it. Needs naming unknown jobs, and cycles, are ignored.
+func (w *Workflow) Layers() []int {
+	index := make(map[string]int, len(w.Jobs))
+	for i, j := range w.Jobs {
+		index[j.ID] = i
+	}
+	layer := make([]int, len(w.Jobs))
+	state := make([]int, len(w.Jobs)) // 0 unvisited, 1 visiting, 2 done
+	var visit func(i int) int
+	visit = func(i int) int {
+		switch state[i] {
+		case 1:
+			return -1 // cycle
+		case 2:
+			return layer[i]
+		}
+		state[i] = 1
+		for _, need := range w.Jobs[i].Needs {
+			if p, ok := index[need]; ok {
+				if l := visit(p); l >= 0 && l+1 > layer[i] {
+					layer[i] = l + 1
+				}
+			}
+		}
+		state[i] = 2
+		return layer[i]
+	}
+	for i := range w.Jobs {
+		visit(i)
+	}
+	return layer
+}
@@ -0,0 +1,102 @@
+package dag
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+const workflow = `
+name: CI
+on: push
+jobs:
+  build:
+    runs-on: ubuntu-latest
+  lint:
+    runs-on: ubuntu-latest
+  test:
+    name: Test
+    needs: build
+    strategy:
+      matrix:
+        os: [ubuntu, macos]
+  deploy:
+    needs: [test, lint, build]
+`
+
+func TestParse(t *testing.T) {
+	wf, err := Parse([]byte(workflow))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if wf.Name != "CI" {
+		t.Errorf("Name = %q, want CI", wf.Name)
+	}
+	want := []Job{
+		{ID: "build"},
+		{ID: "lint"},
+		{ID: "test", Name: "Test", Needs: []string{"build"}},
+		{ID: "deploy", Needs: []string{"test", "lint", "build"}},
+	}
+	if !reflect.DeepEqual(wf.Jobs, want) {
+		t.Errorf("Jobs = %+v, want %+v", wf.Jobs, want)
+	}
+}
+
+func TestParseInvalid(t *testing.T) {
+	for _, data := range []string{"", "- a\n- b\n", "name: x\n", "jobs: [\n"} {
+		if _, err := Parse([]byte(data)); err == nil {
+			t.Errorf("Parse(%q) succeeded, want error", data)
+		}
+	}
+}
+
+func TestLayers(t *testing.T) {
+	wf, _ := Parse([]byte(workflow))
+	if got, want := wf.Layers(), []int{0, 0, 1, 2}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Layers() = %v, want %v", got, want)
+	}
+
+	cyclic := &Workflow{Jobs: []Job{{ID: "a", Needs: []string{"b"}}, {ID: "b", Needs: []string{"a"}}, {ID: "c", Needs: []string{"missing"}}}}
+	for _, l := range cyclic.Layers() {
+		if l > 1 {
+			t.Errorf("cyclic Layers() = %v", cyclic.Layers())
+		}
+	}
+}
+
+func TestMatches(t *testing.T) {
+	tests := []struct {
+		job  Job
+		run  string
+		want bool
+	}{
+		{Job{ID: "build"}, "build", true},
+		{Job{ID: "test", Name: "Test"}, "Test (ubuntu, 1.22)", true},
+		{Job{ID: "test", Name: "Test ${{ matrix.os }}"}, "Test ubuntu", true},
+		{Job{ID: "deploy"}, "deploy / apply", true},
+		{Job{ID: "test", Name: "Test"}, "test", false},
+		{Job{ID: "x", Name: "${{ matrix.os }}"}, "ubuntu", false},
+	}
+	for _, tt := range tests {
+		if got := tt.job.Matches(tt.run); got != tt.want {
+			t.Errorf("%+v.Matches(%q) = %v, want %v", tt.job, tt.run, got, tt.want)
+		}
+	}
+}
+
+func TestRender(t *testing.T) {
+	wf, _ := Parse([]byte(workflow))
+	lines := wf.Render(func(i int) Label { return Label{Text: wf.Jobs[i].DisplayName()} })
+	got := strings.Join(lines, "\n")
+	want := strings.Join([]string{
+		"build──┬─▶Test──┬─▶deploy",
+		"       │        │",
+		"lint ──┼────────┤",
+		"       │        │",
+		"       └────────┘",
+	}, "\n")
+	if got != want {
+		t.Errorf("Render() =\n%s\nwant\n%s", got, want)
+	}
+}
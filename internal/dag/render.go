@@ -0,0 +1,218 @@
+package dag
+
+import (
+	"sort"
+	"strings"
+	"unicode/utf8"
+)
+
+// Label is how a job is drawn: its plain text, and a function that styles it
+// (e.g. colors it by status). Style may be nil.
+type Label struct {
+	Text  string
+	Style func(string) string
+}
+
+// node is a cell of the layout: a job, or an edge passing through a layer on
+// its way to a job further right (job == -1).
+type node struct {
+	job   int
+	preds []int // nodes in the previous layer
+}
+
+// Render draws the workflow left to right, one column per layer, with
+// box-drawing edges from each job to the jobs that need it. Jobs sit on every
+// other row so edges have room to turn.
+func (w *Workflow) Render(label func(job int) Label) []string {
+	if len(w.Jobs) == 0 {
+		return nil
+	}
+	layerOf := w.Layers()
+	nLayers := 0
+	for _, l := range layerOf {
+		nLayers = max(nLayers, l+1)
+	}
+	index := make(map[string]int, len(w.Jobs))
+	for i, j := range w.Jobs {
+		index[j.ID] = i
+	}
+
+	// Build nodes layer by layer so each job's needs already have nodes,
+	// routing edges that skip layers through pass-through nodes.
+	var nodes []node
+	cols := make([][]int, nLayers)
+	jobNode := make([]int, len(w.Jobs))
+	for l := 0; l < nLayers; l++ {
+		for i, j := range w.Jobs {
+			if layerOf[i] != l {
+				continue
+			}
+			n := node{job: i}
+			for _, need := range j.Needs {
+				p, ok := index[need]
+				if !ok || layerOf[p] >= l {
+					continue
+				}
+				src := jobNode[p]
+				for k := layerOf[p] + 1; k < l; k++ {
+					nodes = append(nodes, node{job: -1, preds: []int{src}})
+					src = len(nodes) - 1
+					cols[k] = append(cols[k], src)
+				}
+				n.preds = append(n.preds, src)
+			}
+			nodes = append(nodes, n)
+			jobNode[i] = len(nodes) - 1
+			cols[l] = append(cols[l], jobNode[i])
+		}
+	}
+
+	// Order each layer by the mean position of its predecessors to keep
+	// edges short and mostly uncrossed.
+	pos := make([]int, len(nodes))
+	for k, col := range cols {
+		if k > 0 {
+			center := make(map[int]float64, len(col))
+			for _, n := range col {
+				sum := 0.0
+				for _, p := range nodes[n].preds {
+					sum += float64(pos[p])
+				}
+				if len(nodes[n].preds) > 0 {
+					center[n] = sum / float64(len(nodes[n].preds))
+				}
+			}
+			sort.SliceStable(col, func(a, b int) bool { return center[col[a]] < center[col[b]] })
+		}
+		for i, n := range col {
+			pos[n] = i
+		}
+	}
+
+	height := 0
+	for _, col := range cols {
+		height = max(height, 2*len(col)-1)
+	}
+
+	labels := make([]Label, len(w.Jobs))
+	widths := make([]int, nLayers)
+	for i := range w.Jobs {
+		labels[i] = label(i)
+		widths[layerOf[i]] = max(widths[layerOf[i]], utf8.RuneCountInString(labels[i].Text))
+	}
+
+	connectors := make([]connector, nLayers-1)
+	for k := 1; k < nLayers; k++ {
+		connectors[k-1] = newConnector(height)
+		for _, n := range cols[k] {
+			for _, p := range nodes[n].preds {
+				connectors[k-1].edge(2*pos[p], 2*pos[n], nodes[n].job >= 0)
+			}
+		}
+	}
+
+	lines := make([]string, height)
+	for r := 0; r < height; r++ {
+		var b strings.Builder
+		for k, col := range cols {
+			width := max(widths[k], 1)
+			cell := strings.Repeat(" ", width)
+			if r%2 == 0 && r/2 < len(col) {
+				n := nodes[col[r/2]]
+				if n.job < 0 {
+					cell = strings.Repeat("─", width)
+				} else {
+					l := labels[n.job]
+					text := l.Text
+					if l.Style != nil {
+						text = l.Style(text)
+					}
+					cell = text + strings.Repeat(" ", width-utf8.RuneCountInString(l.Text))
+				}
+			}
+			b.WriteString(cell)
+			if k < len(connectors) {
+				b.WriteString(connectors[k].row(r))
+			}
+		}
+		lines[r] = strings.TrimRight(b.String(), " ")
+	}
+	return lines
+}
+
+// Directions an edge leaves a connector cell in.
+const (
+	up = 1 << iota
+	down
+	left
+	right
+	arrow
+)
+
+var boxChars = map[int]rune{
+	left | right:             '─',
+	up | down:                '│',
+	right | down:             '┌',
+	left | down:              '┐',
+	up | right:               '└',
+	left | up:                '┘',
+	left | right | down:      '┬',
+	left | right | up:        '┴',
+	up | down | right:        '├',
+	up | down | left:         '┤',
+	up | down | left | right: '┼',
+}
+
+// connectorWidth is the number of columns between two layers.
+const connectorWidth = 5
+
+// connector is the grid of edge segments between two adjacent layers.
+type connector [][connectorWidth]int
+
+func newConnector(height int) connector {
+	return make(connector, height)
+}
+
+// edge draws an edge from row from on the left to row to on the right,
+// turning in the middle column.
+func (c connector) edge(from, to int, toJob bool) {
+	c[from][0] |= left | right
+	c[from][1] |= left | right
+	switch {
+	case to == from:
+		c[from][2] |= left | right
+	case to > from:
+		c[from][2] |= left | down
+		for r := from + 1; r < to; r++ {
+			c[r][2] |= up | down
+		}
+		c[to][2] |= up | right
+	default:
+		c[from][2] |= left | up
+		for r := to + 1; r < from; r++ {
+			c[r][2] |= up | down
+		}
+		c[to][2] |= down | right
+	}
+	c[to][3] |= left | right
+	if toJob {
+		c[to][4] |= arrow
+	} else {
+		c[to][4] |= left | right
+	}
+}
+
+func (c connector) row(r int) string {
+	var b strings.Builder
+	for _, bits := range c[r] {
+		switch {
+		case bits == 0:
+			b.WriteByte(' ')
+		case bits&arrow != 0:
+			b.WriteRune('▶')
+		default:
+			b.WriteRune(boxChars[bits])
+		}
+	}
+	return b.String()
+}
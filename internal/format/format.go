@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"math"
 	"time"
+	"unicode/utf8"
 
 	"github.com/dzoba/github-actions-watcher/internal/types"
 )
@@ -53,7 +54,13 @@ func Duration(startStr, endStr string) string {
 	} else {
 		end = time.Now()
 	}
-	sec := int(math.Floor(end.Sub(start).Seconds()))
+	return DurationOf(end.Sub(start))
+}
+
+// DurationOf returns a human-friendly string for d (e.g. "5m 30s").
+// Negative durations are treated as zero.
+func DurationOf(d time.Duration) string {
+	sec := int(math.Floor(d.Seconds()))
 	if sec < 0 {
 		sec = 0
 	}
@@ -70,6 +77,53 @@ func Duration(startStr, endStr string) string {
 	return fmt.Sprintf("%dh %dm", hr, remMin)
 }
 
+// Short returns a compact duration for tight columns: "45s", "3m" or "2h5m".
+func Short(d time.Duration) string {
+	sec := int(math.Round(math.Abs(d.Seconds())))
+	switch {
+	case sec < 60:
+		return fmt.Sprintf("%ds", sec)
+	case sec < 3600:
+		return fmt.Sprintf("%dm", sec/60)
+	case sec%3600/60 == 0:
+		return fmt.Sprintf("%dh", sec/3600)
+	default:
+		return fmt.Sprintf("%dh%dm", sec/3600, sec%3600/60)
+	}
+}
+
+// Delta returns a signed duration for differences, e.g. "+1m 5s" or "-30s".
+func Delta(d time.Duration) string {
+	if d < 0 {
+		return "-" + DurationOf(-d)
+	}
+	return "+" + DurationOf(d)
+}
+
+var sparks = []rune("▁▂▃▄▅▆▇█")
+
+// Sparkline draws values as a row of block characters scaled between the
+// smallest and largest value.
+func Sparkline(values []float64) string {
+	if len(values) == 0 {
+		return ""
+	}
+	lo, hi := values[0], values[0]
+	for _, v := range values {
+		lo = math.Min(lo, v)
+		hi = math.Max(hi, v)
+	}
+	out := make([]rune, len(values))
+	for i, v := range values {
+		level := 0
+		if hi > lo {
+			level = int((v - lo) / (hi - lo) * float64(len(sparks)-1))
+		}
+		out[i] = sparks[level]
+	}
+	return string(out)
+}
+
 // Truncate shortens a string to maxLen, adding an ellipsis if truncated.
 func Truncate(s string, maxLen int) string {
 	if len(s) <= maxLen {
@@ -114,12 +168,14 @@ func Elapsed(startStr string) string {
 	return Duration(startStr, "")
 }
 
-// Pad right-pads s with spaces to width.
+// Pad right-pads s with spaces to width, counting runes so that symbols
+// such as "≥" take one column.
 func Pad(s string, width int) string {
-	if len(s) >= width {
+	n := utf8.RuneCountInString(s)
+	if n >= width {
 		return s
 	}
-	return s + spaces(width-len(s))
+	return s + spaces(width-n)
 }
 
 func spaces(n int) string {
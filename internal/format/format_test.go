@@ -59,6 +59,60 @@ func TestDuration(t *testing.T) {
 	}
 }
 
+func TestDurationOf(t *testing.T) {
+	tests := []struct {
+		d    time.Duration
+		want string
+	}{
+		{-5 * time.Second, "0s"},
+		{90 * time.Second, "1m 30s"},
+		{25 * time.Hour, "25h 0m"},
+	}
+	for _, tt := range tests {
+		if got := DurationOf(tt.d); got != tt.want {
+			t.Errorf("DurationOf(%v) = %q, want %q", tt.d, got, tt.want)
+		}
+	}
+}
+
+func TestShort(t *testing.T) {
+	tests := []struct {
+		d    time.Duration
+		want string
+	}{
+		{45 * time.Second, "45s"},
+		{-3 * time.Minute, "3m"},
+		{2 * time.Hour, "2h"},
+		{2*time.Hour + 5*time.Minute, "2h5m"},
+	}
+	for _, tt := range tests {
+		if got := Short(tt.d); got != tt.want {
+			t.Errorf("Short(%v) = %q, want %q", tt.d, got, tt.want)
+		}
+	}
+}
+
+func TestDelta(t *testing.T) {
+	if got := Delta(65 * time.Second); got != "+1m 5s" {
+		t.Errorf("Delta(65s) = %q", got)
+	}
+	if got := Delta(-30 * time.Second); got != "-30s" {
+		t.Errorf("Delta(-30s) = %q", got)
+	}
+}
+
+func TestSparkline(t *testing.T) {
+	if got := Sparkline([]float64{1, 5, 8, 1}); got != "▁▅█▁" {
+		t.Errorf("Sparkline() = %q", got)
+	}
+	if got := Sparkline([]float64{3, 3}); got != "▁▁" {
+		t.Errorf("flat Sparkline() = %q", got)
+	}
+	if got := Sparkline(nil); got != "" {
+		t.Errorf("empty Sparkline() = %q", got)
+	}
+}
+
 func TestTruncate(t *testing.T) {
 	tests := []struct {
 		s      string
@@ -142,4 +196,7 @@ func TestPad(t *testing.T) {
 	if got := Pad("hello", 3); got != "hello" {
 		t.Errorf("Pad should not truncate, got %q", got)
 	}
+	if got := Pad("≥5", 4); got != "≥5  " {
+		t.Errorf("Pad(≥5, 4) = %q", got)
+	}
 }
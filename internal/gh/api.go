@@ -0,0 +1,295 @@
+package gh
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"os/exec"
+	"strings"
+
+	"github.com/dzoba/github-actions-watcher/internal/types"
+)
+
+// DefaultAPIURL is the GitHub REST API.
+const DefaultAPIURL = "https://api.github.com"
+
+// API is the Backend that calls the GitHub REST API over HTTP instead of
+// running gh for every request. Responses go through a Cache, so polls that
+// find nothing changed are answered with 304 Not Modified, which doesn't count
+// against the rate limit, and the last known runs are available at startup.
+type API struct {
+	baseURL string
+	token   string
+	client  *http.Client
+	cache   *Cache
+}
+
+var _ Backend = (*API)(nil)
+
+// NewAPI returns an API backend caching in cache, authenticated with Token.
+func NewAPI(cache *Cache) (*API, error) {
+	token, err := Token()
+	if err != nil {
+		return nil, err
+	}
+	return NewAPIWith(DefaultAPIURL, token, cache), nil
+}
+
+// Token returns the token to call the API with: GH_TOKEN or GITHUB_TOKEN, or
+// else gh's own.
+func Token() (string, error) {
+	for _, env := range []string{"GH_TOKEN", "GITHUB_TOKEN"} {
+		if token := os.Getenv(env); token != "" {
+			return token, nil
+		}
+	}
+	out, err := exec.Command("gh", "auth", "token").Output()
+	if err != nil {
+		return "", fmt.Errorf("no GitHub token: set GH_TOKEN or run gh auth login: %w", err)
+	}
+	return strings.TrimSpace(string(out)), nil
+}
+
+// NewAPIWith returns an API backend for the API at baseURL, e.g. a GitHub
+// Enterprise server or a test server.
+func NewAPIWith(baseURL, token string, cache *Cache) *API {
+	return &API{
+		baseURL: strings.TrimRight(baseURL, "/"),
+		token:   token,
+		client:  &http.Client{Transport: &Transport{Cache: cache}},
+		cache:   cache,
+	}
+}
+
+func (a *API) url(path string) string { return a.baseURL + "/" + path }
+
+// get decodes the JSON response of a GET request into v and returns the URL
+// of the next page, if there is one.
+func (a *API) get(ctx context.Context, url string, v any) (next string, err error) {
+	return a.fetch(ctx, url, v, true)
+}
+
+// fetch is get, optionally bypassing the cache. Transient failures are
+// retried.
+func (a *API) fetch(ctx context.Context, url string, v any, cache bool) (next string, err error) {
+	err = retry(ctx, func(ctx context.Context) error {
+		next, err = a.fetchOnce(ctx, url, v, cache)
+		return err
+	})
+	return next, err
+}
+
+func (a *API) fetchOnce(ctx context.Context, url string, v any, cache bool) (next string, err error) {
+	ctx, cancel := context.WithTimeout(ctx, RequestTimeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return "", err
+	}
+	if !cache {
+		req.Header.Set("Cache-Control", "no-store")
+	}
+	req.Header.Set("Accept", "application/vnd.github+json")
+	req.Header.Set("X-GitHub-Api-Version", "2022-11-28")
+	if a.token != "" {
+		req.Header.Set("Authorization", "Bearer "+a.token)
+	}
+	resp, err := a.client.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("GET %s failed: %w", url, requestError(ctx, err))
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		var apiErr struct {
+			Message string `json:"message"`
+		}
+		json.NewDecoder(resp.Body).Decode(&apiErr)
+		if apiErr.Message == "" {
+			apiErr.Message = resp.Status
+		}
+		return "", fmt.Errorf("GET %s failed: %w", url, &Error{Kind: statusKind(resp, apiErr.Message), Message: apiErr.Message})
+	}
+	if err := json.NewDecoder(resp.Body).Decode(v); err != nil {
+		return "", fmt.Errorf("failed to parse %s: %w", url, err)
+	}
+	return nextLink(resp.Header.Get("Link")), nil
+}
+
+// nextLink returns the rel="next" URL of a Link header.
+func nextLink(link string) string {
+	for _, part := range strings.Split(link, ",") {
+		url, rel, ok := strings.Cut(part, ";")
+		if ok && strings.TrimSpace(rel) == `rel="next"` {
+			return strings.Trim(strings.TrimSpace(url), "<>")
+		}
+	}
+	return ""
+}
+
+// restRun is a workflow run as the REST API returns it.
+type restRun struct {
+	ID           int                 `json:"id"`
+	Name         string              `json:"name"`
+	DisplayTitle string              `json:"display_title"`
+	Event        string              `json:"event"`
+	HeadBranch   string              `json:"head_branch"`
+	HeadSHA      string              `json:"head_sha"`
+	RunNumber    int                 `json:"run_number"`
+	RunAttempt   int                 `json:"run_attempt"`
+	Status       types.RunStatus     `json:"status"`
+	Conclusion   types.RunConclusion `json:"conclusion"`
+	CreatedAt    string              `json:"created_at"`
+	RunStartedAt string              `json:"run_started_at"`
+	UpdatedAt    string              `json:"updated_at"`
+	HTMLURL      string              `json:"html_url"`
+}
+
+// run converts to the shape gh reports. The REST API names a run after its
+// workflow, which is what gh reports as the workflow name.
+func (r restRun) run() types.WorkflowRun {
+	return types.WorkflowRun{
+		DatabaseID:   r.ID,
+		DisplayTitle: r.DisplayTitle,
+		Event:        r.Event,
+		HeadBranch:   r.HeadBranch,
+		HeadSha:      r.HeadSHA,
+		Name:         r.Name,
+		Number:       r.RunNumber,
+		Attempt:      r.RunAttempt,
+		Status:       r.Status,
+		Conclusion:   r.Conclusion,
+		CreatedAt:    r.CreatedAt,
+		StartedAt:    r.RunStartedAt,
+		UpdatedAt:    r.UpdatedAt,
+		URL:          r.HTMLURL,
+		WorkflowName: r.Name,
+	}
+}
+
+type restStep struct {
+	Name        string              `json:"name"`
+	Status      types.RunStatus     `json:"status"`
+	Conclusion  types.RunConclusion `json:"conclusion"`
+	Number      int                 `json:"number"`
+	StartedAt   string              `json:"started_at"`
+	CompletedAt string              `json:"completed_at"`
+}
+
+type restJob struct {
+	ID          int                 `json:"id"`
+	Name        string              `json:"name"`
+	Status      types.RunStatus     `json:"status"`
+	Conclusion  types.RunConclusion `json:"conclusion"`
+	CreatedAt   string              `json:"created_at"`
+	StartedAt   string              `json:"started_at"`
+	CompletedAt string              `json:"completed_at"`
+	HTMLURL     string              `json:"html_url"`
+	Labels      []string            `json:"labels"`
+	RunnerName  string              `json:"runner_name"`
+	Steps       []restStep          `json:"steps"`
+}
+
+func (j restJob) job() types.Job {
+	job := types.Job{
+		Name:        j.Name,
+		Status:      j.Status,
+		Conclusion:  j.Conclusion,
+		StartedAt:   j.StartedAt,
+		CompletedAt: j.CompletedAt,
+		URL:         j.HTMLURL,
+		DatabaseID:  j.ID,
+		CreatedAt:   j.CreatedAt,
+		Labels:      j.Labels,
+		RunnerName:  j.RunnerName,
+	}
+	for _, s := range j.Steps {
+		job.Steps = append(job.Steps, types.Step(s))
+	}
+	return job
+}
+
+func (a *API) runsURL(repo string) string {
+	return a.url(fmt.Sprintf("repos/%s/actions/runs?per_page=20", repo))
+}
+
+func decodeRuns(page struct {
+	WorkflowRuns []restRun `json:"workflow_runs"`
+}) []types.WorkflowRun {
+	runs := make([]types.WorkflowRun, len(page.WorkflowRuns))
+	for i, r := range page.WorkflowRuns {
+		runs[i] = r.run()
+	}
+	return runs
+}
+
+// FetchRuns returns the 20 most recent workflow runs for a repo.
+func (a *API) FetchRuns(ctx context.Context, repo string) ([]types.WorkflowRun, error) {
+	var page struct {
+		WorkflowRuns []restRun `json:"workflow_runs"`
+	}
+	if _, err := a.get(ctx, a.runsURL(repo), &page); err != nil {
+		return nil, err
+	}
+	return decodeRuns(page), nil
+}
+
+// CachedRuns returns the runs of a repo as of the last fetch, from this or an
+// earlier process, without a request.
+func (a *API) CachedRuns(repo string) ([]types.WorkflowRun, bool) {
+	body, ok := a.cache.Lookup(a.runsURL(repo))
+	if !ok {
+		return nil, false
+	}
+	var page struct {
+		WorkflowRuns []restRun `json:"workflow_runs"`
+	}
+	if json.Unmarshal(body, &page) != nil {
+		return nil, false
+	}
+	return decodeRuns(page), true
+}
+
+// fetchRun returns a single run without its jobs.
+func (a *API) fetchRun(ctx context.Context, repo string, runID int) (restRun, error) {
+	var run restRun
+	_, err := a.get(ctx, a.url(fmt.Sprintf("repos/%s/actions/runs/%d", repo, runID)), &run)
+	return run, err
+}
+
+// FetchRunDetail returns a single run with its jobs and steps.
+func (a *API) FetchRunDetail(ctx context.Context, repo string, runID int) (*types.RunDetail, error) {
+	run, err := a.fetchRun(ctx, repo, runID)
+	if err != nil {
+		return nil, err
+	}
+	detail := &types.RunDetail{WorkflowRun: run.run(), Jobs: []types.Job{}}
+	next := a.url(fmt.Sprintf("repos/%s/actions/runs/%d/jobs?per_page=100", repo, runID))
+	for next != "" {
+		var page struct {
+			Jobs []restJob `json:"jobs"`
+		}
+		var err error
+		if next, err = a.get(ctx, next, &page); err != nil {
+			return nil, err
+		}
+		for _, j := range page.Jobs {
+			detail.Jobs = append(detail.Jobs, j.job())
+		}
+	}
+	return detail, nil
+}
+
+// FetchRateLimit returns the core API rate limit.
+func (a *API) FetchRateLimit(ctx context.Context) (*RateLimit, error) {
+	var resp struct {
+		Resources struct {
+			Core RateLimit `json:"core"`
+		} `json:"resources"`
+	}
+	if _, err := a.fetch(ctx, a.url("rate_limit"), &resp, false); err != nil {
+		return nil, err
+	}
+	return &resp.Resources.Core, nil
+}
@@ -0,0 +1,135 @@
+package gh
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+)
+
+// fakeGitHub serves a run list with an ETag, a run whose jobs span two pages
+// and the rate limit, counting full responses and 304s.
+type fakeGitHub struct {
+	*httptest.Server
+	etag         string
+	full, notMod atomic.Int32
+}
+
+func newFakeGitHub(t *testing.T) *fakeGitHub {
+	f := &fakeGitHub{etag: `"v1"`}
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /repos/o/r/actions/runs", func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer tok" {
+			http.Error(w, `{"message":"Bad credentials"}`, http.StatusUnauthorized)
+			return
+		}
+		if r.Header.Get("If-None-Match") == f.etag {
+			f.notMod.Add(1)
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		f.full.Add(1)
+		w.Header().Set("ETag", f.etag)
+		fmt.Fprintf(w, `{"workflow_runs":[{"id":7,"name":"CI","display_title":"Fix","head_branch":"main","run_attempt":1,"status":"completed","conclusion":"success","html_url":"https://github.com/o/r/actions/runs/7","etag":%q}]}`, f.etag)
+	})
+	mux.HandleFunc("GET /repos/o/r/actions/runs/7", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"id":7,"name":"CI","status":"completed","conclusion":"failure"}`)
+	})
+	mux.HandleFunc("GET /repos/o/r/actions/runs/7/jobs", func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("page") == "2" {
+			fmt.Fprint(w, `{"jobs":[{"id":2,"name":"test","conclusion":"failure","runner_name":"r2","labels":["ubuntu-latest"],"steps":[{"name":"go test","number":1,"conclusion":"failure"}]}]}`)
+			return
+		}
+		w.Header().Set("Link", fmt.Sprintf(`<%s/repos/o/r/actions/runs/7/jobs?per_page=100&page=2>; rel="next", <x>; rel="last"`, f.URL))
+		fmt.Fprint(w, `{"jobs":[{"id":1,"name":"build","conclusion":"success"}]}`)
+	})
+	mux.HandleFunc("GET /rate_limit", func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("If-None-Match") != "" {
+			t.Error("rate limit request was conditional")
+		}
+		w.Header().Set("ETag", `"rl"`)
+		fmt.Fprint(w, `{"resources":{"core":{"limit":5000,"remaining":4999,"reset":1700000000}}}`)
+	})
+	f.Server = httptest.NewServer(mux)
+	t.Cleanup(f.Close)
+	return f
+}
+
+func TestAPIConditionalRequests(t *testing.T) {
+	f := newFakeGitHub(t)
+	dir := t.TempDir()
+	cache, err := NewCache(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	api := NewAPIWith(f.URL, "tok", cache)
+
+	for i := 0; i < 3; i++ {
+		runs, err := api.FetchRuns(context.Background(), "o/r")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(runs) != 1 || runs[0].DatabaseID != 7 || runs[0].WorkflowName != "CI" || runs[0].URL == "" {
+			t.Fatalf("runs = %+v", runs)
+		}
+	}
+	if f.full.Load() != 1 || f.notMod.Load() != 2 {
+		t.Errorf("%d full responses and %d 304s, want 1 and 2", f.full.Load(), f.notMod.Load())
+	}
+
+	// Responses may be of private repos.
+	files, _ := filepath.Glob(filepath.Join(dir, "*.json"))
+	for _, name := range files {
+		if fi, err := os.Stat(name); err != nil || fi.Mode().Perm() != 0o600 {
+			t.Errorf("%s: mode %v, %v", name, fi.Mode(), err)
+		}
+	}
+	if len(files) == 0 {
+		t.Error("nothing cached on disk")
+	}
+
+	// A new process starts from the cache on disk.
+	cache, _ = NewCache(dir)
+	restarted := NewAPIWith(f.URL, "tok", cache)
+	if runs, ok := restarted.CachedRuns("o/r"); !ok || len(runs) != 1 || runs[0].DisplayTitle != "Fix" {
+		t.Errorf("CachedRuns() = %+v, %v", runs, ok)
+	}
+	if _, err := restarted.FetchRuns(context.Background(), "o/r"); err != nil || f.notMod.Load() != 3 {
+		t.Errorf("revalidating after a restart: %v, %d 304s", err, f.notMod.Load())
+	}
+
+	// A changed list is fetched in full.
+	f.etag = `"v2"`
+	if _, err := api.FetchRuns(context.Background(), "o/r"); err != nil || f.full.Load() != 2 {
+		t.Errorf("after a change: %v, %d full responses", err, f.full.Load())
+	}
+
+	if _, err := NewAPIWith(f.URL, "wrong", cache).FetchRuns(context.Background(), "o/r"); err == nil || err.Error() != "GET "+f.URL+"/repos/o/r/actions/runs?per_page=20 failed: Bad credentials" {
+		t.Errorf("bad token: %v", err)
+	}
+}
+
+func TestAPIRunDetail(t *testing.T) {
+	f := newFakeGitHub(t)
+	cache, _ := NewCache("")
+	api := NewAPIWith(f.URL, "tok", cache)
+
+	d, err := api.FetchRunDetail(context.Background(), "o/r", 7)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(d.Jobs) != 2 || d.Jobs[1].Name != "test" || d.Jobs[1].RunnerName != "r2" || len(d.Jobs[1].Steps) != 1 {
+		t.Errorf("jobs = %+v", d.Jobs)
+	}
+
+	for i := 0; i < 2; i++ {
+		rl, err := api.FetchRateLimit(context.Background())
+		if err != nil || rl.Remaining != 4999 {
+			t.Errorf("FetchRateLimit() = %+v, %v", rl, err)
+		}
+	}
+}
@@ -0,0 +1,63 @@
+package gh
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/dzoba/github-actions-watcher/internal/types"
+)
+
+// Backend is how ghaw polls GitHub. CLI is the implementation backed by the
+// gh command, API the one calling the REST API with a response cache and
+// GraphQL the one batching every repo into a query; tests substitute fakes.
+// Each call gives up when ctx is done or after RequestTimeout.
+type Backend interface {
+	FetchRuns(ctx context.Context, repo string) ([]types.WorkflowRun, error)
+	FetchRunDetail(ctx context.Context, repo string, runID int) (*types.RunDetail, error)
+	FetchRateLimit(ctx context.Context) (*RateLimit, error)
+}
+
+// RunCache is implemented by backends that keep the run lists they fetched,
+// so they can be shown before the first fetch of a session returns.
+type RunCache interface {
+	CachedRuns(repo string) ([]types.WorkflowRun, bool)
+}
+
+var _ RunCache = (*API)(nil)
+
+// RateLimit is the state of the core REST API rate limit.
+type RateLimit struct {
+	Limit     int   `json:"limit"`
+	Remaining int   `json:"remaining"`
+	Reset     int64 `json:"reset"` // Unix time the limit resets
+}
+
+// CLI is the Backend that shells out to gh.
+type CLI struct{}
+
+var _ Backend = CLI{}
+
+func (CLI) FetchRuns(ctx context.Context, repo string) ([]types.WorkflowRun, error) {
+	return FetchRuns(ctx, repo)
+}
+
+func (CLI) FetchRunDetail(ctx context.Context, repo string, runID int) (*types.RunDetail, error) {
+	return FetchRunDetail(ctx, repo, runID)
+}
+
+func (CLI) FetchRateLimit(ctx context.Context) (*RateLimit, error) { return FetchRateLimit(ctx) }
+
+// FetchRateLimit returns the core API rate limit. Checking it doesn't count
+// against it.
+func FetchRateLimit(ctx context.Context) (*RateLimit, error) {
+	out, err := output(ctx, "api", "rate_limit", "--jq", ".resources.core")
+	if err != nil {
+		return nil, fmt.Errorf("gh api rate_limit failed: %w", err)
+	}
+	var rl RateLimit
+	if err := json.Unmarshal(out, &rl); err != nil {
+		return nil, fmt.Errorf("failed to parse rate limit: %w", err)
+	}
+	return &rl, nil
+}
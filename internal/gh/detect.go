@@ -1,6 +1,8 @@
 package gh
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"os/exec"
 	"regexp"
@@ -14,7 +16,12 @@ var (
 
 // DetectRepo extracts "owner/repo" from the git origin remote URL.
 func DetectRepo() (string, error) {
-	out, err := exec.Command("git", "remote", "get-url", "origin").Output()
+	return detectRepo(context.Background())
+}
+
+// detectRepo is DetectRepo, giving up on git when ctx is done.
+func detectRepo(ctx context.Context) (string, error) {
+	out, err := exec.CommandContext(ctx, "git", "remote", "get-url", "origin").Output()
 	if err != nil {
 		return "", fmt.Errorf("no git remote found: %w", err)
 	}
@@ -28,3 +35,60 @@ func DetectRepo() (string, error) {
 	}
 	return "", fmt.Errorf("could not parse repo from remote URL: %s", url)
 }
+
+// Commit is a commit of the local checkout.
+type Commit struct {
+	SHA     string
+	Subject string
+}
+
+// Head is the state of the local checkout: the commit checked out, the
+// branch it is on and the commits not pushed yet.
+type Head struct {
+	Commit
+	Branch string   // empty when HEAD is detached
+	Ahead  []Commit // commits ahead of the upstream branch, newest first
+}
+
+// maxAhead caps the unpushed commits LocalHead lists.
+const maxAhead = 20
+
+// LocalHead reads HEAD's commit and branch from the git checkout in the
+// current directory. Without an upstream branch, the commits ahead are those
+// on no branch of origin.
+func LocalHead() (*Head, error) {
+	commits, err := gitLog("-1", "HEAD")
+	if err != nil {
+		return nil, fmt.Errorf("failed to read HEAD: %w", err)
+	}
+	if len(commits) == 0 {
+		return nil, errors.New("HEAD has no commits")
+	}
+	h := &Head{Commit: commits[0]}
+	if out, err := exec.Command("git", "symbolic-ref", "--quiet", "--short", "HEAD").Output(); err == nil {
+		h.Branch = strings.TrimSpace(string(out))
+	}
+	h.Ahead, err = gitLog("-n", fmt.Sprint(maxAhead), "@{upstream}..HEAD")
+	if err != nil {
+		h.Ahead, err = gitLog("-n", fmt.Sprint(maxAhead), "HEAD", "--not", "--remotes=origin")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to list unpushed commits: %w", err)
+	}
+	return h, nil
+}
+
+// gitLog lists the commits git log selects with args.
+func gitLog(args ...string) ([]Commit, error) {
+	out, err := exec.Command("git", append([]string{"log", "--format=%H%x00%s"}, args...)...).Output()
+	if err != nil {
+		return nil, err
+	}
+	var commits []Commit
+	for _, line := range strings.Split(strings.TrimSpace(string(out)), "\n") {
+		if sha, subject, ok := strings.Cut(line, "\x00"); ok {
+			commits = append(commits, Commit{SHA: sha, Subject: subject})
+		}
+	}
+	return commits, nil
+}
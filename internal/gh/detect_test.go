@@ -0,0 +1,49 @@
+package gh
+
+import (
+	"os/exec"
+	"testing"
+)
+
+func TestLocalHead(t *testing.T) {
+	dir := t.TempDir()
+	t.Chdir(dir)
+	t.Setenv("GIT_CONFIG_GLOBAL", "/dev/null")
+	git := func(args ...string) {
+		t.Helper()
+		args = append([]string{"-c", "user.name=t", "-c", "user.email=t@example.com"}, args...)
+		if out, err := exec.Command("git", args...).CombinedOutput(); err != nil {
+			t.Fatalf("git %v: %v\n%s", args, err, out)
+		}
+	}
+	git("init", "-q", "-b", "main")
+	git("remote", "add", "origin", "https://github.com/o/r.git")
+	git("commit", "-q", "--allow-empty", "-m", "pushed")
+	git("update-ref", "refs/remotes/origin/main", "HEAD")
+	git("commit", "-q", "--allow-empty", "-m", "first")
+	git("commit", "-q", "--allow-empty", "-m", "second")
+
+	// Without an upstream, commits on no branch of origin are ahead.
+	h, err := LocalHead()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if h.Branch != "main" || h.Subject != "second" || len(h.SHA) != 40 {
+		t.Errorf("head = %+v", h)
+	}
+	if len(h.Ahead) != 2 || h.Ahead[0].Subject != "second" || h.Ahead[1].Subject != "first" {
+		t.Errorf("ahead = %+v", h.Ahead)
+	}
+
+	// With one, those ahead of it.
+	git("branch", "-q", "--set-upstream-to=origin/main")
+	git("update-ref", "refs/remotes/origin/main", "HEAD~1")
+	if h, err = LocalHead(); err != nil || len(h.Ahead) != 1 || h.Ahead[0].Subject != "second" {
+		t.Errorf("ahead of upstream = %+v, %v", h, err)
+	}
+
+	git("checkout", "-q", "--detach")
+	if h, err = LocalHead(); err != nil || h.Branch != "" {
+		t.Errorf("detached head = %+v, %v", h, err)
+	}
+}
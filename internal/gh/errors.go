@@ -0,0 +1,208 @@
+package gh
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"math/rand/v2"
+	"net/http"
+	"os/exec"
+	"strings"
+	"time"
+)
+
+// ErrorKind is why a request to GitHub failed, as far as can be told.
+type ErrorKind int
+
+const (
+	ErrUnknown      ErrorKind = iota
+	ErrNotInstalled           // gh isn't on the PATH
+	ErrAuth                   // not logged in, or the token expired or was revoked
+	ErrNotFound               // the repo or run doesn't exist or isn't visible
+	ErrRateLimited            // the API rate limit ran out
+	ErrNetwork                // GitHub couldn't be reached, timed out or had an outage
+)
+
+// Error is a failed gh command or API request. Message is the cause as gh
+// printed it to stderr or the API reported it, rather than an exit status.
+type Error struct {
+	Kind    ErrorKind
+	Message string
+	Err     error // the underlying error, if any
+}
+
+func (e *Error) Error() string {
+	if e.Message == "" && e.Err != nil {
+		return e.Err.Error()
+	}
+	return e.Message
+}
+
+func (e *Error) Unwrap() error { return e.Err }
+
+// Transient reports whether the request may well succeed if repeated.
+func (e *Error) Transient() bool { return e.Kind == ErrNetwork }
+
+// Hint is what to do about the error, if anything can be done.
+func (e *Error) Hint() string {
+	switch e.Kind {
+	case ErrNotInstalled:
+		return "Install the GitHub CLI from https://cli.github.com."
+	case ErrAuth:
+		return "Run gh auth login, or set GH_TOKEN to a valid token."
+	case ErrNotFound:
+		return "Check the repo name and that your account can see it."
+	case ErrRateLimited:
+		return "Polls slow down until the rate limit resets."
+	case ErrNetwork:
+		return "Check your connection; polls keep retrying."
+	}
+	return ""
+}
+
+// KindOf returns the kind of err, or ErrUnknown if it isn't an *Error.
+func KindOf(err error) ErrorKind {
+	var e *Error
+	if errors.As(err, &e) {
+		return e.Kind
+	}
+	return ErrUnknown
+}
+
+// Explain returns err's message followed by what to do about it, for showing
+// to the user.
+func Explain(err error) string {
+	var e *Error
+	if errors.As(err, &e) {
+		if hint := e.Hint(); hint != "" {
+			return err.Error() + ". " + hint
+		}
+	}
+	return err.Error()
+}
+
+// classify tells the kind of a failure from gh's stderr or an API message.
+func classify(msg string) ErrorKind {
+	s := strings.ToLower(msg)
+	has := func(subs ...string) bool {
+		for _, sub := range subs {
+			if strings.Contains(s, sub) {
+				return true
+			}
+		}
+		return false
+	}
+	switch {
+	case has("rate limit", "http 429"):
+		return ErrRateLimited
+	case has("http 401", "bad credentials", "gh auth login", "authentication required", "token has expired"):
+		return ErrAuth
+	case has("http 404", "not found", "could not resolve to a repository"):
+		return ErrNotFound
+	case has("http 500", "http 502", "http 503", "http 504", "error connecting to", "no such host",
+		"connection refused", "connection reset", "i/o timeout", "tls handshake timeout", "unexpected eof"):
+		return ErrNetwork
+	}
+	return ErrUnknown
+}
+
+// statusKind classifies a failed API response.
+func statusKind(resp *http.Response, msg string) ErrorKind {
+	switch {
+	case resp.StatusCode == http.StatusUnauthorized:
+		return ErrAuth
+	case resp.StatusCode == http.StatusNotFound:
+		return ErrNotFound
+	case resp.StatusCode == http.StatusTooManyRequests,
+		resp.StatusCode == http.StatusForbidden && (resp.Header.Get("X-RateLimit-Remaining") == "0" || classify(msg) == ErrRateLimited):
+		return ErrRateLimited
+	case resp.StatusCode >= 500:
+		return ErrNetwork
+	}
+	return classify(msg)
+}
+
+// requestError wraps the error of an HTTP request that got no response.
+func requestError(ctx context.Context, err error) *Error {
+	if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		return &Error{Kind: ErrNetwork, Message: fmt.Sprintf("timed out after %s", RequestTimeout), Err: err}
+	}
+	return &Error{Kind: ErrNetwork, Message: err.Error(), Err: err}
+}
+
+// command runs gh with args and returns its stdout. A failure is an *Error
+// carrying what gh printed to stderr.
+func command(ctx context.Context, args ...string) ([]byte, error) {
+	var stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, "gh", args...)
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err == nil {
+		return out, nil
+	}
+	if errors.Is(err, exec.ErrNotFound) {
+		return nil, &Error{Kind: ErrNotInstalled, Message: "gh is not installed", Err: err}
+	}
+	if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		return nil, &Error{Kind: ErrNetwork, Message: fmt.Sprintf("timed out after %s", RequestTimeout), Err: err}
+	}
+	msg := firstLine(stderr.String())
+	if msg == "" {
+		return nil, &Error{Message: err.Error(), Err: err}
+	}
+	return nil, &Error{Kind: classify(stderr.String()), Message: msg, Err: err}
+}
+
+// firstLine returns the first non-blank line of s; gh follows the cause with
+// usage hints.
+func firstLine(s string) string {
+	for _, line := range strings.Split(s, "\n") {
+		if line = strings.TrimSpace(line); line != "" {
+			return line
+		}
+	}
+	return ""
+}
+
+// output runs gh as command does, for requests to GitHub: each try is killed
+// after RequestTimeout, and transient failures are retried.
+func output(ctx context.Context, args ...string) ([]byte, error) {
+	var out []byte
+	err := retry(ctx, func(ctx context.Context) error {
+		ctx, cancel := context.WithTimeout(ctx, RequestTimeout)
+		defer cancel()
+		var err error
+		out, err = command(ctx, args...)
+		return err
+	})
+	return out, err
+}
+
+// Retries of transient failures: up to maxRetries more tries, the first
+// after about retryDelay and each after that twice as long.
+const maxRetries = 2
+
+var retryDelay = time.Second
+
+// retry calls fn until it succeeds or fails for good: with an error that
+// isn't transient, after maxRetries retries, or because ctx is done. Waits
+// between tries are jittered by ±50% so that tabs failing together don't
+// retry in lockstep.
+func retry(ctx context.Context, fn func(ctx context.Context) error) error {
+	delay := retryDelay
+	for n := 0; ; n++ {
+		err := fn(ctx)
+		var e *Error
+		if err == nil || n == maxRetries || !errors.As(err, &e) || !e.Transient() {
+			return err
+		}
+		wait := delay/2 + rand.N(delay)
+		select {
+		case <-ctx.Done():
+			return err
+		case <-time.After(wait):
+		}
+		delay *= 2
+	}
+}
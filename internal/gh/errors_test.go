@@ -0,0 +1,104 @@
+package gh
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestClassify(t *testing.T) {
+	for _, tc := range []struct {
+		stderr string
+		want   ErrorKind
+	}{
+		{"HTTP 401: Bad credentials (https://api.github.com/graphql)\nTry authenticating with:  gh auth login", ErrAuth},
+		{"To get started with GitHub CLI, please run:  gh auth login", ErrAuth},
+		{"GraphQL: Could not resolve to a Repository with the name 'o/nope'. (repository)", ErrNotFound},
+		{"HTTP 404: Not Found (https://api.github.com/repos/o/r/actions/runs/1)", ErrNotFound},
+		{"HTTP 403: API rate limit exceeded for user ID 1.", ErrRateLimited},
+		{"error connecting to api.github.com\ncheck your internet connection or https://githubstatus.com", ErrNetwork},
+		{"HTTP 502: Bad Gateway", ErrNetwork},
+		{"unknown flag: --bogus", ErrUnknown},
+	} {
+		if got := classify(tc.stderr); got != tc.want {
+			t.Errorf("classify(%q) = %d, want %d", tc.stderr, got, tc.want)
+		}
+	}
+}
+
+// fakeGH puts a gh on the PATH that runs script.
+func fakeGH(t *testing.T, script string) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "gh"), []byte("#!/bin/sh\n"+script), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("PATH", dir+string(os.PathListSeparator)+os.Getenv("PATH"))
+}
+
+func TestCommandStderr(t *testing.T) {
+	fakeGH(t, "echo 'HTTP 401: Bad credentials (https://api.github.com/graphql)' >&2\necho 'Try authenticating with:  gh auth login' >&2\nexit 1\n")
+	_, err := FetchRuns(context.Background(), "o/r")
+	var e *Error
+	if !errors.As(err, &e) || e.Kind != ErrAuth {
+		t.Fatalf("err = %#v, want an auth *Error", err)
+	}
+	if err.Error() != "gh run list failed: HTTP 401: Bad credentials (https://api.github.com/graphql)" {
+		t.Errorf("err = %q", err)
+	}
+	if msg := Explain(err); !strings.HasSuffix(msg, "Run gh auth login, or set GH_TOKEN to a valid token.") {
+		t.Errorf("Explain() = %q", msg)
+	}
+}
+
+func TestCommandNotInstalled(t *testing.T) {
+	t.Setenv("PATH", t.TempDir())
+	_, err := FetchRuns(context.Background(), "o/r")
+	var e *Error
+	if !errors.As(err, &e) || e.Kind != ErrNotInstalled {
+		t.Fatalf("err = %v, want ErrNotInstalled", err)
+	}
+}
+
+func TestRetry(t *testing.T) {
+	defer func(d time.Duration) { retryDelay = d }(retryDelay)
+	retryDelay = time.Millisecond
+
+	// A flaky gh that fails to connect twice, counting calls in a file.
+	dir := t.TempDir()
+	count := filepath.Join(dir, "count")
+	fakeGH(t, `n=$(cat `+count+` 2>/dev/null || echo 0); n=$((n+1)); echo $n > `+count+`
+if [ $n -lt 3 ]; then echo 'error connecting to api.github.com' >&2; exit 1; fi
+echo '[{"databaseId":1}]'
+`)
+	runs, err := FetchRuns(context.Background(), "o/r")
+	if err != nil || len(runs) != 1 {
+		t.Fatalf("FetchRuns() = %v, %v after transient failures", runs, err)
+	}
+
+	// Errors that won't go away aren't retried.
+	var calls atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if calls.Add(1) == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		http.Error(w, `{"message":"Not Found"}`, http.StatusNotFound)
+	}))
+	defer srv.Close()
+	cache, err := NewCache(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = NewAPIWith(srv.URL, "tok", cache).FetchRuns(context.Background(), "o/r")
+	var e *Error
+	if !errors.As(err, &e) || e.Kind != ErrNotFound || calls.Load() != 2 {
+		t.Errorf("err = %v after %d calls, want not found after a retried 503", err, calls.Load())
+	}
+}
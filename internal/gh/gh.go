@@ -1,22 +1,32 @@
 package gh
 
 import (
+	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
 	"os/exec"
+	"sort"
 	"strconv"
+	"strings"
+	"sync"
+	"time"
 
 	"github.com/dzoba/github-actions-watcher/internal/types"
 )
 
-const runFields = "databaseId,displayTitle,event,headBranch,name,number,status,conclusion,createdAt,updatedAt,url,workflowName"
+const runFields = "databaseId,displayTitle,event,headBranch,headSha,name,number,attempt,status,conclusion,createdAt,startedAt,updatedAt,url,workflowName"
+
+// RequestTimeout bounds each request, so that a hung gh process or
+// connection can't hold up the polls or views waiting on it.
+const RequestTimeout = 30 * time.Second
 
 // FetchRepoList returns recently-pushed repos for the authenticated user.
-func FetchRepoList() ([]types.PickerRepo, error) {
-	out, err := exec.Command("gh", "repo", "list",
+func FetchRepoList(ctx context.Context) ([]types.PickerRepo, error) {
+	out, err := output(ctx, "repo", "list",
 		"--json", "nameWithOwner,pushedAt",
 		"--limit", "20",
-	).Output()
+	)
 	if err != nil {
 		return nil, fmt.Errorf("gh repo list failed: %w", err)
 	}
@@ -27,13 +37,69 @@ func FetchRepoList() ([]types.PickerRepo, error) {
 	return repos, nil
 }
 
+// FetchOrgRepos returns the unarchived repos of an org, or of a team given as
+// "org/team-slug", most recently pushed first.
+func FetchOrgRepos(ctx context.Context, org string) ([]types.OrgRepo, error) {
+	path := fmt.Sprintf("orgs/%s/repos?per_page=100&sort=pushed", org)
+	if owner, team, ok := strings.Cut(org, "/"); ok {
+		path = fmt.Sprintf("orgs/%s/teams/%s/repos?per_page=100", owner, team)
+	}
+	out, err := output(ctx, "api", "--paginate", path,
+		"--jq", ".[] | select(.archived | not) | {nameWithOwner: .full_name, pushedAt: .pushed_at, defaultBranch: .default_branch}",
+	)
+	if err != nil {
+		return nil, fmt.Errorf("gh api %s failed: %w", path, err)
+	}
+	var repos []types.OrgRepo
+	dec := json.NewDecoder(bytes.NewReader(out))
+	for dec.More() {
+		var r types.OrgRepo
+		if err := dec.Decode(&r); err != nil {
+			return nil, fmt.Errorf("failed to parse repos: %w", err)
+		}
+		repos = append(repos, r)
+	}
+	// Team repos can't be sorted by the API.
+	sort.SliceStable(repos, func(i, j int) bool { return repos[i].PushedAt > repos[j].PushedAt })
+	return repos, nil
+}
+
 // FetchRuns returns the 20 most recent workflow runs for a repo.
-func FetchRuns(repo string) ([]types.WorkflowRun, error) {
-	out, err := exec.Command("gh", "run", "list",
+func FetchRuns(ctx context.Context, repo string) ([]types.WorkflowRun, error) {
+	return runList(ctx, repo, 20)
+}
+
+// FetchRunHistory returns up to limit of the most recent workflow runs for a
+// repo. gh pages through the API as needed.
+func FetchRunHistory(ctx context.Context, repo string, limit int) ([]types.WorkflowRun, error) {
+	return runList(ctx, repo, limit)
+}
+
+func runList(ctx context.Context, repo string, limit int) ([]types.WorkflowRun, error) {
+	out, err := output(ctx, "run", "list",
 		"--repo", repo,
 		"--json", runFields,
-		"--limit", "20",
-	).Output()
+		"--limit", strconv.Itoa(limit),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("gh run list failed: %w", err)
+	}
+	var runs []types.WorkflowRun
+	if err := json.Unmarshal(out, &runs); err != nil {
+		return nil, fmt.Errorf("failed to parse runs: %w", err)
+	}
+	return runs, nil
+}
+
+// FetchRunsSince returns up to limit runs created at or after since, newest
+// first, for looking back over a period rather than a number of runs.
+func FetchRunsSince(ctx context.Context, repo string, since time.Time, limit int) ([]types.WorkflowRun, error) {
+	out, err := output(ctx, "run", "list",
+		"--repo", repo,
+		"--json", runFields,
+		"--created", ">="+since.UTC().Format("2006-01-02"),
+		"--limit", strconv.Itoa(limit),
+	)
 	if err != nil {
 		return nil, fmt.Errorf("gh run list failed: %w", err)
 	}
@@ -45,12 +111,31 @@ func FetchRuns(repo string) ([]types.WorkflowRun, error) {
 }
 
 // FetchRunDetail returns a single run with its jobs and steps.
-func FetchRunDetail(repo string, runID int) (*types.RunDetail, error) {
-	out, err := exec.Command("gh", "run", "view",
+func FetchRunDetail(ctx context.Context, repo string, runID int) (*types.RunDetail, error) {
+	out, err := output(ctx, "run", "view",
+		strconv.Itoa(runID),
+		"--repo", repo,
+		"--json", runFields+",jobs",
+	)
+	if err != nil {
+		return nil, fmt.Errorf("gh run view failed: %w", err)
+	}
+	var detail types.RunDetail
+	if err := json.Unmarshal(out, &detail); err != nil {
+		return nil, fmt.Errorf("failed to parse run detail: %w", err)
+	}
+	addJobMeta(ctx, &detail, fmt.Sprintf("repos/%s/actions/runs/%d/jobs", repo, runID))
+	return &detail, nil
+}
+
+// FetchRunAttempt returns an earlier attempt of a re-run run with its jobs.
+func FetchRunAttempt(ctx context.Context, repo string, runID, attempt int) (*types.RunDetail, error) {
+	out, err := output(ctx, "run", "view",
 		strconv.Itoa(runID),
 		"--repo", repo,
+		"--attempt", strconv.Itoa(attempt),
 		"--json", runFields+",jobs",
-	).Output()
+	)
 	if err != nil {
 		return nil, fmt.Errorf("gh run view failed: %w", err)
 	}
@@ -58,5 +143,148 @@ func FetchRunDetail(repo string, runID int) (*types.RunDetail, error) {
 	if err := json.Unmarshal(out, &detail); err != nil {
 		return nil, fmt.Errorf("failed to parse run detail: %w", err)
 	}
+	// gh reports the run's latest attempt number regardless.
+	detail.Attempt = attempt
+	addJobMeta(ctx, &detail, fmt.Sprintf("repos/%s/actions/runs/%d/attempts/%d/jobs", repo, runID, attempt))
 	return &detail, nil
 }
+
+// jobMeta is what the REST jobs API knows about a job that gh run view
+// doesn't report.
+type jobMeta struct {
+	ID         int      `json:"id"`
+	CreatedAt  string   `json:"created_at"`
+	Labels     []string `json:"labels"`
+	RunnerName string   `json:"runner_name"`
+	final      bool     // the job had finished when this was fetched
+}
+
+// jobMetas caches the jobMeta of jobs already seen. Once a job has a runner
+// or has finished, its jobMeta doesn't change, so polling a run's detail
+// costs a jobs request only when jobs are added or get their runners.
+var jobMetas = struct {
+	sync.Mutex
+	m map[int]jobMeta
+}{m: make(map[int]jobMeta)}
+
+// maxJobMetas bounds jobMetas; it is emptied when full.
+const maxJobMetas = 10000
+
+// knownJobMeta returns the cached jobMeta of a job, if it can't have changed
+// since it was fetched.
+func knownJobMeta(job types.Job) (jobMeta, bool) {
+	m, ok := jobMetas.m[job.DatabaseID]
+	return m, ok && (m.RunnerName != "" || m.final || job.StartedAt == "")
+}
+
+// addJobMeta fills in when each job was queued and which runner it asked for
+// and got, from the REST jobs endpoint at path unless all of it is cached.
+// It is best effort: without it the detail is still complete, just without
+// queue times.
+func addJobMeta(ctx context.Context, d *types.RunDetail, path string) {
+	jobMetas.Lock()
+	known := true
+	for _, job := range d.Jobs {
+		if _, ok := knownJobMeta(job); !ok {
+			known = false
+		}
+	}
+	jobMetas.Unlock()
+	if !known {
+		fetchJobMeta(ctx, d, path)
+	}
+
+	jobMetas.Lock()
+	defer jobMetas.Unlock()
+	for i := range d.Jobs {
+		if m, ok := jobMetas.m[d.Jobs[i].DatabaseID]; ok {
+			d.Jobs[i].CreatedAt = m.CreatedAt
+			d.Jobs[i].Labels = m.Labels
+			d.Jobs[i].RunnerName = m.RunnerName
+		}
+	}
+}
+
+// fetchJobMeta caches the jobMeta of the jobs of d.
+func fetchJobMeta(ctx context.Context, d *types.RunDetail, path string) {
+	out, err := output(ctx, "api", "--paginate",
+		path+"?per_page=100",
+		"--jq", ".jobs[] | {id, created_at, labels, runner_name}",
+	)
+	if err != nil {
+		return
+	}
+	final := make(map[int]bool)
+	for _, job := range d.Jobs {
+		final[job.DatabaseID] = job.Status == types.StatusCompleted
+	}
+	jobMetas.Lock()
+	defer jobMetas.Unlock()
+	if len(jobMetas.m) >= maxJobMetas {
+		clear(jobMetas.m)
+	}
+	dec := json.NewDecoder(bytes.NewReader(out))
+	for {
+		var m jobMeta
+		if err := dec.Decode(&m); err != nil {
+			break
+		}
+		m.final = final[m.ID]
+		jobMetas.m[m.ID] = m
+	}
+}
+
+// FetchDefaultBranch returns the name of a repo's default branch.
+func FetchDefaultBranch(ctx context.Context, repo string) (string, error) {
+	out, err := output(ctx, "repo", "view", repo,
+		"--json", "defaultBranchRef",
+		"--jq", ".defaultBranchRef.name",
+	)
+	if err != nil {
+		return "", fmt.Errorf("gh repo view failed: %w", err)
+	}
+	return strings.TrimSpace(string(out)), nil
+}
+
+// FetchRunTiming returns the billable time of a run.
+func FetchRunTiming(ctx context.Context, repo string, runID int) (*types.RunTiming, error) {
+	out, err := output(ctx, "api",
+		fmt.Sprintf("repos/%s/actions/runs/%d/timing", repo, runID),
+		"--jq", "{billable: (.billable // {} | map_values({totalMs: .total_ms, jobs: .jobs, jobMs: [.job_runs[]?.duration_ms]})), runDurationMs: .run_duration_ms}",
+	)
+	if err != nil {
+		return nil, fmt.Errorf("gh api timing failed: %w", err)
+	}
+	var t types.RunTiming
+	if err := json.Unmarshal(out, &t); err != nil {
+		return nil, fmt.Errorf("failed to parse run timing: %w", err)
+	}
+	return &t, nil
+}
+
+// FetchComparison returns the commits between two SHAs, oldest first. The
+// API lists at most 250 commits.
+func FetchComparison(ctx context.Context, repo, base, head string) (*types.Comparison, error) {
+	out, err := output(ctx, "api",
+		fmt.Sprintf("repos/%s/compare/%s...%s", repo, base, head),
+		"--jq", "{status, aheadBy: .ahead_by, behindBy: .behind_by, commits: [.commits[] | {sha, message: .commit.message, author: .commit.author.name}]}",
+	)
+	if err != nil {
+		return nil, fmt.Errorf("gh api compare failed: %w", err)
+	}
+	var c types.Comparison
+	if err := json.Unmarshal(out, &c); err != nil {
+		return nil, fmt.Errorf("failed to parse comparison: %w", err)
+	}
+	return &c, nil
+}
+
+// JobLogCommand returns a command that shows the log of a single job through
+// gh's pager. It is meant to be run in the foreground terminal.
+func JobLogCommand(repo string, jobID int) *exec.Cmd {
+	return exec.Command("gh", "run", "view",
+		"--repo", repo,
+		"--job", strconv.Itoa(jobID),
+		"--log",
+	)
+}
@@ -0,0 +1,49 @@
+package gh
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestJobMetaCached(t *testing.T) {
+	// A gh whose run has a job 9001 that is running, and that counts jobs
+	// API requests in a file.
+	dir := t.TempDir()
+	count := filepath.Join(dir, "count")
+	fakeGH(t, `case $1 in
+run) echo '{"databaseId":1,"attempt":1,"jobs":[{"databaseId":9001,"status":"in_progress","startedAt":"2024-01-01T00:00:00Z"}]}' ;;
+api) echo x >> `+count+`; echo '{"id":9001,"created_at":"2023-12-31T23:59:00Z","labels":["ubuntu-latest"],"runner_name":"r1"}' ;;
+esac
+`)
+	for range 3 {
+		d, err := FetchRunDetail(context.Background(), "o/r", 1)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if job := d.Jobs[0]; job.CreatedAt != "2023-12-31T23:59:00Z" || job.RunnerName != "r1" {
+			t.Fatalf("job = %+v", job)
+		}
+	}
+	data, _ := os.ReadFile(count)
+	if n := strings.Count(string(data), "x"); n != 1 {
+		t.Errorf("jobs API requested %d times for 3 polls, want once", n)
+	}
+}
+
+func TestFetchCancelled(t *testing.T) {
+	// A gh that hangs, e.g. on a dead connection.
+	fakeGH(t, "exec sleep 10\n")
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	if _, err := FetchDefaultBranch(ctx, "o/r"); err == nil {
+		t.Fatal("no error from a cancelled fetch")
+	}
+	if took := time.Since(start); took > 5*time.Second {
+		t.Errorf("cancelled fetch took %s", took)
+	}
+}
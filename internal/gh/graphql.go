@@ -0,0 +1,564 @@
+package gh
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/dzoba/github-actions-watcher/internal/types"
+)
+
+// DefaultGraphQLURL is the GitHub GraphQL API.
+const DefaultGraphQLURL = "https://api.github.com/graphql"
+
+// graphqlRuns is how many runs a batch returns per repo, as many as the
+// other backends list.
+const graphqlRuns = 20
+
+// GraphQL can't order branches by when they were pushed to, so a batch
+// reads the tips of the first graphqlBranches branches by name and the last
+// graphqlHistory commits of the default branch.
+const (
+	graphqlBranches = 100
+	graphqlHistory  = 10
+)
+
+// RunRef names a run to fetch the detail of in a batch. URL is the run's web
+// URL, which GraphQL looks runs up by.
+type RunRef struct {
+	Repo  string
+	RunID int
+	URL   string
+}
+
+// BatchResult is what one batched query found. Repos whose runs couldn't be
+// fetched are in Errors instead of Runs.
+type BatchResult struct {
+	Runs      map[string][]types.WorkflowRun
+	Errors    map[string]error
+	Detail    *types.RunDetail
+	DetailErr error
+}
+
+// Batcher is implemented by backends that fetch the runs of many repos, and
+// the detail of one run, in a single request.
+type Batcher interface {
+	FetchBatch(ctx context.Context, repos []string, detail *RunRef) (*BatchResult, error)
+}
+
+// GraphQL is the Backend that asks the GraphQL API for the latest runs of
+// every repo in one query per poll. GraphQL has no list of a repo's workflow
+// runs, so they are found through the check suites of the recent commits of
+// the default branch and of the tips of other branches: runs of earlier
+// commits of other branches, of branches past the first graphqlBranches by
+// name and of forks' pull requests are not seen. Nor does it report run
+// attempts or when they started, so listed runs have Attempt 0 and start
+// when they were created; the attempt and start of a run whose detail is
+// fetched are asked of the REST API.
+type GraphQL struct {
+	endpoint string
+	web      string
+	token    string
+	client   *http.Client
+	rest     *API // for what GraphQL doesn't report of a run, if set
+
+	mu        sync.Mutex
+	rateLimit *RateLimit // as of the last query
+}
+
+var (
+	_ Backend = (*GraphQL)(nil)
+	_ Batcher = (*GraphQL)(nil)
+)
+
+// NewGraphQL returns a GraphQL backend authenticated with Token. The REST
+// requests it makes for run details are cached in cache.
+func NewGraphQL(cache *Cache) (*GraphQL, error) {
+	token, err := Token()
+	if err != nil {
+		return nil, err
+	}
+	return NewGraphQLWith(DefaultGraphQLURL, "https://github.com", token, NewAPIWith(DefaultAPIURL, token, cache)), nil
+}
+
+// NewGraphQLWith returns a GraphQL backend for the API at endpoint, with run
+// pages under web. Without rest, details have Attempt 0 and start when their
+// run was created.
+func NewGraphQLWith(endpoint, web, token string, rest *API) *GraphQL {
+	return &GraphQL{
+		endpoint: endpoint,
+		web:      strings.TrimRight(web, "/"),
+		token:    token,
+		client:   &http.Client{},
+		rest:     rest,
+	}
+}
+
+const rateLimitFields = `rateLimit { limit remaining resetAt }`
+
+const commitRunsFields = `oid
+          messageHeadline
+          checkSuites(last: 10) {
+            nodes {
+              status
+              conclusion
+              workflowRun { databaseId runNumber event createdAt updatedAt url workflow { name } }
+            }
+          }`
+
+var repoRunsFields = fmt.Sprintf(`defaultBranchRef {
+    name
+    target {
+      ... on Commit {
+        history(first: %d) {
+          nodes {
+          %s
+          }
+        }
+      }
+    }
+  }
+  refs(refPrefix: "refs/heads/", first: %d) {
+    nodes {
+      name
+      target {
+        ... on Commit {
+          %s
+        }
+      }
+    }
+  }`, graphqlHistory, commitRunsFields, graphqlBranches, commitRunsFields)
+
+const runDetailFields = `... on WorkflowRun {
+      databaseId runNumber event createdAt updatedAt url
+      workflow { name }
+      checkSuite {
+        status
+        conclusion
+        branch { name }
+        commit { oid messageHeadline }
+        checkRuns(first: 100) {
+          nodes {
+            databaseId name status conclusion startedAt completedAt detailsUrl
+            steps(first: 100) { nodes { name status conclusion number startedAt completedAt } }
+          }
+        }
+      }
+    }`
+
+// batchQuery returns the query for a batch and its variables. Repos are
+// aliased r0, r1, ... in order.
+func batchQuery(repos []string, detailURL string) (string, map[string]any, error) {
+	var params, fields []string
+	vars := make(map[string]any)
+	for i, repo := range repos {
+		owner, name, ok := strings.Cut(repo, "/")
+		if !ok {
+			return "", nil, fmt.Errorf("invalid repo %q, want owner/repo", repo)
+		}
+		params = append(params, fmt.Sprintf("$o%d: String!, $n%d: String!", i, i))
+		fields = append(fields, fmt.Sprintf("r%d: repository(owner: $o%d, name: $n%d) {\n  %s\n}", i, i, i, repoRunsFields))
+		vars[fmt.Sprintf("o%d", i)] = owner
+		vars[fmt.Sprintf("n%d", i)] = name
+	}
+	if detailURL != "" {
+		params = append(params, "$u: URI!")
+		fields = append(fields, "detail: resource(url: $u) {\n    "+runDetailFields+"\n}")
+		vars["u"] = detailURL
+	}
+	fields = append(fields, rateLimitFields)
+	q := "query"
+	if len(params) > 0 {
+		q += "(" + strings.Join(params, ", ") + ")"
+	}
+	return q + " {\n" + strings.Join(fields, "\n") + "\n}", vars, nil
+}
+
+type gqlError struct {
+	Type    string `json:"type"`
+	Message string `json:"message"`
+	Path    []any  `json:"path"`
+}
+
+func (e gqlError) err() *Error {
+	kind := classify(e.Message)
+	switch e.Type {
+	case "NOT_FOUND":
+		kind = ErrNotFound
+	case "RATE_LIMITED":
+		kind = ErrRateLimited
+	}
+	return &Error{Kind: kind, Message: e.Message}
+}
+
+type gqlWorkflowRun struct {
+	DatabaseID int    `json:"databaseId"`
+	RunNumber  int    `json:"runNumber"`
+	Event      string `json:"event"`
+	CreatedAt  string `json:"createdAt"`
+	UpdatedAt  string `json:"updatedAt"`
+	URL        string `json:"url"`
+	Workflow   struct {
+		Name string `json:"name"`
+	} `json:"workflow"`
+}
+
+// run converts to the shape gh reports. GraphQL has no attempt or start time
+// of a run, nor a title other than the commit's, so a re-run seems to have
+// started when its first attempt was created.
+func (r gqlWorkflowRun) run(status, conclusion, branch, sha, title string) types.WorkflowRun {
+	return types.WorkflowRun{
+		DatabaseID:   r.DatabaseID,
+		DisplayTitle: title,
+		Event:        r.Event,
+		HeadBranch:   branch,
+		HeadSha:      sha,
+		Name:         r.Workflow.Name,
+		Number:       r.RunNumber,
+		Status:       types.RunStatus(strings.ToLower(status)),
+		Conclusion:   types.RunConclusion(strings.ToLower(conclusion)),
+		CreatedAt:    r.CreatedAt,
+		StartedAt:    r.CreatedAt,
+		UpdatedAt:    r.UpdatedAt,
+		URL:          r.URL,
+		WorkflowName: r.Workflow.Name,
+	}
+}
+
+type gqlCommit struct {
+	OID             string `json:"oid"`
+	MessageHeadline string `json:"messageHeadline"`
+	CheckSuites     struct {
+		Nodes []struct {
+			Status      string          `json:"status"`
+			Conclusion  string          `json:"conclusion"`
+			WorkflowRun *gqlWorkflowRun `json:"workflowRun"`
+		} `json:"nodes"`
+	} `json:"checkSuites"`
+}
+
+type gqlRepo struct {
+	DefaultBranchRef *struct {
+		Name   string `json:"name"`
+		Target struct {
+			History struct {
+				Nodes []gqlCommit `json:"nodes"`
+			} `json:"history"`
+		} `json:"target"`
+	} `json:"defaultBranchRef"`
+	Refs struct {
+		Nodes []struct {
+			Name   string    `json:"name"`
+			Target gqlCommit `json:"target"`
+		} `json:"nodes"`
+	} `json:"refs"`
+}
+
+// runs returns the newest runs across the repo's branches.
+func (r gqlRepo) runs() []types.WorkflowRun {
+	seen := make(map[int]bool)
+	runs := []types.WorkflowRun{}
+	add := func(branch string, c gqlCommit) {
+		for _, cs := range c.CheckSuites.Nodes {
+			// Suites of other apps than Actions have no run.
+			if cs.WorkflowRun == nil || seen[cs.WorkflowRun.DatabaseID] {
+				continue
+			}
+			seen[cs.WorkflowRun.DatabaseID] = true
+			runs = append(runs, cs.WorkflowRun.run(cs.Status, cs.Conclusion, branch, c.OID, c.MessageHeadline))
+		}
+	}
+	if def := r.DefaultBranchRef; def != nil {
+		for _, c := range def.Target.History.Nodes {
+			add(def.Name, c)
+		}
+	}
+	for _, ref := range r.Refs.Nodes {
+		add(ref.Name, ref.Target)
+	}
+	sort.SliceStable(runs, func(i, j int) bool { return runs[i].CreatedAt > runs[j].CreatedAt })
+	if len(runs) > graphqlRuns {
+		runs = runs[:graphqlRuns]
+	}
+	return runs
+}
+
+type gqlRunDetail struct {
+	gqlWorkflowRun
+	CheckSuite struct {
+		Status     string `json:"status"`
+		Conclusion string `json:"conclusion"`
+		Branch     *struct {
+			Name string `json:"name"`
+		} `json:"branch"`
+		Commit struct {
+			OID             string `json:"oid"`
+			MessageHeadline string `json:"messageHeadline"`
+		} `json:"commit"`
+		CheckRuns struct {
+			Nodes []struct {
+				DatabaseID  int    `json:"databaseId"`
+				Name        string `json:"name"`
+				Status      string `json:"status"`
+				Conclusion  string `json:"conclusion"`
+				StartedAt   string `json:"startedAt"`
+				CompletedAt string `json:"completedAt"`
+				DetailsURL  string `json:"detailsUrl"`
+				Steps       struct {
+					Nodes []struct {
+						Name        string `json:"name"`
+						Status      string `json:"status"`
+						Conclusion  string `json:"conclusion"`
+						Number      int    `json:"number"`
+						StartedAt   string `json:"startedAt"`
+						CompletedAt string `json:"completedAt"`
+					} `json:"nodes"`
+				} `json:"steps"`
+			} `json:"nodes"`
+		} `json:"checkRuns"`
+	} `json:"checkSuite"`
+}
+
+func (d gqlRunDetail) detail() *types.RunDetail {
+	cs := d.CheckSuite
+	branch := ""
+	if cs.Branch != nil {
+		branch = cs.Branch.Name
+	}
+	detail := &types.RunDetail{
+		WorkflowRun: d.run(cs.Status, cs.Conclusion, branch, cs.Commit.OID, cs.Commit.MessageHeadline),
+		Jobs:        []types.Job{},
+	}
+	for _, cr := range cs.CheckRuns.Nodes {
+		job := types.Job{
+			Name:        cr.Name,
+			Status:      types.RunStatus(strings.ToLower(cr.Status)),
+			Conclusion:  types.RunConclusion(strings.ToLower(cr.Conclusion)),
+			StartedAt:   cr.StartedAt,
+			CompletedAt: cr.CompletedAt,
+			URL:         cr.DetailsURL,
+			DatabaseID:  cr.DatabaseID,
+		}
+		for _, s := range cr.Steps.Nodes {
+			job.Steps = append(job.Steps, types.Step{
+				Name:        s.Name,
+				Status:      types.RunStatus(strings.ToLower(s.Status)),
+				Conclusion:  types.RunConclusion(strings.ToLower(s.Conclusion)),
+				Number:      s.Number,
+				StartedAt:   s.StartedAt,
+				CompletedAt: s.CompletedAt,
+			})
+		}
+		detail.Jobs = append(detail.Jobs, job)
+	}
+	return detail
+}
+
+type gqlRateLimit struct {
+	Limit     int    `json:"limit"`
+	Remaining int    `json:"remaining"`
+	ResetAt   string `json:"resetAt"`
+}
+
+func (r gqlRateLimit) rateLimit() *RateLimit {
+	rl := &RateLimit{Limit: r.Limit, Remaining: r.Remaining}
+	if t, err := time.Parse(time.RFC3339, r.ResetAt); err == nil {
+		rl.Reset = t.Unix()
+	}
+	return rl
+}
+
+// query runs a GraphQL query and returns its data and errors. Transient
+// failures are retried.
+func (g *GraphQL) query(ctx context.Context, query string, vars map[string]any) (data map[string]json.RawMessage, errs []gqlError, err error) {
+	err = retry(ctx, func(ctx context.Context) error {
+		data, errs, err = g.queryOnce(ctx, query, vars)
+		return err
+	})
+	return data, errs, err
+}
+
+func (g *GraphQL) queryOnce(ctx context.Context, query string, vars map[string]any) (map[string]json.RawMessage, []gqlError, error) {
+	body, err := json.Marshal(map[string]any{"query": query, "variables": vars})
+	if err != nil {
+		return nil, nil, err
+	}
+	ctx, cancel := context.WithTimeout(ctx, RequestTimeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, g.endpoint, bytes.NewReader(body))
+	if err != nil {
+		return nil, nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if g.token != "" {
+		req.Header.Set("Authorization", "Bearer "+g.token)
+	}
+	resp, err := g.client.Do(req)
+	if err != nil {
+		return nil, nil, fmt.Errorf("GraphQL query failed: %w", requestError(ctx, err))
+	}
+	defer resp.Body.Close()
+	var out struct {
+		Data    map[string]json.RawMessage `json:"data"`
+		Errors  []gqlError                 `json:"errors"`
+		Message string                     `json:"message"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&out); err != nil && resp.StatusCode == http.StatusOK {
+		return nil, nil, fmt.Errorf("failed to parse GraphQL response: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		if out.Message == "" {
+			out.Message = resp.Status
+		}
+		return nil, nil, fmt.Errorf("GraphQL query failed: %w", &Error{Kind: statusKind(resp, out.Message), Message: out.Message})
+	}
+	if out.Data == nil && len(out.Errors) > 0 {
+		return nil, nil, fmt.Errorf("GraphQL query failed: %w", out.Errors[0].err())
+	}
+	if raw, ok := out.Data["rateLimit"]; ok {
+		var rl gqlRateLimit
+		if json.Unmarshal(raw, &rl) == nil {
+			g.mu.Lock()
+			g.rateLimit = rl.rateLimit()
+			g.mu.Unlock()
+		}
+	}
+	return out.Data, out.Errors, nil
+}
+
+// FetchBatch returns the latest runs of every repo and, if detail is set,
+// the jobs and steps of that run. An error is returned only if the query as
+// a whole failed.
+func (g *GraphQL) FetchBatch(ctx context.Context, repos []string, detail *RunRef) (*BatchResult, error) {
+	detailURL := ""
+	if detail != nil {
+		detailURL = detail.URL
+		if detailURL == "" {
+			detailURL = g.runURL(detail.Repo, detail.RunID)
+		}
+	}
+	q, vars, err := batchQuery(repos, detailURL)
+	if err != nil {
+		return nil, err
+	}
+	data, errs, err := g.query(ctx, q, vars)
+	if err != nil {
+		return nil, err
+	}
+
+	// Errors are reported against the alias of the field that failed.
+	fieldErrs := make(map[string]error)
+	for _, e := range errs {
+		if len(e.Path) > 0 {
+			if alias, ok := e.Path[0].(string); ok && fieldErrs[alias] == nil {
+				fieldErrs[alias] = fmt.Errorf("GraphQL query failed: %w", e.err())
+			}
+		}
+	}
+	res := &BatchResult{
+		Runs:   make(map[string][]types.WorkflowRun),
+		Errors: make(map[string]error),
+	}
+	for i, repo := range repos {
+		alias := "r" + strconv.Itoa(i)
+		if err := fieldErrs[alias]; err != nil {
+			res.Errors[repo] = err
+			continue
+		}
+		raw := data[alias]
+		if len(raw) == 0 || string(raw) == "null" {
+			res.Errors[repo] = &Error{Kind: ErrNotFound, Message: fmt.Sprintf("repository %s not found", repo)}
+			continue
+		}
+		var r gqlRepo
+		if err := json.Unmarshal(raw, &r); err != nil {
+			res.Errors[repo] = fmt.Errorf("failed to parse runs of %s: %w", repo, err)
+			continue
+		}
+		res.Runs[repo] = r.runs()
+	}
+	if detail != nil {
+		res.Detail, res.DetailErr = decodeDetail(data["detail"], fieldErrs["detail"], detail.RunID)
+		if res.DetailErr == nil && g.rest != nil {
+			res.DetailErr = g.addAttempt(ctx, detail.Repo, res.Detail)
+		}
+	}
+	return res, nil
+}
+
+// addAttempt fills in the attempt of a run and when it started, which only
+// the REST API reports. Unchanged runs are answered from the cache.
+func (g *GraphQL) addAttempt(ctx context.Context, repo string, d *types.RunDetail) error {
+	run, err := g.rest.fetchRun(ctx, repo, d.DatabaseID)
+	if err != nil {
+		return err
+	}
+	d.Attempt = run.RunAttempt
+	d.StartedAt = run.RunStartedAt
+	return nil
+}
+
+func decodeDetail(raw json.RawMessage, err error, runID int) (*types.RunDetail, error) {
+	if err != nil {
+		return nil, err
+	}
+	var d gqlRunDetail
+	if len(raw) == 0 || string(raw) == "null" || json.Unmarshal(raw, &d) != nil || d.DatabaseID == 0 {
+		return nil, &Error{Kind: ErrNotFound, Message: fmt.Sprintf("run %d not found", runID)}
+	}
+	return d.detail(), nil
+}
+
+func (g *GraphQL) runURL(repo string, runID int) string {
+	return fmt.Sprintf("%s/%s/actions/runs/%d", g.web, repo, runID)
+}
+
+// FetchRuns returns the latest workflow runs for a repo.
+func (g *GraphQL) FetchRuns(ctx context.Context, repo string) ([]types.WorkflowRun, error) {
+	res, err := g.FetchBatch(ctx, []string{repo}, nil)
+	if err != nil {
+		return nil, err
+	}
+	if err := res.Errors[repo]; err != nil {
+		return nil, err
+	}
+	return res.Runs[repo], nil
+}
+
+// FetchRunDetail returns a single run with its jobs and steps.
+func (g *GraphQL) FetchRunDetail(ctx context.Context, repo string, runID int) (*types.RunDetail, error) {
+	res, err := g.FetchBatch(ctx, nil, &RunRef{Repo: repo, RunID: runID})
+	if err != nil {
+		return nil, err
+	}
+	return res.Detail, res.DetailErr
+}
+
+// FetchRateLimit returns the GraphQL rate limit as of the last query, which
+// is separate from the REST one. It only asks if nothing was queried yet.
+func (g *GraphQL) FetchRateLimit(ctx context.Context) (*RateLimit, error) {
+	g.mu.Lock()
+	rl := g.rateLimit
+	g.mu.Unlock()
+	if rl != nil {
+		cp := *rl
+		return &cp, nil
+	}
+	if _, _, err := g.query(ctx, "query {\n"+rateLimitFields+"\n}", nil); err != nil {
+		return nil, err
+	}
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if g.rateLimit == nil {
+		return nil, fmt.Errorf("GraphQL response had no rate limit")
+	}
+	cp := *g.rateLimit
+	return &cp, nil
+}
@@ -0,0 +1,189 @@
+package gh
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+)
+
+// fakeGraphQL answers batch queries: o/r has two branches whose check suites
+// share a run, o/gone doesn't exist, and the detail is of run 7.
+func newFakeGraphQL(t *testing.T, queries *atomic.Int32) *httptest.Server {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		queries.Add(1)
+		if r.Header.Get("Authorization") != "Bearer tok" {
+			w.WriteHeader(http.StatusUnauthorized)
+			fmt.Fprint(w, `{"message":"Bad credentials"}`)
+			return
+		}
+		var req struct {
+			Query     string            `json:"query"`
+			Variables map[string]string `json:"variables"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			t.Error(err)
+		}
+		data := map[string]any{
+			"rateLimit": map[string]any{"limit": 5000, "remaining": 4990, "resetAt": "2023-11-14T22:13:20Z"},
+		}
+		var errs []map[string]any
+		for i := 0; ; i++ {
+			owner, ok := req.Variables[fmt.Sprintf("o%d", i)]
+			if !ok {
+				break
+			}
+			alias := fmt.Sprintf("r%d", i)
+			if owner+"/"+req.Variables[fmt.Sprintf("n%d", i)] != "o/r" {
+				data[alias] = nil
+				errs = append(errs, map[string]any{"message": "Could not resolve to a Repository", "path": []string{alias}})
+				continue
+			}
+			data[alias] = json.RawMessage(`{"refs":{"nodes":[
+				{"name":"main","target":{"oid":"abc","messageHeadline":"Fix","checkSuites":{"nodes":[
+					{"status":"COMPLETED","conclusion":"SUCCESS","workflowRun":{"databaseId":7,"runNumber":3,"event":"push","createdAt":"2024-01-01T10:00:00Z","url":"https://github.com/o/r/actions/runs/7","workflow":{"name":"CI"}}},
+					{"status":"COMPLETED","conclusion":"NEUTRAL","workflowRun":null}]}}},
+				{"name":"feature","target":{"oid":"def","messageHeadline":"Try","checkSuites":{"nodes":[
+					{"status":"IN_PROGRESS","conclusion":null,"workflowRun":{"databaseId":8,"runNumber":4,"event":"push","createdAt":"2024-01-01T11:00:00Z","url":"https://github.com/o/r/actions/runs/8","workflow":{"name":"CI"}}},
+					{"status":"COMPLETED","conclusion":"SUCCESS","workflowRun":{"databaseId":7,"runNumber":3,"event":"push","createdAt":"2024-01-01T10:00:00Z","url":"https://github.com/o/r/actions/runs/7","workflow":{"name":"CI"}}}]}}}]}}`)
+		}
+		if u, ok := req.Variables["u"]; ok {
+			if u != "https://github.com/o/r/actions/runs/7" {
+				t.Errorf("detail url = %q", u)
+			}
+			data["detail"] = json.RawMessage(`{"databaseId":7,"runNumber":3,"event":"push","url":"https://github.com/o/r/actions/runs/7","workflow":{"name":"CI"},
+				"checkSuite":{"status":"COMPLETED","conclusion":"FAILURE","branch":{"name":"main"},"commit":{"oid":"abc","messageHeadline":"Fix"},
+				"checkRuns":{"nodes":[{"databaseId":1,"name":"test","status":"COMPLETED","conclusion":"FAILURE","steps":{"nodes":[{"name":"go test","status":"COMPLETED","conclusion":"FAILURE","number":1}]}}]}}}`)
+		}
+		json.NewEncoder(w).Encode(map[string]any{"data": data, "errors": errs})
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func TestGraphQLBatch(t *testing.T) {
+	var queries atomic.Int32
+	srv := newFakeGraphQL(t, &queries)
+	// GraphQL doesn't report attempts; the REST API is asked for those of
+	// details.
+	rest := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/repos/o/r/actions/runs/7" {
+			t.Errorf("REST request for %s", r.URL.Path)
+		}
+		fmt.Fprint(w, `{"id":7,"run_attempt":2,"run_started_at":"2024-01-01T12:00:00Z"}`)
+	}))
+	t.Cleanup(rest.Close)
+	cache, err := NewCache(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	g := NewGraphQLWith(srv.URL, "https://github.com", "tok", NewAPIWith(rest.URL, "tok", cache))
+
+	res, err := g.FetchBatch(context.Background(), []string{"o/r", "o/gone"}, &RunRef{Repo: "o/r", RunID: 7})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if queries.Load() != 1 {
+		t.Errorf("%d queries, want 1", queries.Load())
+	}
+
+	runs := res.Runs["o/r"]
+	if len(runs) != 2 {
+		t.Fatalf("runs = %+v, want 2 without duplicates", runs)
+	}
+	if r := runs[0]; r.DatabaseID != 8 || r.HeadBranch != "feature" || r.Status != "in_progress" || r.Conclusion != "" || r.WorkflowName != "CI" {
+		t.Errorf("newest run = %+v", r)
+	}
+	if r := runs[1]; r.DatabaseID != 7 || r.HeadSha != "abc" || r.DisplayTitle != "Fix" || r.Conclusion != "success" || r.Attempt != 0 || r.StartedAt != r.CreatedAt {
+		t.Errorf("older run = %+v", r)
+	}
+	if err := res.Errors["o/gone"]; err == nil || !strings.Contains(err.Error(), "Could not resolve") {
+		t.Errorf("o/gone error = %v", err)
+	}
+
+	if res.DetailErr != nil {
+		t.Fatal(res.DetailErr)
+	}
+	d := res.Detail
+	if d.Conclusion != "failure" || d.HeadBranch != "main" || len(d.Jobs) != 1 || len(d.Jobs[0].Steps) != 1 || d.Jobs[0].Steps[0].Conclusion != "failure" {
+		t.Errorf("detail = %+v", d)
+	}
+	if d.Attempt != 2 || d.StartedAt != "2024-01-01T12:00:00Z" {
+		t.Errorf("detail of attempt %d started at %q, want 2 at 12:00", d.Attempt, d.StartedAt)
+	}
+
+	rl, err := g.FetchRateLimit(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rl.Remaining != 4990 || rl.Reset != 1700000000 {
+		t.Errorf("rate limit = %+v", rl)
+	}
+	if queries.Load() != 1 {
+		t.Errorf("rate limit was queried again although the batch reported it")
+	}
+}
+
+func TestGraphQLBadCredentials(t *testing.T) {
+	var queries atomic.Int32
+	srv := newFakeGraphQL(t, &queries)
+	g := NewGraphQLWith(srv.URL, "https://github.com", "wrong", nil)
+	if _, err := g.FetchRuns(context.Background(), "o/r"); err == nil || !strings.Contains(err.Error(), "Bad credentials") {
+		t.Errorf("err = %v, want Bad credentials", err)
+	}
+}
+
+// TestGraphQLBranches checks that the newest runs are found however branches
+// sort by name, including those of earlier commits of the default branch.
+func TestGraphQLBranches(t *testing.T) {
+	suite := func(id int, created string) string {
+		return fmt.Sprintf(`{"checkSuites":{"nodes":[{"status":"COMPLETED","conclusion":"SUCCESS","workflowRun":{"databaseId":%d,"createdAt":%q,"workflow":{"name":"CI"}}}]}}`, id, created)
+	}
+	// Branch b00 is the oldest by name and the newest by its run, which is
+	// the only one that sorts after b01..b19.
+	var refs []string
+	for i := range 20 {
+		created := fmt.Sprintf("2024-01-01T%02d:00:00Z", i)
+		if i == 0 {
+			created = "2024-01-02T00:00:00Z"
+		}
+		refs = append(refs, fmt.Sprintf(`{"name":"b%02d","target":%s}`, i, suite(100+i, created)))
+	}
+	history := suite(1, "2024-01-03T00:00:00Z") + "," + suite(2, "2024-01-01T21:00:00Z")
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			Query string `json:"query"`
+		}
+		json.NewDecoder(r.Body).Decode(&req)
+		if !strings.Contains(req.Query, fmt.Sprintf("first: %d", graphqlBranches)) || strings.Contains(req.Query, "orderBy") {
+			t.Errorf("query doesn't list branches by name:\n%s", req.Query)
+		}
+		fmt.Fprintf(w, `{"data":{"r0":{"defaultBranchRef":{"name":"main","target":{"history":{"nodes":[%s]}}},"refs":{"nodes":[%s]}}}}`,
+			history, strings.Join(refs, ","))
+	}))
+	t.Cleanup(srv.Close)
+
+	runs, err := NewGraphQLWith(srv.URL, "https://github.com", "tok", nil).FetchRuns(context.Background(), "o/r")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(runs) != graphqlRuns {
+		t.Fatalf("%d runs, want %d", len(runs), graphqlRuns)
+	}
+	var got []int
+	for _, run := range runs[:4] {
+		got = append(got, run.DatabaseID)
+	}
+	// The default branch's HEAD, b00's tip, an earlier commit of the
+	// default branch, then the tips of the other branches newest first.
+	if fmt.Sprint(got) != "[1 100 2 119]" {
+		t.Errorf("newest runs = %v, want [1 100 2 119]", got)
+	}
+	if runs[2].HeadBranch != "main" {
+		t.Errorf("run of an earlier default branch commit on %q", runs[2].HeadBranch)
+	}
+}
@@ -0,0 +1,183 @@
+package gh
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"sync"
+)
+
+// FromCacheHeader is set on responses served from a Cache because the server
+// answered 304 Not Modified.
+const FromCacheHeader = "X-Ghaw-From-Cache"
+
+// cacheEntry is a cached response body with the validators to revalidate it.
+type cacheEntry struct {
+	URL          string `json:"url"`
+	ETag         string `json:"etag,omitempty"`
+	LastModified string `json:"lastModified,omitempty"`
+	Body         []byte `json:"body"`
+}
+
+// Cache keeps GET responses that carry an ETag or Last-Modified header, in
+// memory and, if it has a directory, on disk so they survive restarts. The
+// responses may be of private repos, so only the user can read the files.
+type Cache struct {
+	dir string
+
+	mu      sync.Mutex
+	entries map[string]*cacheEntry
+}
+
+// NewCache returns a cache that persists in dir, or only in memory if dir is
+// empty.
+func NewCache(dir string) (*Cache, error) {
+	if dir != "" {
+		if err := os.MkdirAll(dir, 0o700); err != nil {
+			return nil, fmt.Errorf("failed to create cache dir: %w", err)
+		}
+	}
+	return &Cache{dir: dir, entries: make(map[string]*cacheEntry)}, nil
+}
+
+// DefaultCacheDir is where the API backend caches responses by default.
+func DefaultCacheDir() (string, error) {
+	cache, err := os.UserCacheDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(cache, "ghaw", "http"), nil
+}
+
+func (c *Cache) path(url string) string {
+	sum := sha256.Sum256([]byte(url))
+	return filepath.Join(c.dir, hex.EncodeToString(sum[:16])+".json")
+}
+
+func (c *Cache) get(url string) *cacheEntry {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if e, ok := c.entries[url]; ok {
+		return e
+	}
+	if c.dir == "" {
+		return nil
+	}
+	data, err := os.ReadFile(c.path(url))
+	if err != nil {
+		return nil
+	}
+	var e cacheEntry
+	if json.Unmarshal(data, &e) != nil || e.URL != url {
+		return nil
+	}
+	c.entries[url] = &e
+	return &e
+}
+
+// put stores an entry. Failing to write it to disk only costs a full
+// response after the next restart, so errors are ignored.
+func (c *Cache) put(e *cacheEntry) {
+	c.mu.Lock()
+	c.entries[e.URL] = e
+	c.mu.Unlock()
+	if c.dir == "" {
+		return
+	}
+	data, err := json.Marshal(e)
+	if err != nil {
+		return
+	}
+	tmp := c.path(e.URL) + ".tmp"
+	if os.WriteFile(tmp, data, 0o600) == nil {
+		os.Rename(tmp, c.path(e.URL))
+	}
+}
+
+// Lookup returns the cached body of url without revalidating it.
+func (c *Cache) Lookup(url string) ([]byte, bool) {
+	if e := c.get(url); e != nil {
+		return e.Body, true
+	}
+	return nil, false
+}
+
+// Transport makes GET requests conditional on what the cache holds and
+// answers 304 Not Modified responses from it. GitHub doesn't count 304s
+// against the rate limit. Requests with "Cache-Control: no-store" bypass it.
+type Transport struct {
+	Cache *Cache
+	// Base defaults to http.DefaultTransport.
+	Base http.RoundTripper
+}
+
+func (t *Transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	base := t.Base
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	if req.Method != http.MethodGet || req.Header.Get("Cache-Control") == "no-store" {
+		return base.RoundTrip(req)
+	}
+
+	url := req.URL.String()
+	cached := t.Cache.get(url)
+	if cached != nil {
+		req = req.Clone(req.Context())
+		if cached.ETag != "" {
+			req.Header.Set("If-None-Match", cached.ETag)
+		}
+		if cached.LastModified != "" {
+			req.Header.Set("If-Modified-Since", cached.LastModified)
+		}
+	}
+	resp, err := base.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+
+	switch {
+	case resp.StatusCode == http.StatusNotModified && cached != nil:
+		resp.Body.Close()
+		return cachedResponse(req, resp.Header, cached.Body), nil
+	case resp.StatusCode == http.StatusOK:
+		etag, lastModified := resp.Header.Get("ETag"), resp.Header.Get("Last-Modified")
+		if etag == "" && lastModified == "" {
+			return resp, nil
+		}
+		body, err := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+		t.Cache.put(&cacheEntry{URL: url, ETag: etag, LastModified: lastModified, Body: body})
+		resp.Body = io.NopCloser(bytes.NewReader(body))
+	}
+	return resp, nil
+}
+
+// cachedResponse builds a 200 response with a cached body and the headers of
+// the 304 that confirmed it, which include the current rate limit.
+func cachedResponse(req *http.Request, header http.Header, body []byte) *http.Response {
+	header = header.Clone()
+	header.Set(FromCacheHeader, "1")
+	header.Set("Content-Length", strconv.Itoa(len(body)))
+	return &http.Response{
+		Status:        "200 OK",
+		StatusCode:    http.StatusOK,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        header,
+		Body:          io.NopCloser(bytes.NewReader(body)),
+		ContentLength: int64(len(body)),
+		Request:       req,
+	}
+}
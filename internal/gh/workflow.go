@@ -0,0 +1,44 @@
+package gh
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"os/exec"
+	"strconv"
+	"strings"
+)
+
+// FetchWorkflowFile returns the contents of the workflow file a run was
+// started from, as of the run's head commit. When the current directory is a
+// checkout of repo that has the commit, the file is read locally; otherwise
+// it is fetched from the API.
+func FetchWorkflowFile(ctx context.Context, repo string, runID int, headSha string) ([]byte, error) {
+	out, err := output(ctx, "api",
+		"repos/"+repo+"/actions/runs/"+strconv.Itoa(runID),
+		"--jq", ".path",
+	)
+	if err != nil {
+		return nil, fmt.Errorf("gh api run failed: %w", err)
+	}
+	// Runs of reusable workflows report "path@ref"; the file is the same.
+	path, _, _ := strings.Cut(strings.TrimSpace(string(out)), "@")
+	if path == "" {
+		return nil, fmt.Errorf("run %d has no workflow file", runID)
+	}
+
+	if local, err := detectRepo(ctx); err == nil && strings.EqualFold(local, repo) {
+		if data, err := exec.CommandContext(ctx, "git", "show", headSha+":"+path).Output(); err == nil {
+			return data, nil
+		}
+	}
+
+	data, err := output(ctx, "api",
+		"-H", "Accept: application/vnd.github.raw+json",
+		"repos/"+repo+"/contents/"+path+"?ref="+url.QueryEscape(headSha),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("gh api contents failed: %w", err)
+	}
+	return data, nil
+}
@@ -0,0 +1,192 @@
+// Package matrix groups the jobs a matrix strategy expands into, such as
+// "test (ubuntu, 1.22)" and "test (macos, 1.23)", under their parent job.
+package matrix
+
+import (
+	"strings"
+
+	"github.com/dzoba/github-actions-watcher/internal/types"
+)
+
+// Parse splits a job name of the form "base (a, b, ...)" into its base name
+// and matrix values. ok is false for names without a trailing value list.
+func Parse(name string) (base string, values []string, ok bool) {
+	if !strings.HasSuffix(name, ")") {
+		return name, nil, false
+	}
+	// Find the parenthesis matching the trailing one; values may themselves
+	// contain parentheses, e.g. "e2e (shard (1), 4)".
+	depth, open := 0, -1
+	for i := len(name) - 1; i >= 0 && open < 0; i-- {
+		switch name[i] {
+		case ')':
+			depth++
+		case '(':
+			depth--
+			if depth == 0 {
+				open = i
+			}
+		}
+	}
+	if open < 2 || name[open-1] != ' ' {
+		return name, nil, false
+	}
+	inner := name[open+1 : len(name)-1]
+	if strings.TrimSpace(inner) == "" {
+		return name, nil, false
+	}
+	depth, start := 0, 0
+	for i := 0; i < len(inner); i++ {
+		switch inner[i] {
+		case '(':
+			depth++
+		case ')':
+			depth--
+		case ',':
+			if depth == 0 {
+				values = append(values, strings.TrimSpace(inner[start:i]))
+				start = i + 1
+			}
+		}
+	}
+	values = append(values, strings.TrimSpace(inner[start:]))
+	return name[:open-1], values, true
+}
+
+// Group is a set of jobs sharing a base name. Jobs indexes into the slice
+// passed to GroupJobs; Values holds the parsed matrix values of each job.
+type Group struct {
+	Name   string
+	Jobs   []int
+	Values [][]string
+}
+
+// IsMatrix reports whether the group holds more than one matrix job.
+func (g Group) IsMatrix() bool {
+	return len(g.Jobs) > 1
+}
+
+// GroupJobs groups matrix jobs by base name, in order of first appearance.
+// Jobs that aren't part of a matrix get a group of their own.
+func GroupJobs(jobs []types.Job) []Group {
+	var groups []Group
+	byName := make(map[string]int)
+	for i, job := range jobs {
+		base, values, ok := Parse(job.Name)
+		if !ok {
+			groups = append(groups, Group{Name: job.Name, Jobs: []int{i}})
+			continue
+		}
+		gi, seen := byName[base]
+		if !seen {
+			gi = len(groups)
+			byName[base] = gi
+			groups = append(groups, Group{Name: base})
+		}
+		groups[gi].Jobs = append(groups[gi].Jobs, i)
+		groups[gi].Values = append(groups[gi].Values, values)
+	}
+	// A lone "build (linux)" is just a job with a descriptive suffix.
+	for i, g := range groups {
+		if len(g.Jobs) == 1 {
+			groups[i].Name = jobs[g.Jobs[0]].Name
+			groups[i].Values = nil
+		}
+	}
+	return groups
+}
+
+// Counts tallies the outcomes of a group's jobs.
+type Counts struct {
+	Passed  int
+	Failed  int
+	Running int
+	Queued  int
+	Other   int
+}
+
+// Count returns the outcome tally of the group's jobs.
+func (g Group) Count(jobs []types.Job) Counts {
+	var c Counts
+	for _, ji := range g.Jobs {
+		job := jobs[ji]
+		switch job.Status {
+		case types.StatusInProgress:
+			c.Running++
+			continue
+		case types.StatusQueued, types.StatusWaiting, types.StatusPending, types.StatusRequested:
+			c.Queued++
+			continue
+		}
+		switch job.Conclusion {
+		case types.ConclusionSuccess:
+			c.Passed++
+		case types.ConclusionFailure, types.ConclusionTimedOut:
+			c.Failed++
+		default:
+			c.Other++
+		}
+	}
+	return c
+}
+
+// Status returns an aggregate status and conclusion for the group, suitable
+// for format.StatusBadge: running if any job runs, then queued, then failed
+// if any job failed, and passed only if no job did anything else.
+func (g Group) Status(jobs []types.Job) (types.RunStatus, types.RunConclusion) {
+	c := g.Count(jobs)
+	switch {
+	case c.Running > 0:
+		return types.StatusInProgress, ""
+	case c.Queued > 0:
+		return types.StatusQueued, ""
+	case c.Failed > 0:
+		return types.StatusCompleted, types.ConclusionFailure
+	case c.Passed > 0 && c.Other == 0:
+		return types.StatusCompleted, types.ConclusionSuccess
+	case c.Passed == 0 && c.Other > 0:
+		// All skipped or cancelled: report what the first job says.
+		return types.StatusCompleted, jobs[g.Jobs[0]].Conclusion
+	}
+	return types.StatusCompleted, types.ConclusionNeutral
+}
+
+// Grid lays a group's jobs out by their matrix values: rows by the first
+// value, columns by the remaining ones joined together. Cells hold an index
+// into Group.Jobs, or -1 where no job has that combination.
+type Grid struct {
+	Rows  []string
+	Cols  []string
+	Cells [][]int
+}
+
+// Grid builds the grid for a matrix group.
+func (g Group) Grid() Grid {
+	var grid Grid
+	rowIdx := make(map[string]int)
+	colIdx := make(map[string]int)
+	keys := make([][2]string, len(g.Values))
+	for i, values := range g.Values {
+		row, col := values[0], strings.Join(values[1:], ", ")
+		keys[i] = [2]string{row, col}
+		if _, ok := rowIdx[row]; !ok {
+			rowIdx[row] = len(grid.Rows)
+			grid.Rows = append(grid.Rows, row)
+		}
+		if _, ok := colIdx[col]; !ok {
+			colIdx[col] = len(grid.Cols)
+			grid.Cols = append(grid.Cols, col)
+		}
+	}
+	grid.Cells = make([][]int, len(grid.Rows))
+	for r := range grid.Cells {
+		grid.Cells[r] = make([]int, len(grid.Cols))
+		for c := range grid.Cells[r] {
+			grid.Cells[r][c] = -1
+		}
+	}
+	for i, k := range keys {
+		grid.Cells[rowIdx[k[0]]][colIdx[k[1]]] = i
+	}
+	return grid
+}
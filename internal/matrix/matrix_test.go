@@ -0,0 +1,88 @@
+package matrix
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/dzoba/github-actions-watcher/internal/types"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name   string
+		base   string
+		values []string
+		ok     bool
+	}{
+		{"test (ubuntu, 1.22)", "test", []string{"ubuntu", "1.22"}, true},
+		{"build (linux)", "build", []string{"linux"}, true},
+		{"e2e (shard (1), 4)", "e2e", []string{"shard (1)", "4"}, true},
+		{"lint", "lint", nil, false},
+		{"deploy ()", "deploy ()", nil, false},
+		{"(weird)", "(weird)", nil, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			base, values, ok := Parse(tt.name)
+			if base != tt.base || !reflect.DeepEqual(values, tt.values) || ok != tt.ok {
+				t.Errorf("Parse(%q) = %q, %q, %v; want %q, %q, %v", tt.name, base, values, ok, tt.base, tt.values, tt.ok)
+			}
+		})
+	}
+}
+
+func job(name string, status types.RunStatus, conclusion types.RunConclusion) types.Job {
+	return types.Job{Name: name, Status: status, Conclusion: conclusion}
+}
+
+func TestGroupJobs(t *testing.T) {
+	jobs := []types.Job{
+		job("lint", types.StatusCompleted, types.ConclusionSuccess),
+		job("test (ubuntu, 1.22)", types.StatusCompleted, types.ConclusionSuccess),
+		job("build (linux)", types.StatusCompleted, types.ConclusionSuccess),
+		job("test (ubuntu, 1.23)", types.StatusCompleted, types.ConclusionFailure),
+		job("test (macos, 1.22)", types.StatusInProgress, ""),
+	}
+	groups := GroupJobs(jobs)
+	if len(groups) != 3 {
+		t.Fatalf("got %d groups, want 3", len(groups))
+	}
+	if groups[1].Name != "test" || !reflect.DeepEqual(groups[1].Jobs, []int{1, 3, 4}) {
+		t.Errorf("test group = %+v", groups[1])
+	}
+	if groups[2].Name != "build (linux)" || groups[2].IsMatrix() {
+		t.Errorf("lone matrix job should stay a plain job, got %+v", groups[2])
+	}
+
+	c := groups[1].Count(jobs)
+	if c.Passed != 1 || c.Failed != 1 || c.Running != 1 {
+		t.Errorf("Count() = %+v", c)
+	}
+	if s, _ := groups[1].Status(jobs); s != types.StatusInProgress {
+		t.Errorf("Status() = %q, want in_progress", s)
+	}
+
+	grid := groups[1].Grid()
+	if !reflect.DeepEqual(grid.Rows, []string{"ubuntu", "macos"}) || !reflect.DeepEqual(grid.Cols, []string{"1.22", "1.23"}) {
+		t.Fatalf("grid axes = %q x %q", grid.Rows, grid.Cols)
+	}
+	want := [][]int{{0, 1}, {2, -1}}
+	if !reflect.DeepEqual(grid.Cells, want) {
+		t.Errorf("grid cells = %v, want %v", grid.Cells, want)
+	}
+}
+
+func TestGroupStatus(t *testing.T) {
+	jobs := []types.Job{
+		job("t (a)", types.StatusCompleted, types.ConclusionSuccess),
+		job("t (b)", types.StatusCompleted, types.ConclusionFailure),
+	}
+	g := GroupJobs(jobs)[0]
+	if _, c := g.Status(jobs); c != types.ConclusionFailure {
+		t.Errorf("conclusion = %q, want failure", c)
+	}
+	jobs[1].Conclusion = types.ConclusionSuccess
+	if _, c := g.Status(jobs); c != types.ConclusionSuccess {
+		t.Errorf("conclusion = %q, want success", c)
+	}
+}
@@ -0,0 +1,165 @@
+// Package metrics exposes the poller's state in the Prometheus text
+// exposition format. The format is simple enough to write by hand, which
+// keeps the Prometheus client library out of the binary.
+package metrics
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/dzoba/github-actions-watcher/internal/poller"
+	"github.com/dzoba/github-actions-watcher/internal/stats"
+	"github.com/dzoba/github-actions-watcher/internal/types"
+)
+
+// Handler serves the metrics of p at every request.
+func Handler(p *poller.Poller) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+		Write(w, p.State(), time.Now())
+	})
+}
+
+// family is one metric with its samples.
+type family struct {
+	name, typ, help string
+	samples         []sample
+}
+
+type sample struct {
+	labels []string // name, value pairs
+	value  float64
+}
+
+func (f *family) add(value float64, labels ...string) {
+	f.samples = append(f.samples, sample{labels: labels, value: value})
+}
+
+// Write writes the metrics of s as of now.
+func Write(w io.Writer, s poller.State, now time.Time) error {
+	runs := &family{name: "ghaw_runs", typ: "gauge", help: "Runs in the latest run list by workflow, status and conclusion."}
+	inProgress := &family{name: "ghaw_runs_in_progress", typ: "gauge", help: "Runs in progress."}
+	queued := &family{name: "ghaw_runs_queued", typ: "gauge", help: "Runs queued, waiting or pending."}
+	duration := &family{name: "ghaw_last_run_duration_seconds", typ: "gauge", help: "Duration of the latest finished run per workflow and branch."}
+	age := &family{name: "ghaw_last_run_age_seconds", typ: "gauge", help: "Time since the latest finished run per workflow and branch finished."}
+	success := &family{name: "ghaw_last_run_success", typ: "gauge", help: "Whether the latest finished run per workflow and branch succeeded (1) or not (0)."}
+	fetches := &family{name: "ghaw_fetches_total", typ: "counter", help: "Run list fetches attempted."}
+	errors := &family{name: "ghaw_fetch_errors_total", typ: "counter", help: "Run list fetches that failed."}
+	lastFetch := &family{name: "ghaw_last_fetch_timestamp_seconds", typ: "gauge", help: "Unix time of the last successful run list fetch."}
+	rateRemaining := &family{name: "ghaw_rate_limit_remaining", typ: "gauge", help: "Requests left in the GitHub API rate limit."}
+	rateLimit := &family{name: "ghaw_rate_limit_limit", typ: "gauge", help: "Requests allowed per GitHub API rate limit window."}
+	rateReset := &family{name: "ghaw_rate_limit_reset_timestamp_seconds", typ: "gauge", help: "Unix time the GitHub API rate limit resets."}
+
+	for _, rs := range s.Repos {
+		repo := rs.Repo
+		fetches.add(float64(rs.Fetches), "repo", repo)
+		errors.add(float64(rs.Errors), "repo", repo)
+		if !rs.FetchedAt.IsZero() {
+			lastFetch.add(float64(rs.FetchedAt.Unix()), "repo", repo)
+		}
+
+		type statusKey struct {
+			workflow   string
+			status     types.RunStatus
+			conclusion types.RunConclusion
+		}
+		counts := make(map[statusKey]int)
+		running, waiting := 0, 0
+		type branchKey struct{ workflow, branch string }
+		latest := make(map[branchKey]types.WorkflowRun)
+		for _, r := range rs.Runs {
+			counts[statusKey{r.WorkflowName, r.Status, r.Conclusion}]++
+			switch r.Status {
+			case types.StatusInProgress:
+				running++
+			case types.StatusQueued, types.StatusWaiting, types.StatusPending, types.StatusRequested:
+				waiting++
+			}
+			if r.Status != types.StatusCompleted {
+				continue
+			}
+			k := branchKey{r.WorkflowName, r.HeadBranch}
+			if cur, ok := latest[k]; !ok || r.CreatedAt > cur.CreatedAt {
+				latest[k] = r
+			}
+		}
+		for k, n := range counts {
+			runs.add(float64(n), "repo", repo, "workflow", k.workflow, "status", string(k.status), "conclusion", string(k.conclusion))
+		}
+		inProgress.add(float64(running), "repo", repo)
+		queued.add(float64(waiting), "repo", repo)
+		for k, r := range latest {
+			labels := []string{"repo", repo, "workflow", k.workflow, "branch", k.branch}
+			if d, ok := stats.RunDuration(r); ok {
+				duration.add(d.Seconds(), labels...)
+			}
+			if end, err := time.Parse(time.RFC3339, r.UpdatedAt); err == nil {
+				age.add(max(now.Sub(end), 0).Truncate(time.Second).Seconds(), labels...)
+			}
+			ok := 0.0
+			if r.Conclusion == types.ConclusionSuccess {
+				ok = 1
+			}
+			success.add(ok, labels...)
+		}
+	}
+	if rl := s.RateLimit; rl != nil {
+		rateRemaining.add(float64(rl.Remaining))
+		rateLimit.add(float64(rl.Limit))
+		rateReset.add(float64(rl.Reset))
+	}
+
+	for _, f := range []*family{runs, inProgress, queued, duration, age, success, fetches, errors, lastFetch, rateRemaining, rateLimit, rateReset} {
+		if len(f.samples) == 0 {
+			continue
+		}
+		if err := f.write(w); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (f *family) write(w io.Writer) error {
+	var b strings.Builder
+	fmt.Fprintf(&b, "# HELP %s %s\n", f.name, f.help)
+	fmt.Fprintf(&b, "# TYPE %s %s\n", f.name, f.typ)
+	lines := make([]string, len(f.samples))
+	for i, s := range f.samples {
+		lines[i] = f.name + labelString(s.labels) + " " + formatValue(s.value)
+	}
+	sort.Strings(lines)
+	for _, l := range lines {
+		b.WriteString(l)
+		b.WriteByte('\n')
+	}
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+func labelString(labels []string) string {
+	if len(labels) == 0 {
+		return ""
+	}
+	parts := make([]string, 0, len(labels)/2)
+	for i := 0; i+1 < len(labels); i += 2 {
+		parts = append(parts, labels[i]+`="`+escape(labels[i+1])+`"`)
+	}
+	return "{" + strings.Join(parts, ",") + "}"
+}
+
+// escape escapes a label value: backslash, double quote and newline.
+func escape(s string) string {
+	return strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`).Replace(s)
+}
+
+func formatValue(v float64) string {
+	if v == float64(int64(v)) {
+		return fmt.Sprintf("%d", int64(v))
+	}
+	return fmt.Sprintf("%g", v)
+}
@@ -0,0 +1,67 @@
+package metrics
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/dzoba/github-actions-watcher/internal/gh"
+	"github.com/dzoba/github-actions-watcher/internal/poller"
+	"github.com/dzoba/github-actions-watcher/internal/poller/pollertest"
+	"github.com/dzoba/github-actions-watcher/internal/types"
+)
+
+func TestScrape(t *testing.T) {
+	ago := func(d time.Duration) string { return time.Now().Add(-d).UTC().Format(time.RFC3339) }
+	b := &pollertest.Backend{Runs: map[string][]types.WorkflowRun{"o/r": {
+		{DatabaseID: 4, WorkflowName: "CI", HeadBranch: "main", Status: types.StatusInProgress, CreatedAt: ago(time.Minute)},
+		{DatabaseID: 3, WorkflowName: "CI", HeadBranch: "main", Status: types.StatusQueued, CreatedAt: ago(time.Minute)},
+		{DatabaseID: 2, WorkflowName: "CI", HeadBranch: "main", Status: types.StatusCompleted, Conclusion: types.ConclusionFailure,
+			CreatedAt: ago(time.Hour), StartedAt: ago(time.Hour), UpdatedAt: ago(50 * time.Minute)},
+		{DatabaseID: 1, WorkflowName: "CI", HeadBranch: "main", Status: types.StatusCompleted, Conclusion: types.ConclusionSuccess,
+			CreatedAt: ago(2 * time.Hour), StartedAt: ago(2 * time.Hour), UpdatedAt: ago(time.Hour)},
+		{DatabaseID: 0, WorkflowName: `say "hi"`, HeadBranch: "dev", Status: types.StatusCompleted, Conclusion: types.ConclusionSuccess},
+	}}}
+	b.RateLimit = gh.RateLimit{Limit: 5000, Remaining: 4999, Reset: 1700000000}
+	p := poller.New(b, []string{"o/r", "o/gone"}, time.Minute)
+	p.Poll(context.Background())
+
+	srv := httptest.NewServer(Handler(p))
+	defer srv.Close()
+	resp, err := http.Get(srv.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	if ct := resp.Header.Get("Content-Type"); !strings.HasPrefix(ct, "text/plain; version=0.0.4") {
+		t.Errorf("Content-Type = %q", ct)
+	}
+	body, _ := io.ReadAll(resp.Body)
+	got := string(body)
+
+	for _, want := range []string{
+		"# TYPE ghaw_runs gauge",
+		`ghaw_runs{repo="o/r",workflow="CI",status="completed",conclusion="failure"} 1`,
+		`ghaw_runs{repo="o/r",workflow="say \"hi\"",status="completed",conclusion="success"} 1`,
+		`ghaw_runs_in_progress{repo="o/r"} 1`,
+		`ghaw_runs_queued{repo="o/r"} 1`,
+		`ghaw_last_run_duration_seconds{repo="o/r",workflow="CI",branch="main"} 600`,
+		`ghaw_last_run_success{repo="o/r",workflow="CI",branch="main"} 0`,
+		`ghaw_fetch_errors_total{repo="o/gone"} 1`,
+		`ghaw_fetches_total{repo="o/r"} 1`,
+		"ghaw_rate_limit_remaining 4999",
+		"ghaw_rate_limit_reset_timestamp_seconds 1700000000",
+	} {
+		if !strings.Contains(got, want+"\n") {
+			t.Errorf("missing %q in:\n%s", want, got)
+		}
+	}
+	if !regexp.MustCompile(`ghaw_last_run_age_seconds\{repo="o/r",workflow="CI",branch="main"\} 300[01]\n`).MatchString(got) {
+		t.Errorf("missing last run age of 50m in:\n%s", got)
+	}
+}
@@ -0,0 +1,249 @@
+package model
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/dzoba/github-actions-watcher/internal/format"
+	"github.com/dzoba/github-actions-watcher/internal/rundiff"
+	"github.com/dzoba/github-actions-watcher/internal/types"
+	"github.com/dzoba/github-actions-watcher/internal/ui"
+)
+
+// stepDeltaMin is the smallest change in a step's duration worth listing.
+const stepDeltaMin = 10 * time.Second
+
+// commitsShown caps the commits listed between two compared runs.
+const commitsShown = 15
+
+// toggleMark marks a run for comparison, or unmarks it. Marking a third run
+// drops the oldest mark.
+func (t *repoTab) toggleMark(run types.WorkflowRun) {
+	for i, r := range t.marked {
+		if r.DatabaseID == run.DatabaseID {
+			t.marked = append(t.marked[:i:i], t.marked[i+1:]...)
+			return
+		}
+	}
+	t.marked = append(t.marked, run)
+	if len(t.marked) > 2 {
+		t.marked = t.marked[1:]
+	}
+}
+
+func (t repoTab) isMarked(id int) bool {
+	for _, r := range t.marked {
+		if r.DatabaseID == id {
+			return true
+		}
+	}
+	return false
+}
+
+// comparePair picks the runs to compare: the two marked runs, the marked run
+// and the selected one, or else the selected run and the previous run of its
+// workflow, preferably on the same branch. The older run comes first.
+func (t repoTab) comparePair() (old, new types.WorkflowRun, ok bool) {
+	var selected *types.WorkflowRun
+	if runs := t.listRuns(); t.selectedIndex < len(runs) {
+		selected = &runs[t.selectedIndex]
+	}
+	switch {
+	case len(t.marked) == 2:
+		old, new = t.marked[0], t.marked[1]
+	case len(t.marked) == 1 && selected != nil && selected.DatabaseID != t.marked[0].DatabaseID:
+		old, new = t.marked[0], *selected
+	case len(t.marked) == 0 && selected != nil:
+		prev, found := t.previousRun(*selected)
+		if !found {
+			return old, new, false
+		}
+		old, new = prev, *selected
+	default:
+		return old, new, false
+	}
+	if new.CreatedAt < old.CreatedAt || (new.CreatedAt == old.CreatedAt && new.DatabaseID < old.DatabaseID) {
+		old, new = new, old
+	}
+	return old, new, true
+}
+
+// previousRun finds the latest run of the same workflow created before run,
+// on the same branch if there is one.
+func (t repoTab) previousRun(run types.WorkflowRun) (types.WorkflowRun, bool) {
+	var best, sameBranch *types.WorkflowRun
+	for _, list := range [][]types.WorkflowRun{t.runs, t.history} {
+		for i := range list {
+			r := &list[i]
+			if r.WorkflowName != run.WorkflowName || r.DatabaseID == run.DatabaseID || r.CreatedAt >= run.CreatedAt {
+				continue
+			}
+			if best == nil || r.CreatedAt > best.CreatedAt {
+				best = r
+			}
+			if r.HeadBranch == run.HeadBranch && (sameBranch == nil || r.CreatedAt > sameBranch.CreatedAt) {
+				sameBranch = r
+			}
+		}
+	}
+	switch {
+	case sameBranch != nil:
+		return *sameBranch, true
+	case best != nil:
+		return *best, true
+	}
+	return types.WorkflowRun{}, false
+}
+
+func (t repoTab) compareIDs() [2]int {
+	return [2]int{t.compareRuns[0].DatabaseID, t.compareRuns[1].DatabaseID}
+}
+
+func shortSha(sha string) string {
+	if len(sha) > 7 {
+		return sha[:7]
+	}
+	return sha
+}
+
+func badge(status types.RunStatus, conclusion types.RunConclusion) string {
+	text, color := format.StatusBadge(status, conclusion)
+	return ui.BadgeStyle(color).Render(text)
+}
+
+func deltaText(d time.Duration, min time.Duration) string {
+	switch {
+	case d >= min:
+		return ui.Red.Render(format.Delta(d))
+	case d <= -min:
+		return ui.Green.Render(format.Delta(d))
+	}
+	return ui.Dim.Render(format.Delta(d))
+}
+
+var changeMarkers = map[rundiff.Change]string{
+	rundiff.Same:      "  ",
+	rundiff.Added:     ui.Green.Render("+ "),
+	rundiff.Removed:   ui.Red.Render("- "),
+	rundiff.Concluded: ui.Yellow.Render("~ "),
+}
+
+// compareRows draws the two runs, the commits between them and a job by job
+// diff with the steps that changed.
+func (m Model) compareRows() []string {
+	t := m.tabs[m.activeTab]
+	old, new := t.compareOld, t.compareNew
+	if old == nil || new == nil {
+		return nil
+	}
+
+	var rows []string
+	for _, side := range []struct {
+		label string
+		d     *types.RunDetail
+	}{{"old", old}, {"new", new}} {
+		d := side.d
+		rows = append(rows, fmt.Sprintf("  %s #%d %s %s %s %s  %s",
+			ui.Dim.Render(side.label),
+			d.Number,
+			badge(d.Status, d.Conclusion),
+			ui.Magenta.Render(d.HeadBranch),
+			shortSha(d.HeadSha),
+			ui.Dim.Render(format.RelativeTime(d.CreatedAt)),
+			d.DisplayTitle,
+		))
+	}
+	rows = append(rows, "")
+
+	switch {
+	case old.HeadSha == new.HeadSha:
+		rows = append(rows, ui.Dim.Render("  Same commit "+shortSha(new.HeadSha)))
+	case t.compareRange == nil:
+		rows = append(rows, ui.Dim.Render("  Commit range unavailable"))
+	default:
+		c := t.compareRange
+		summary := fmt.Sprintf("  %d commits %s..%s", len(c.Commits), shortSha(old.HeadSha), shortSha(new.HeadSha))
+		if c.Status == "diverged" || c.Status == "behind" {
+			summary += fmt.Sprintf(" (%s: %d ahead, %d behind)", c.Status, c.AheadBy, c.BehindBy)
+		}
+		rows = append(rows, ui.Bold.Render(summary))
+		commits := c.Commits
+		if len(commits) > commitsShown {
+			commits = commits[len(commits)-commitsShown:]
+		}
+		for i := len(commits) - 1; i >= 0; i-- {
+			cm := commits[i]
+			msg, _, _ := strings.Cut(cm.Message, "\n")
+			rows = append(rows, fmt.Sprintf("    %s %s %s", ui.Yellow.Render(shortSha(cm.SHA)), msg, ui.Dim.Render("- "+cm.Author)))
+		}
+		if more := len(c.Commits) - len(commits); more > 0 {
+			rows = append(rows, ui.Dim.Render(fmt.Sprintf("    ... %d earlier", more)))
+		}
+	}
+	rows = append(rows, "")
+
+	for _, jd := range rundiff.Diff(old, new) {
+		var b strings.Builder
+		b.WriteString("  " + changeMarkers[jd.Change])
+		switch jd.Change {
+		case rundiff.Added:
+			b.WriteString(badge(jd.New.Status, jd.New.Conclusion))
+		case rundiff.Removed:
+			b.WriteString(badge(jd.Old.Status, jd.Old.Conclusion))
+		case rundiff.Concluded:
+			b.WriteString(badge(jd.Old.Status, jd.Old.Conclusion) + " → " + badge(jd.New.Status, jd.New.Conclusion))
+		default:
+			b.WriteString(badge(jd.New.Status, jd.New.Conclusion))
+		}
+		b.WriteString(" " + ui.Bold.Render(jd.Name))
+		if jd.HasDelta {
+			b.WriteString(" " + deltaText(jd.Delta, stepDeltaMin))
+		}
+		rows = append(rows, b.String())
+
+		for _, sd := range jd.Steps {
+			if sd.Change == rundiff.Same && (!sd.HasDelta || (sd.Delta < stepDeltaMin && sd.Delta > -stepDeltaMin)) {
+				continue
+			}
+			var b strings.Builder
+			b.WriteString("      " + changeMarkers[sd.Change])
+			switch sd.Change {
+			case rundiff.Added:
+				b.WriteString(badge(sd.New.Status, sd.New.Conclusion))
+			case rundiff.Removed:
+				b.WriteString(badge(sd.Old.Status, sd.Old.Conclusion))
+			case rundiff.Concluded:
+				b.WriteString(badge(sd.Old.Status, sd.Old.Conclusion) + " → " + badge(sd.New.Status, sd.New.Conclusion))
+			default:
+				b.WriteString(badge(sd.New.Status, sd.New.Conclusion))
+			}
+			b.WriteString(" " + sd.Name)
+			if sd.HasDelta {
+				b.WriteString(" " + deltaText(sd.Delta, stepDeltaMin))
+			}
+			rows = append(rows, b.String())
+		}
+	}
+	return rows
+}
+
+func (m Model) compareView() string {
+	t := m.tabs[m.activeTab]
+	title := ui.Bold.Render("Compare runs")
+	switch {
+	case t.compareLoading:
+		title += ui.Dim.Render(" (loading...)")
+	case t.compareError != "":
+		title += ui.Red.Render(" (" + t.compareError + ")")
+	}
+	lines := []string{title, ""}
+
+	rows := m.compareRows()
+	if len(rows) == 0 {
+		lines = append(lines, ui.Dim.Render("Loading both runs..."))
+	} else {
+		lines = append(lines, t.scroll.render(rows, m.scrollHeight()))
+	}
+	return strings.Join(lines, "\n")
+}
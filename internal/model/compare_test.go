@@ -0,0 +1,52 @@
+package model
+
+import (
+	"testing"
+
+	"github.com/dzoba/github-actions-watcher/internal/types"
+)
+
+func compareTab() repoTab {
+	run := func(id int, wf, branch, created string) types.WorkflowRun {
+		return types.WorkflowRun{DatabaseID: id, WorkflowName: wf, HeadBranch: branch, CreatedAt: created}
+	}
+	// Newest first, as gh lists them.
+	return repoTab{runs: []types.WorkflowRun{
+		run(5, "CI", "main", "2024-01-05T00:00:00Z"),
+		run(4, "Lint", "main", "2024-01-04T00:00:00Z"),
+		run(3, "CI", "dev", "2024-01-03T00:00:00Z"),
+		run(2, "CI", "main", "2024-01-02T00:00:00Z"),
+		run(1, "CI", "main", "2024-01-01T00:00:00Z"),
+	}}
+}
+
+func TestComparePairPrevious(t *testing.T) {
+	tab := compareTab()
+	old, new, ok := tab.comparePair()
+	if !ok || old.DatabaseID != 2 || new.DatabaseID != 5 {
+		t.Errorf("comparePair() = %d, %d, %v; want the previous CI run on main", old.DatabaseID, new.DatabaseID, ok)
+	}
+
+	tab.selectedIndex = 4
+	if _, _, ok := tab.comparePair(); ok {
+		t.Error("comparePair() found a run before the oldest")
+	}
+}
+
+func TestComparePairMarked(t *testing.T) {
+	tab := compareTab()
+	tab.toggleMark(tab.runs[0])
+	tab.toggleMark(tab.runs[3])
+	tab.toggleMark(tab.runs[2]) // drops the first mark
+	old, new, ok := tab.comparePair()
+	if !ok || old.DatabaseID != 2 || new.DatabaseID != 3 {
+		t.Errorf("comparePair() = %d, %d, %v; want 2 and 3", old.DatabaseID, new.DatabaseID, ok)
+	}
+
+	tab.toggleMark(tab.runs[2]) // unmark
+	tab.selectedIndex = 1
+	old, new, ok = tab.comparePair()
+	if !ok || old.DatabaseID != 2 || new.DatabaseID != 4 {
+		t.Errorf("comparePair() = %d, %d, %v; want the mark and the selected run", old.DatabaseID, new.DatabaseID, ok)
+	}
+}
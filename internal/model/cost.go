@@ -0,0 +1,109 @@
+package model
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/dzoba/github-actions-watcher/internal/cost"
+	"github.com/dzoba/github-actions-watcher/internal/format"
+	"github.com/dzoba/github-actions-watcher/internal/ui"
+)
+
+// costRunsShown is how many of the most expensive runs are listed.
+const costRunsShown = 10
+
+// costRows lists billable minutes and estimated cost for the repo, per
+// workflow, and for its most expensive runs.
+func (m Model) costRows() []string {
+	t := m.tabs[m.activeTab]
+	if len(t.costTimings) == 0 {
+		return nil
+	}
+	s := cost.Summarize(t.costRuns, t.costTimings, m.rates)
+	widest := m.windows[0]
+	for _, w := range m.windows {
+		if w.Dur > widest.Dur {
+			widest = w
+		}
+	}
+
+	rows := []string{
+		fmt.Sprintf("  Last %s: %s over %d runs", widest.Label, ui.Bold.Render(cost.Describe(s.Total)), len(s.Runs)),
+	}
+	if t.costMissing > 0 {
+		rows = append(rows, ui.Dim.Render(fmt.Sprintf("  %d runs without timing yet; refresh to fetch more", t.costMissing)))
+	}
+	rows = append(rows, "")
+
+	nameWidth := len("WORKFLOW")
+	for _, w := range s.Workflows {
+		nameWidth = max(nameWidth, len(w.Workflow))
+	}
+	nameWidth = min(nameWidth, 28)
+	rows = append(rows, ui.Dim.Render(fmt.Sprintf("  %s %5s %8s %9s  %s", format.Pad("WORKFLOW", nameWidth), "RUNS", "MINUTES", "COST", "BY OS")))
+	for _, w := range s.Workflows {
+		rows = append(rows, fmt.Sprintf("  %s %5d %8d %s  %s",
+			ui.Blue.Render(format.Pad(format.Truncate(w.Workflow, nameWidth), nameWidth)),
+			w.Runs,
+			w.Total(),
+			ui.Yellow.Render(fmt.Sprintf("%9s", fmt.Sprintf("$%.2f", w.Cost))),
+			ui.Dim.Render(byOS(w.Usage)),
+		))
+	}
+
+	runs := s.Runs
+	if len(runs) > costRunsShown {
+		runs = runs[:costRunsShown]
+	}
+	if len(runs) > 0 && runs[0].Total() > 0 {
+		rows = append(rows, "", ui.Dim.Render("  Most expensive runs"))
+		for _, r := range runs {
+			if r.Total() == 0 {
+				break
+			}
+			rows = append(rows, fmt.Sprintf("  %s %s %s %s  %s",
+				ui.Yellow.Render(fmt.Sprintf("%9s", fmt.Sprintf("$%.2f", r.Cost))),
+				fmt.Sprintf("%5d min", r.Total()),
+				ui.Blue.Render(r.Run.WorkflowName),
+				fmt.Sprintf("#%d", r.Run.Number),
+				ui.Magenta.Render(r.Run.HeadBranch),
+			))
+		}
+	}
+	rows = append(rows, "", ui.Dim.Render("  Jobs round up to whole minutes; self-hosted runners aren't billed."))
+	return rows
+}
+
+// byOS lists minutes per runner OS, e.g. "macos 20, ubuntu 310".
+func byOS(u cost.Usage) string {
+	oses := u.OSes()
+	parts := make([]string, len(oses))
+	for i, os := range oses {
+		parts[i] = fmt.Sprintf("%s %d", strings.ToLower(os), u.Minutes[os])
+	}
+	return strings.Join(parts, ", ")
+}
+
+func (m Model) costView() string {
+	t := m.tabs[m.activeTab]
+	title := ui.Bold.Render("Billable minutes")
+	switch {
+	case t.costLoading:
+		title += ui.Dim.Render(" (fetching run timings...)")
+	case t.costError != "":
+		title += ui.Red.Render(" (" + t.costError + ")")
+	}
+	lines := []string{title, ""}
+
+	rows := m.costRows()
+	if len(rows) == 0 {
+		if t.costLoading {
+			lines = append(lines, ui.Dim.Render("Fetching run timings..."))
+		} else {
+			lines = append(lines, ui.Dim.Render("No finished runs in this period."))
+		}
+	} else {
+		lines = append(lines, t.scroll.render(rows, m.scrollHeight()))
+	}
+	return strings.Join(lines, "\n")
+}
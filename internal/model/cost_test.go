@@ -0,0 +1,34 @@
+package model
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/dzoba/github-actions-watcher/internal/types"
+)
+
+func TestCostRows(t *testing.T) {
+	m := New(Options{})
+	runs := []types.WorkflowRun{
+		{DatabaseID: 1, Number: 1, WorkflowName: "CI", Status: types.StatusCompleted},
+		{DatabaseID: 2, Number: 2, WorkflowName: "Release", Status: types.StatusCompleted},
+	}
+	linux := &types.RunTiming{Billable: map[string]types.OSTiming{"UBUNTU": {TotalMS: 600_000}}}
+	mac := &types.RunTiming{Billable: map[string]types.OSTiming{"MACOS": {TotalMS: 600_000}}}
+	m.tabs = []repoTab{{view: types.ViewCost, costRuns: runs, costTimings: map[int]*types.RunTiming{1: linux, 2: mac}}}
+
+	rows := m.costRows()
+	if len(rows) == 0 || !strings.Contains(rows[0], "20 min (macos 10, ubuntu 10) ≈ $0.88 over 2 runs") {
+		t.Fatalf("rows = %q", rows)
+	}
+	// The macOS workflow costs more, so it comes first.
+	for i, row := range rows {
+		if strings.Contains(row, "WORKFLOW") {
+			if !strings.Contains(rows[i+1], "Release") {
+				t.Errorf("first workflow row = %q, want Release", rows[i+1])
+			}
+			return
+		}
+	}
+	t.Error("no workflow table")
+}
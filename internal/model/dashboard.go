@@ -0,0 +1,130 @@
+package model
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/dzoba/github-actions-watcher/internal/format"
+	"github.com/dzoba/github-actions-watcher/internal/stats"
+	"github.com/dzoba/github-actions-watcher/internal/ui"
+)
+
+// rateCellWidth fits "100/100/100% ≥1000".
+const rateCellWidth = 18
+
+// rateCell shows success, failure and cancel percentages and the run count,
+// as a lower bound for partial windows.
+func rateCell(r stats.Rates, partial bool) string {
+	if r.Runs == 0 {
+		return format.Pad("-", rateCellWidth)
+	}
+	runs := fmt.Sprint(r.Runs)
+	if partial {
+		runs = "≥" + runs
+	}
+	text := fmt.Sprintf("%.0f/%.0f/%.0f%% %s", r.SuccessRate()*100, r.FailureRate()*100, r.CancelRate()*100, runs)
+	style := ui.Green
+	switch {
+	case r.FailureRate() >= 0.25:
+		style = ui.Red
+	case r.Failure > 0:
+		style = ui.Yellow
+	}
+	return style.Render(format.Pad(text, rateCellWidth))
+}
+
+func streakCell(s stats.Streak) string {
+	switch {
+	case s.Runs == 0:
+		return ui.Dim.Render("-")
+	case s.Green:
+		return ui.Green.Render(fmt.Sprintf("✓ %d", s.Runs))
+	default:
+		return ui.Red.Render(fmt.Sprintf("✗ %d", s.Runs))
+	}
+}
+
+// statsRows draws the rates of each workflow and branch over the configured
+// windows, with recovery time and streaks for the default branch.
+func (m Model) statsRows() []string {
+	t := m.tabs[m.activeTab]
+	if len(t.statsRuns) == 0 {
+		return nil
+	}
+	d := stats.BuildDashboard(t.statsRuns, m.windows, t.defaultBranch, time.Now())
+
+	nameWidth := 8
+	for _, r := range d.Workflows {
+		nameWidth = max(nameWidth, len(r.Workflow))
+	}
+	for _, r := range d.Branches {
+		nameWidth = max(nameWidth, len(r.Branch))
+	}
+	nameWidth = min(nameWidth, 24)
+
+	header := func(title string) string {
+		var b strings.Builder
+		b.WriteString("  " + format.Pad(title, nameWidth) + " ")
+		for _, w := range m.windows {
+			b.WriteString(format.Pad(w.Label, rateCellWidth) + " ")
+		}
+		b.WriteString("STREAK")
+		return ui.Dim.Render(b.String())
+	}
+	line := func(name string, r stats.RateRow) string {
+		var b strings.Builder
+		b.WriteString("  " + name + " ")
+		for i, rates := range r.Windows {
+			b.WriteString(rateCell(rates, d.Partial[i]) + " ")
+		}
+		b.WriteString(streakCell(r.Streak))
+		return b.String()
+	}
+
+	var rows []string
+	if d.DefaultBranch != "" {
+		mttr := "no recoveries"
+		if d.Recoveries > 0 {
+			mttr = fmt.Sprintf("%s over %d recoveries", format.DurationOf(d.MTTR), d.Recoveries)
+		}
+		rows = append(rows, "  Mean time to recovery on "+ui.Magenta.Render(d.DefaultBranch)+": "+mttr, "")
+	}
+	rows = append(rows, header("WORKFLOW"))
+	for _, r := range d.Workflows {
+		rows = append(rows, line(ui.Blue.Render(format.Pad(format.Truncate(r.Workflow, nameWidth), nameWidth)), r))
+	}
+	rows = append(rows, "", header("BRANCH"))
+	for _, r := range d.Branches {
+		rows = append(rows, line(ui.Magenta.Render(format.Pad(format.Truncate(r.Branch, nameWidth), nameWidth)), r))
+	}
+	rows = append(rows, "", ui.Dim.Render("  success/failure/cancelled % and finished runs; workflow streaks are on the default branch"))
+	if !d.Since.IsZero() {
+		rows = append(rows, ui.Yellow.Render(fmt.Sprintf("  ≥: partial, only the latest %d runs were fetched, since %s", stats.MaxRuns, d.Since.Local().Format("Jan 2 15:04"))))
+	}
+	return rows
+}
+
+func (m Model) statsView() string {
+	t := m.tabs[m.activeTab]
+	title := ui.Bold.Render("CI health")
+	switch {
+	case t.statsLoading:
+		title += ui.Dim.Render(" (loading runs...)")
+	case t.statsError != "":
+		title += ui.Red.Render(" (" + t.statsError + ")")
+	}
+	lines := []string{title, ""}
+
+	rows := m.statsRows()
+	if len(rows) == 0 {
+		if t.statsLoading {
+			lines = append(lines, ui.Dim.Render("Loading runs..."))
+		} else {
+			lines = append(lines, ui.Dim.Render("No finished runs in this period."))
+		}
+	} else {
+		lines = append(lines, t.scroll.render(rows, m.scrollHeight()))
+	}
+	return strings.Join(lines, "\n")
+}
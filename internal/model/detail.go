@@ -3,23 +3,213 @@ package model
 import (
 	"fmt"
 	"strings"
+	"time"
 
+	"github.com/charmbracelet/x/ansi"
+
+	"github.com/dzoba/github-actions-watcher/internal/cost"
 	"github.com/dzoba/github-actions-watcher/internal/format"
+	"github.com/dzoba/github-actions-watcher/internal/matrix"
+	"github.com/dzoba/github-actions-watcher/internal/types"
 	"github.com/dzoba/github-actions-watcher/internal/ui"
 )
 
+// detailHeaderLines is the number of lines pinned above the scrolling
+// job/step rows: title, metadata and separator.
+const detailHeaderLines = 3
+
+// nodeKind identifies what a row in the detail view points at.
+type nodeKind int
+
+const (
+	nodeJob nodeKind = iota
+	nodeStep
+	nodeGroup // parent row of a matrix
+	nodeGrid  // one line of a matrix's outcome grid
+)
+
+// detailNode is one selectable row of the detail view. group indexes into
+// the run's matrix groups (-1 for jobs outside a matrix), job into
+// RunDetail.Jobs and step into that job's Steps. For grid rows, step is the
+// grid row, with -1 for the column header.
+type detailNode struct {
+	kind  nodeKind
+	group int
+	job   int
+	step  int
+}
+
+// jobGroups returns the matrix groups of the tab's run.
+func (t repoTab) jobGroups() []matrix.Group {
+	if t.detail == nil {
+		return nil
+	}
+	return matrix.GroupJobs(t.detail.Jobs)
+}
+
+// jobExpanded reports whether a job's steps are shown. Jobs the user has
+// toggled keep their state; otherwise failed and running jobs are expanded
+// and everything else is collapsed. Jobs without steps are never expanded.
+func (t repoTab) jobExpanded(job types.Job) bool {
+	if len(job.Steps) == 0 {
+		return false
+	}
+	if v, ok := t.expanded[job.DatabaseID]; ok {
+		return v
+	}
+	return autoExpand(job.Status, job.Conclusion)
+}
+
+// groupExpanded is jobExpanded for a matrix group, using its aggregate status.
+func (t repoTab) groupExpanded(g matrix.Group) bool {
+	if v, ok := t.expandedGroups[g.Name]; ok {
+		return v
+	}
+	return autoExpand(g.Status(t.detail.Jobs))
+}
+
+func autoExpand(status types.RunStatus, conclusion types.RunConclusion) bool {
+	switch status {
+	case types.StatusInProgress:
+		return true
+	case types.StatusCompleted:
+		return conclusion == types.ConclusionFailure || conclusion == types.ConclusionTimedOut
+	}
+	return false
+}
+
+// nodeExpanded reports whether the rows below n are shown. Steps count as
+// expanded so that toggling one collapses its job.
+func (t repoTab) nodeExpanded(n detailNode) bool {
+	switch n.kind {
+	case nodeJob:
+		return t.jobExpanded(t.detail.Jobs[n.job])
+	case nodeGroup, nodeGrid:
+		return t.groupExpanded(t.jobGroups()[n.group])
+	}
+	return true
+}
+
+// detailNodes flattens the jobs of the tab's run into selectable rows:
+// matrix groups with their grid and member jobs, and the steps of expanded
+// jobs.
+func (t repoTab) detailNodes() []detailNode {
+	var nodes []detailNode
+	addJob := func(gi, ji int) {
+		nodes = append(nodes, detailNode{kind: nodeJob, group: gi, job: ji})
+		job := t.detail.Jobs[ji]
+		if !t.jobExpanded(job) {
+			return
+		}
+		for si := range job.Steps {
+			nodes = append(nodes, detailNode{kind: nodeStep, group: gi, job: ji, step: si})
+		}
+	}
+	for gi, g := range t.jobGroups() {
+		if !g.IsMatrix() {
+			addJob(-1, g.Jobs[0])
+			continue
+		}
+		nodes = append(nodes, detailNode{kind: nodeGroup, group: gi, job: g.Jobs[0]})
+		if !t.groupExpanded(g) {
+			continue
+		}
+		grid := g.Grid()
+		if len(grid.Cols) > 1 || grid.Cols[0] != "" {
+			nodes = append(nodes, detailNode{kind: nodeGrid, group: gi, job: g.Jobs[0], step: -1})
+		}
+		for r := range grid.Rows {
+			nodes = append(nodes, detailNode{kind: nodeGrid, group: gi, job: g.Jobs[0], step: r})
+		}
+		for _, ji := range g.Jobs {
+			addJob(gi, ji)
+		}
+	}
+	return nodes
+}
+
+// selectedNode returns the node under the detail cursor.
+func (t repoTab) selectedNode() (detailNode, bool) {
+	nodes := t.detailNodes()
+	if len(nodes) == 0 {
+		return detailNode{}, false
+	}
+	return nodes[clampCursor(t.detailCursor, len(nodes))], true
+}
+
+// setExpanded expands or collapses the job or matrix group under the cursor.
+// Collapsing moves the cursor up to the row being collapsed so it doesn't
+// point at a hidden row; collapsing an already collapsed matrix job collapses
+// its group.
+func (t *repoTab) setExpanded(expand bool) {
+	n, ok := t.selectedNode()
+	if !ok {
+		return
+	}
+	job := t.detail.Jobs[n.job]
+	collapseGroup := !expand && n.kind == nodeJob && n.group >= 0 && !t.jobExpanded(job)
+	if n.kind == nodeGroup || n.kind == nodeGrid || collapseGroup {
+		if t.expandedGroups == nil {
+			t.expandedGroups = make(map[string]bool)
+		}
+		t.expandedGroups[t.jobGroups()[n.group].Name] = expand
+		if !expand {
+			t.moveCursorTo(detailNode{kind: nodeGroup, group: n.group, job: n.job})
+		}
+		return
+	}
+	if t.expanded == nil {
+		t.expanded = make(map[int]bool)
+	}
+	t.expanded[job.DatabaseID] = expand
+	if !expand && n.kind == nodeStep {
+		t.moveCursorTo(detailNode{kind: nodeJob, group: n.group, job: n.job})
+	}
+}
+
+// moveCursorTo puts the detail cursor on target if it is visible.
+func (t *repoTab) moveCursorTo(target detailNode) {
+	for i, node := range t.detailNodes() {
+		if node.kind == target.kind && node.group == target.group && (node.kind == nodeGroup || node.job == target.job) {
+			t.detailCursor = i
+			return
+		}
+	}
+}
+
+// stepURL links to a step's section of the job log on github.com.
+func stepURL(job types.Job, step types.Step) string {
+	if job.URL == "" {
+		return ""
+	}
+	return fmt.Sprintf("%s#step:%d:1", job.URL, step.Number)
+}
+
 func (m Model) detailView() string {
 	t := m.tabs[m.activeTab]
 	if t.detailLoading && t.detail == nil {
 		return ui.Dim.Render("Loading run details...")
 	}
-	if t.detailError != "" {
+	if t.detailError != "" && t.detail == nil {
 		return ui.Red.Render("Error: " + t.detailError)
 	}
 	if t.detail == nil {
 		return ui.Dim.Render("No detail available.")
 	}
 
+	lines := m.runHeader()
+	rows := m.detailRows()
+	h := m.detailHeight()
+	cursor := clampCursor(t.detailCursor, len(rows))
+	lines = append(lines, t.detailScroll.follow(cursor, len(rows), h).render(rows, h))
+
+	return strings.Join(lines, "\n")
+}
+
+// runHeader returns the detailHeaderLines lines pinned above the views of a
+// single run: status and title, metadata, and a separator.
+func (m Model) runHeader() []string {
+	t := m.tabs[m.activeTab]
 	d := t.detail
 	var lines []string
 
@@ -30,40 +220,165 @@ func (m Model) detailView() string {
 
 	// Metadata line
 	meta := fmt.Sprintf("%s #%d on %s (%s)", d.WorkflowName, d.Number, d.HeadBranch, d.Event)
+	if t.timing != nil && t.timingRunID == d.DatabaseID {
+		meta += " | billable " + cost.Describe(cost.RunUsage(t.timing, m.rates))
+	}
 	if t.detailLoading {
 		meta += " fetching..."
 	}
-	lines = append(lines, ui.Dim.Render(meta))
+	meta = ui.Dim.Render(meta)
+	if stale := m.staleness(t.detailAt, t.detailError != ""); stale != "" {
+		meta += " " + stale
+	}
+	if t.detailError != "" {
+		meta += " " + ui.Red.Render(t.detailError)
+	}
+	if m.width > 0 {
+		// One line, whatever the length of the error.
+		meta = ansi.Truncate(meta, m.width, "…")
+	}
+	lines = append(lines, meta)
 
 	// Separator
 	lines = append(lines, ui.Dim.Render("---"))
+	return lines
+}
 
-	// Jobs and steps
-	for _, job := range d.Jobs {
-		badgeText, badgeColor := format.StatusBadge(job.Status, job.Conclusion)
-		jobLine := ui.BadgeStyle(badgeColor).Render(badgeText) + " " + ui.Bold.Render(job.Name)
-		if job.StartedAt != "" {
-			jobLine += " " + ui.Dim.Render("("+format.Duration(job.StartedAt, job.CompletedAt)+")")
+// detailRows renders one line per detail node of the active tab's run,
+// before scrolling is applied.
+func (m Model) detailRows() []string {
+	t := m.tabs[m.activeTab]
+	nodes := t.detailNodes()
+	if len(nodes) == 0 {
+		return nil
+	}
+	cursor := clampCursor(t.detailCursor, len(nodes))
+	groups := t.jobGroups()
+	grids := make(map[int]matrix.Grid)
+	now := time.Now()
+
+	rows := make([]string, 0, len(nodes))
+	for i, n := range nodes {
+		var b strings.Builder
+		if i == cursor {
+			b.WriteString("> ")
+		} else {
+			b.WriteString("  ")
+		}
+		if n.group >= 0 && n.kind != nodeGroup {
+			b.WriteString("  ")
 		}
-		lines = append(lines, "")
-		lines = append(lines, jobLine)
 
-		for _, step := range job.Steps {
+		job := t.detail.Jobs[n.job]
+		switch n.kind {
+		case nodeGroup:
+			g := groups[n.group]
+			b.WriteString(expandMarker(t.groupExpanded(g)))
+			status, conclusion := g.Status(t.detail.Jobs)
+			badgeText, badgeColor := format.StatusBadge(status, conclusion)
+			b.WriteString(ui.BadgeStyle(badgeColor).Render(badgeText) + " " + ui.Bold.Render(g.Name))
+			b.WriteString(" " + ui.Dim.Render("("+groupSummary(g.Count(t.detail.Jobs), len(g.Jobs))+")"))
+		case nodeGrid:
+			grid, ok := grids[n.group]
+			if !ok {
+				grid = groups[n.group].Grid()
+				grids[n.group] = grid
+			}
+			b.WriteString(m.gridRow(groups[n.group], grid, n.step))
+		case nodeJob:
+			if len(job.Steps) == 0 {
+				b.WriteString("  ")
+			} else {
+				b.WriteString(expandMarker(t.jobExpanded(job)))
+			}
+			badgeText, badgeColor := format.StatusBadge(job.Status, job.Conclusion)
+			name := job.Name
+			if n.group >= 0 {
+				// The group row already carries the base name.
+				if _, values, ok := matrix.Parse(job.Name); ok {
+					name = strings.Join(values, ", ")
+				}
+			}
+			b.WriteString(ui.BadgeStyle(badgeColor).Render(badgeText) + " " + ui.Bold.Render(name))
+			if timing := m.jobTiming(job, now); timing != "" {
+				b.WriteString(" " + timing)
+			}
+			if fj, ok := t.flakyJob(job.Name); ok {
+				b.WriteString(" " + flakyMarker(fj))
+			}
+		case nodeStep:
+			step := job.Steps[n.step]
 			sbText, sbColor := format.StatusBadge(step.Status, step.Conclusion)
-			stepLine := "  " + ui.BadgeStyle(sbColor).Render(sbText) + " " + step.Name
+			b.WriteString("    " + ui.BadgeStyle(sbColor).Render(sbText) + " " + step.Name)
 			if step.StartedAt != "" {
-				stepLine += " " + ui.Dim.Render("("+format.Duration(step.StartedAt, step.CompletedAt)+")")
+				b.WriteString(" " + ui.Dim.Render("("+format.Duration(step.StartedAt, step.CompletedAt)+")"))
 			}
-			lines = append(lines, stepLine)
 		}
+		rows = append(rows, b.String())
+	}
+	return rows
+}
+
+func expandMarker(expanded bool) string {
+	if expanded {
+		return "▾ "
 	}
+	return "▸ "
+}
 
-	// Apply scroll offset
-	if t.detailScrollOffset > 0 && t.detailScrollOffset < len(lines) {
-		lines = lines[t.detailScrollOffset:]
-	} else if t.detailScrollOffset >= len(lines) {
-		lines = nil
+// groupSummary describes a matrix group's outcomes, e.g. "6 jobs: 5 passed, 1 failed".
+func groupSummary(c matrix.Counts, total int) string {
+	parts := []string{fmt.Sprintf("%d jobs", total)}
+	var counts []string
+	for _, p := range []struct {
+		n    int
+		what string
+	}{{c.Passed, "passed"}, {c.Failed, "failed"}, {c.Running, "running"}, {c.Queued, "queued"}, {c.Other, "other"}} {
+		if p.n > 0 {
+			counts = append(counts, fmt.Sprintf("%d %s", p.n, p.what))
+		}
 	}
+	if len(counts) > 0 {
+		parts = append(parts, strings.Join(counts, ", "))
+	}
+	return strings.Join(parts, ": ")
+}
 
-	return strings.Join(lines, "\n")
+// gridRow renders row r of a matrix group's outcome grid, or the column
+// header for r == -1. Each cell shows the symbol of its job's status badge.
+func (m Model) gridRow(g matrix.Group, grid matrix.Grid, r int) string {
+	t := m.tabs[m.activeTab]
+	labelWidth := 0
+	for _, row := range grid.Rows {
+		labelWidth = max(labelWidth, len(row))
+	}
+
+	var b strings.Builder
+	b.WriteString("  ")
+	if r < 0 {
+		b.WriteString(format.Pad("", labelWidth))
+		for _, col := range grid.Cols {
+			b.WriteString("  " + ui.Dim.Render(col))
+		}
+		return b.String()
+	}
+
+	b.WriteString(ui.Dim.Render(format.Pad(grid.Rows[r], labelWidth)))
+	for c, col := range grid.Cols {
+		width := max(len(col), 1)
+		b.WriteString("  ")
+		cell := grid.Cells[r][c]
+		if cell < 0 {
+			b.WriteString(ui.Dim.Render(format.Pad("·", width)))
+			continue
+		}
+		job := t.detail.Jobs[g.Jobs[cell]]
+		badgeText, badgeColor := format.StatusBadge(job.Status, job.Conclusion)
+		b.WriteString(ui.BadgeStyle(badgeColor).Render(format.Pad(badgeText[:1], width)))
+	}
+	return b.String()
+}
+
+func (m Model) detailRowCount() int {
+	return len(m.tabs[m.activeTab].detailNodes())
 }
@@ -0,0 +1,89 @@
+package model
+
+import (
+	"testing"
+
+	"github.com/dzoba/github-actions-watcher/internal/types"
+)
+
+func detailTab() repoTab {
+	steps := []types.Step{{Name: "checkout", Number: 1}, {Name: "test", Number: 2}}
+	return repoTab{detail: &types.RunDetail{Jobs: []types.Job{
+		{DatabaseID: 1, Name: "lint", Status: types.StatusCompleted, Conclusion: types.ConclusionSuccess, Steps: steps},
+		{DatabaseID: 2, Name: "test", Status: types.StatusCompleted, Conclusion: types.ConclusionFailure, Steps: steps},
+		{DatabaseID: 3, Name: "build", Status: types.StatusInProgress, Steps: steps},
+	}}}
+}
+
+func TestDetailNodesAutoExpand(t *testing.T) {
+	nodes := detailTab().detailNodes()
+	// lint collapsed, test and build expanded
+	if len(nodes) != 7 {
+		t.Fatalf("got %d nodes, want 7", len(nodes))
+	}
+	if nodes[1].kind != nodeJob || nodes[1].job != 1 {
+		t.Errorf("nodes[1] = %+v, want job 1", nodes[1])
+	}
+	if nodes[2].kind != nodeStep || nodes[2].job != 1 || nodes[2].step != 0 {
+		t.Errorf("nodes[2] = %+v, want first step of job 1", nodes[2])
+	}
+}
+
+func TestSetExpanded(t *testing.T) {
+	tab := detailTab()
+
+	tab.detailCursor = 0
+	tab.setExpanded(true)
+	if got := len(tab.detailNodes()); got != 9 {
+		t.Fatalf("after expanding lint: %d nodes, want 9", got)
+	}
+
+	// Collapsing from a step moves the cursor to its job.
+	tab.detailCursor = 5 // second step of "test"
+	tab.setExpanded(false)
+	if tab.detailCursor != 3 {
+		t.Errorf("cursor = %d, want 3", tab.detailCursor)
+	}
+	if got := len(tab.detailNodes()); got != 7 {
+		t.Errorf("after collapsing test: %d nodes, want 7", got)
+	}
+}
+
+func TestStepURL(t *testing.T) {
+	job := types.Job{URL: "https://github.com/o/r/actions/runs/1/job/2"}
+	got := stepURL(job, types.Step{Number: 3})
+	want := "https://github.com/o/r/actions/runs/1/job/2#step:3:1"
+	if got != want {
+		t.Errorf("stepURL() = %q, want %q", got, want)
+	}
+}
+
+func TestDetailNodesMatrix(t *testing.T) {
+	tab := repoTab{detail: &types.RunDetail{Jobs: []types.Job{
+		{DatabaseID: 1, Name: "test (ubuntu, 1.22)", Status: types.StatusCompleted, Conclusion: types.ConclusionSuccess},
+		{DatabaseID: 2, Name: "test (macos, 1.22)", Status: types.StatusCompleted, Conclusion: types.ConclusionFailure},
+		{DatabaseID: 3, Name: "deploy", Status: types.StatusCompleted, Conclusion: types.ConclusionSkipped},
+	}}}
+
+	// Failed group is expanded: group, grid header, 2 grid rows, 2 jobs, deploy.
+	nodes := tab.detailNodes()
+	kinds := []nodeKind{nodeGroup, nodeGrid, nodeGrid, nodeGrid, nodeJob, nodeJob, nodeJob}
+	if len(nodes) != len(kinds) {
+		t.Fatalf("got %d nodes, want %d", len(nodes), len(kinds))
+	}
+	for i, k := range kinds {
+		if nodes[i].kind != k {
+			t.Errorf("nodes[%d].kind = %d, want %d", i, nodes[i].kind, k)
+		}
+	}
+
+	// Collapsing from a member job collapses the group.
+	tab.detailCursor = 5
+	tab.setExpanded(false)
+	if got := len(tab.detailNodes()); got != 2 {
+		t.Errorf("after collapsing group: %d nodes, want 2", got)
+	}
+	if tab.detailCursor != 0 {
+		t.Errorf("cursor = %d, want 0", tab.detailCursor)
+	}
+}
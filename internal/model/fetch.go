@@ -0,0 +1,94 @@
+package model
+
+import (
+	"context"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// fetchKey identifies a fetch under way: the run list of a tab (run 0), the
+// detail of one of its runs, or with tab 0 a batch of every tab; or, by its
+// kind, what a tab loads for its other views. Tabs are identified by
+// repoTab.id, which unlike their index doesn't change when another tab is
+// closed.
+type fetchKey struct {
+	tab, run int
+	kind     fetchKind
+}
+
+// fetchKind tells apart what a tab fetches besides its runs.
+type fetchKind int
+
+const (
+	pollFetch     fetchKind = iota // the run list or a run's detail
+	historyFetch                   // older runs for trends
+	statsFetch                     // runs of the stats windows
+	flakyFetch                     // flaky jobs from the history
+	backfillFetch                  // flaky jobs, filling gaps in the history first
+	queueFetch                     // jobs with queue times
+	timingFetch                    // billable time of a run
+	costFetch                      // billable time of the stats windows
+	compareFetch                   // the runs of a comparison
+	graphFetch                     // the workflow graph of a run
+	branchFetch                    // the default branch
+)
+
+// inflight is a fetch under way.
+type inflight struct {
+	ctx    context.Context
+	cancel context.CancelFunc
+}
+
+// fetchDoneMsg carries the result of a fetch started by m.fetch.
+type fetchDoneMsg struct {
+	key fetchKey
+	ctx context.Context
+	msg tea.Msg
+}
+
+// fetch runs fn unless a fetch with the same key is still under way, as a
+// slow response would otherwise have polls stack up duplicate requests.
+func (m Model) fetch(key fetchKey, fn func(ctx context.Context) tea.Msg) tea.Cmd {
+	if _, ok := m.fetches[key]; ok {
+		return nil
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	m.fetches[key] = &inflight{ctx, cancel}
+	return func() tea.Msg {
+		return fetchDoneMsg{key: key, ctx: ctx, msg: fn(ctx)}
+	}
+}
+
+// fetchDone forgets a finished fetch and passes its result on, unless it was
+// cancelled because what it fetched for is gone.
+func (m Model) fetchDone(msg fetchDoneMsg) (tea.Model, tea.Cmd) {
+	cancelled := msg.ctx.Err() != nil
+	if f, ok := m.fetches[msg.key]; ok && f.ctx == msg.ctx {
+		f.cancel()
+		delete(m.fetches, msg.key)
+	}
+	if cancelled || msg.msg == nil {
+		return m, nil
+	}
+	return m.Update(msg.msg)
+}
+
+// cancelFetches cancels the fetches under way whose keys match.
+func (m Model) cancelFetches(match func(fetchKey) bool) {
+	for key, f := range m.fetches {
+		if match(key) {
+			f.cancel()
+			delete(m.fetches, key)
+		}
+	}
+}
+
+// indexOfTab returns the index of the tab with an id, or -1 if it was closed.
+func (m Model) indexOfTab(id int) int {
+	for i, t := range m.tabs {
+		if t.id == id {
+			return i
+		}
+	}
+	return -1
+}
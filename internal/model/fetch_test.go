@@ -0,0 +1,137 @@
+package model
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	"github.com/dzoba/github-actions-watcher/internal/types"
+)
+
+func TestFetchDedup(t *testing.T) {
+	m := New(Options{})
+	m.tabs = []repoTab{{id: 1, repo: "o/a"}, {id: 2, repo: "o/b"}}
+
+	calls := 0
+	fn := func(ctx context.Context) tea.Msg {
+		calls++
+		return runsMsg{tab: 2, runs: []types.WorkflowRun{{DatabaseID: 9}}, json: `[{"databaseId":9}]`}
+	}
+	first := m.fetch(fetchKey{tab: 2}, fn)
+	if first == nil {
+		t.Fatal("first fetch wasn't started")
+	}
+	if m.fetch(fetchKey{tab: 2}, fn) != nil {
+		t.Fatal("a second fetch of the same tab was started while the first is under way")
+	}
+
+	// Closing the first tab shifts the second one's index; its result still
+	// lands on it.
+	m = m.closeTab(0)
+	next, _ := m.Update(first())
+	m = next.(Model)
+	if calls != 1 || len(m.tabs[0].runs) != 1 {
+		t.Fatalf("calls = %d, runs = %+v", calls, m.tabs[0].runs)
+	}
+	if len(m.fetches) != 0 {
+		t.Errorf("finished fetch is still tracked: %v", m.fetches)
+	}
+	if m.fetch(fetchKey{tab: 2}, func(ctx context.Context) tea.Msg { return nil }) == nil {
+		t.Error("fetch wasn't started again once the last one finished")
+	}
+}
+
+func TestFetchCancel(t *testing.T) {
+	m := New(Options{})
+	m.tabs = []repoTab{{id: 1, repo: "o/a"}, {id: 2, repo: "o/b", view: types.ViewDetail, selectedRunID: 5}}
+	m.activeTab = 1
+
+	var list, detail error
+	listCmd := m.fetch(fetchKey{tab: 2}, func(ctx context.Context) tea.Msg {
+		<-ctx.Done()
+		list = ctx.Err()
+		return runsErrMsg{tab: 2, err: ctx.Err()}
+	})
+	detailCmd := m.fetch(fetchKey{tab: 2, run: 5}, func(ctx context.Context) tea.Msg {
+		<-ctx.Done()
+		detail = ctx.Err()
+		return detailErrMsg{tab: 2, runID: 5, err: ctx.Err()}
+	})
+
+	// Leaving the detail view cancels the detail fetch only.
+	next, _ := m.handleDetailKey(tea.KeyMsg{Type: tea.KeyEsc})
+	m = next.(Model)
+	next, _ = m.Update(detailCmd())
+	m = next.(Model)
+	if !errors.Is(detail, context.Canceled) || m.tabs[1].detailError != "" {
+		t.Errorf("detail fetch: err %v, shown error %q", detail, m.tabs[1].detailError)
+	}
+	if _, ok := m.fetches[fetchKey{tab: 2}]; !ok {
+		t.Fatal("run list fetch was cancelled with the detail")
+	}
+
+	// Closing the tab cancels the rest.
+	m = m.closeTab(1)
+	next, _ = m.Update(listCmd())
+	m = next.(Model)
+	if !errors.Is(list, context.Canceled) || m.tabs[0].runsError != "" {
+		t.Errorf("run list fetch: err %v, error %q shown on another tab", list, m.tabs[0].runsError)
+	}
+}
+
+func TestOpenRunDropsStaleDetail(t *testing.T) {
+	m := New(Options{})
+	m.tabs = []repoTab{{id: 1, repo: "o/a", view: types.ViewDetail, selectedRunID: 5}}
+
+	var old error
+	oldCmd := m.fetch(fetchKey{tab: 1, run: 5}, func(ctx context.Context) tea.Msg {
+		<-ctx.Done()
+		old = ctx.Err()
+		d := &types.RunDetail{WorkflowRun: types.WorkflowRun{DatabaseID: 5, Status: types.StatusCompleted}}
+		return detailMsg{tab: 1, runID: 5, detail: d, json: `{"databaseId":5}`}
+	})
+
+	// Opening another run cancels the fetch of the last one, and what it
+	// still delivers doesn't replace the run now open.
+	next, _ := m.openRun(types.WorkflowRun{DatabaseID: 6})
+	m = next.(Model)
+	next, cmd := m.Update(oldCmd())
+	m = next.(Model)
+	if !errors.Is(old, context.Canceled) {
+		t.Errorf("fetch of the last run: err %v, want cancelled", old)
+	}
+	if tab := m.tabs[0]; tab.detail != nil || !tab.detailLoading || tab.timingRunID != 0 || cmd != nil {
+		t.Errorf("late detail of run 5 landed on run 6: detail %+v, timing of %d", tab.detail, tab.timingRunID)
+	}
+	next, _ = m.Update(detailErrMsg{tab: 1, runID: 5, err: errors.New("HTTP 502")})
+	m = next.(Model)
+	if m.tabs[0].detailError != "" {
+		t.Errorf("error of run 5 shown on run 6: %q", m.tabs[0].detailError)
+	}
+}
+
+func TestViewFetchesByTab(t *testing.T) {
+	m := New(Options{})
+	m.tabs = []repoTab{{id: 1, repo: "o/a"}, {id: 2, repo: "o/b"}}
+
+	// What a tab loads for its views is tracked like its runs, and closing
+	// it cancels that too.
+	if m.loadStats("o/a", "main", 1) == nil || m.loadStats("o/a", "main", 1) != nil {
+		t.Fatal("stats of a tab were loaded twice at once, or not at all")
+	}
+	m = m.closeTab(0)
+	if len(m.fetches) != 0 {
+		t.Errorf("fetches of a closed tab are still under way: %v", m.fetches)
+	}
+
+	// Results land on their tab although its index changed.
+	next, _ := m.Update(statsMsg{tab: 2, runs: []types.WorkflowRun{{DatabaseID: 3}}})
+	m = next.(Model)
+	next, _ = m.Update(statsMsg{tab: 1, runs: []types.WorkflowRun{{DatabaseID: 4}}})
+	m = next.(Model)
+	if runs := m.tabs[0].statsRuns; len(runs) != 1 || runs[0].DatabaseID != 3 {
+		t.Errorf("stats runs = %+v, want run 3", runs)
+	}
+}
@@ -0,0 +1,89 @@
+package model
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/dzoba/github-actions-watcher/internal/format"
+	"github.com/dzoba/github-actions-watcher/internal/stats"
+	"github.com/dzoba/github-actions-watcher/internal/ui"
+)
+
+// flakesShown is how many flaky failures are listed under each job.
+const flakesShown = 5
+
+// flakyJob returns the flakiness of a job of the tab's current run, if it has
+// flaked before.
+func (t repoTab) flakyJob(name string) (stats.FlakyJob, bool) {
+	if t.detail == nil {
+		return stats.FlakyJob{}, false
+	}
+	key := stats.Key(t.detail.WorkflowName, name)
+	for _, fj := range t.flaky {
+		if stats.Key(fj.Workflow, fj.Job) == key {
+			return fj, true
+		}
+	}
+	return stats.FlakyJob{}, false
+}
+
+// flakyMarker tags a job in the detail view that has flaked before.
+func flakyMarker(fj stats.FlakyJob) string {
+	return ui.Yellow.Render(fmt.Sprintf("~ flaky %.0f%%", fj.Score*100))
+}
+
+// flakyRows ranks the tab's flaky jobs, each followed by links to its most
+// recent flaky failures.
+func (m Model) flakyRows() []string {
+	t := m.tabs[m.activeTab]
+	if len(t.flaky) == 0 {
+		return nil
+	}
+	rows := []string{ui.Dim.Render("  SCORE  FLAKES  JOB")}
+	for _, fj := range t.flaky {
+		rows = append(rows, fmt.Sprintf("  %s  %s  %s",
+			ui.Yellow.Render(fmt.Sprintf("%4.0f%%", fj.Score*100)),
+			format.Pad(fmt.Sprintf("%d/%d", len(fj.Flakes), fj.Runs), 6),
+			ui.Blue.Render(fj.Workflow)+" / "+ui.Bold.Render(fj.Job),
+		))
+		shown := fj.Flakes
+		if len(shown) > flakesShown {
+			shown = shown[len(shown)-flakesShown:]
+		}
+		for i := len(shown) - 1; i >= 0; i-- {
+			f := shown[i]
+			rows = append(rows, ui.Dim.Render(fmt.Sprintf("                 #%d attempt %d  %s  %s",
+				f.Number, f.Attempt, format.Pad(f.Reason, 15), f.URL)))
+		}
+		if more := len(fj.Flakes) - len(shown); more > 0 {
+			rows = append(rows, ui.Dim.Render(fmt.Sprintf("                 ... %d earlier", more)))
+		}
+	}
+	return rows
+}
+
+func (m Model) flakyView() string {
+	t := m.tabs[m.activeTab]
+	title := ui.Bold.Render("Flaky jobs")
+	switch {
+	case t.flakyLoading:
+		title += ui.Dim.Render(" (loading history...)")
+	case t.flakyError != "":
+		title += ui.Red.Render(" (" + t.flakyError + ")")
+	case t.defaultBranch != "":
+		title += ui.Dim.Render(" (reruns, and flip-flops on " + t.defaultBranch + ")")
+	}
+	lines := []string{title, ""}
+
+	rows := m.flakyRows()
+	if len(rows) == 0 {
+		if t.flakyLoading {
+			lines = append(lines, ui.Dim.Render("Fetching jobs of recent runs..."))
+		} else {
+			lines = append(lines, ui.Dim.Render("No flaky jobs found in the run history."))
+		}
+	} else {
+		lines = append(lines, t.scroll.render(rows, m.scrollHeight()))
+	}
+	return strings.Join(lines, "\n")
+}
@@ -0,0 +1,39 @@
+package model
+
+import (
+	"testing"
+
+	"github.com/dzoba/github-actions-watcher/internal/stats"
+	"github.com/dzoba/github-actions-watcher/internal/types"
+)
+
+func TestFlakyJob(t *testing.T) {
+	tab := detailTab()
+	tab.detail.WorkflowName = "CI"
+	tab.flaky = []stats.FlakyJob{
+		{Workflow: "Deploy", Job: "test", Score: 0.5},
+		{Workflow: "CI", Job: "test", Score: 0.25},
+	}
+	fj, ok := tab.flakyJob("test")
+	if !ok || fj.Workflow != "CI" || fj.Score != 0.25 {
+		t.Errorf("flakyJob(test) = %+v, %v; want the CI job", fj, ok)
+	}
+	if _, ok := tab.flakyJob("lint"); ok {
+		t.Error("flakyJob(lint) found a job that never flaked")
+	}
+}
+
+func TestFlakyRows(t *testing.T) {
+	m := New(Options{})
+	var flakes []stats.Flake
+	for i := 1; i <= 7; i++ {
+		flakes = append(flakes, stats.Flake{RunID: i, Number: i, Attempt: 1, Reason: stats.ReasonRerun})
+	}
+	m.tabs = []repoTab{{view: types.ViewFlaky, flaky: []stats.FlakyJob{
+		{Workflow: "CI", Job: "test", Score: 0.5, Runs: 14, Flakes: flakes},
+	}}}
+	// header, the job, the latest flakesShown flakes and a "more" line
+	if got, want := len(m.flakyRows()), 2+flakesShown+1; got != want {
+		t.Errorf("got %d rows, want %d", got, want)
+	}
+}
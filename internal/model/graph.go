@@ -0,0 +1,77 @@
+package model
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/charmbracelet/x/ansi"
+
+	"github.com/dzoba/github-actions-watcher/internal/dag"
+	"github.com/dzoba/github-actions-watcher/internal/format"
+	"github.com/dzoba/github-actions-watcher/internal/matrix"
+	"github.com/dzoba/github-actions-watcher/internal/types"
+	"github.com/dzoba/github-actions-watcher/internal/ui"
+)
+
+// graphLabel draws a workflow job with the live status of the run jobs
+// created from it. Matrix jobs show how many of their cells passed.
+func graphLabel(job dag.Job, jobs []types.Job) dag.Label {
+	g := matrix.Group{}
+	for i, j := range jobs {
+		if job.Matches(j.Name) {
+			g.Jobs = append(g.Jobs, i)
+		}
+	}
+	name := format.Truncate(job.DisplayName(), 24)
+	if len(g.Jobs) == 0 {
+		// Not created yet, or never will be (e.g. an if: that didn't match).
+		text := "· " + name
+		return dag.Label{Text: text, Style: func(s string) string { return ui.Dim.Render(s) }}
+	}
+
+	badgeText, badgeColor := format.StatusBadge(g.Status(jobs))
+	text := badgeText[:1] + " " + name
+	if len(g.Jobs) > 1 {
+		text += fmt.Sprintf(" %d/%d", g.Count(jobs).Passed, len(g.Jobs))
+	}
+	style := ui.BadgeStyle(badgeColor)
+	return dag.Label{Text: text, Style: func(s string) string { return style.Render(s) }}
+}
+
+// graphRows renders the job graph of the active tab's run.
+func (m Model) graphRows() []string {
+	t := m.tabs[m.activeTab]
+	if t.graph == nil || t.detail == nil {
+		return nil
+	}
+	rows := t.graph.Render(func(i int) dag.Label {
+		return graphLabel(t.graph.Jobs[i], t.detail.Jobs)
+	})
+	if m.width > 0 {
+		for i, row := range rows {
+			rows[i] = ansi.Truncate(row, m.width, "…")
+		}
+	}
+	return rows
+}
+
+func (m Model) graphView() string {
+	t := m.tabs[m.activeTab]
+	if t.detail == nil {
+		return ui.Dim.Render("Loading run details...")
+	}
+
+	lines := m.runHeader()
+	switch {
+	case t.graphError != "":
+		lines = append(lines, ui.Red.Render("Error: "+t.graphError))
+	case t.graphLoading || t.graph == nil:
+		lines = append(lines, ui.Dim.Render("Loading workflow file..."))
+	case len(t.graph.Jobs) == 0:
+		lines = append(lines, ui.Dim.Render("No jobs in workflow file."))
+	default:
+		rows := m.graphRows()
+		lines = append(lines, t.scroll.render(rows, m.scrollHeight()))
+	}
+	return strings.Join(lines, "\n")
+}
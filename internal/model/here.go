@@ -0,0 +1,173 @@
+package model
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	"github.com/dzoba/github-actions-watcher/internal/format"
+	"github.com/dzoba/github-actions-watcher/internal/gh"
+	"github.com/dzoba/github-actions-watcher/internal/types"
+	"github.com/dzoba/github-actions-watcher/internal/ui"
+)
+
+// In here mode, the tab of the repo checked out in the current directory
+// lists only the runs of the local branch and commit, those of HEAD first,
+// under the local commits that have no runs yet.
+
+// maxPending caps the commits without runs listed above the runs.
+const maxPending = 5
+
+type headMsg struct {
+	head *gh.Head
+	err  error
+}
+
+// readHead reads the local state in here mode.
+func (m Model) readHead() tea.Cmd {
+	if !m.here {
+		return nil
+	}
+	return func() tea.Msg {
+		head, err := gh.LocalHead()
+		return headMsg{head, err}
+	}
+}
+
+// localTab returns the index of the tab of the checked-out repo, or -1.
+func (m Model) localTab() int {
+	if m.localRepo == "" {
+		return -1
+	}
+	for i, t := range m.tabs {
+		if strings.EqualFold(t.repo, m.localRepo) {
+			return i
+		}
+	}
+	return -1
+}
+
+// setHead shows the local state on the tab of the checked-out repo, or
+// stops doing so if head is nil.
+func (m *Model) setHead(head *gh.Head) {
+	i := m.localTab()
+	if i < 0 {
+		return
+	}
+	t := &m.tabs[i]
+	pinned := t.head != nil && head != nil && t.head.SHA == head.SHA
+	t.head = head
+	if !pinned {
+		// The runs of the new HEAD come first.
+		t.selectedIndex = 0
+		t.listScroll = viewport{}
+	}
+	t.selectedIndex = clampCursor(t.selectedIndex, len(t.listRuns()))
+}
+
+// listRuns returns the runs the list view shows, in order.
+func (t repoTab) listRuns() []types.WorkflowRun {
+	if t.head == nil {
+		return t.runs
+	}
+	here := func(run types.WorkflowRun) bool {
+		return run.HeadSha == t.head.SHA
+	}
+	// The latest fetch covers a few runs of every branch; the history
+	// holds earlier ones of the local branch.
+	seen := make(map[int]bool)
+	var runs []types.WorkflowRun
+	for _, list := range [][]types.WorkflowRun{t.runs, t.history} {
+		for _, run := range list {
+			if seen[run.DatabaseID] || !here(run) && (t.head.Branch == "" || run.HeadBranch != t.head.Branch) {
+				continue
+			}
+			seen[run.DatabaseID] = true
+			runs = append(runs, run)
+		}
+	}
+	sort.SliceStable(runs, func(i, j int) bool {
+		if here(runs[i]) != here(runs[j]) {
+			return here(runs[i])
+		}
+		return runs[i].CreatedAt > runs[j].CreatedAt
+	})
+	return runs
+}
+
+// pendingCommits returns the local commits that have no runs: those not
+// pushed yet and, once pushed, HEAD until its runs show up.
+func (t repoTab) pendingCommits() []gh.Commit {
+	if t.head == nil {
+		return nil
+	}
+	hasRuns := make(map[string]bool)
+	for _, list := range [][]types.WorkflowRun{t.runs, t.history} {
+		for _, run := range list {
+			hasRuns[run.HeadSha] = true
+		}
+	}
+	commits := t.head.Ahead
+	if len(commits) == 0 {
+		commits = []gh.Commit{t.head.Commit}
+	}
+	var pending []gh.Commit
+	for _, c := range commits {
+		if !hasRuns[c.SHA] {
+			pending = append(pending, c)
+		}
+	}
+	return pending
+}
+
+// pendingView renders the local commits without runs, one line each, and
+// the number of lines.
+func (m Model) pendingView() (string, int) {
+	t := m.tabs[m.activeTab]
+	pending := t.pendingCommits()
+	if len(pending) == 0 {
+		return "", 0
+	}
+	ahead := make(map[string]bool)
+	for _, c := range t.head.Ahead {
+		ahead[c.SHA] = true
+	}
+	cols := m.width
+	if cols == 0 {
+		cols = 120
+	}
+	var lines []string
+	for _, c := range pending[:min(len(pending), maxPending)] {
+		state := "no runs yet"
+		if ahead[c.SHA] {
+			state = "not pushed"
+		}
+		subject := format.Truncate(c.Subject, max(cols-(2+14+8), 15))
+		lines = append(lines, "  "+ui.Dim.Render(format.Pad(state, 13))+" "+ui.Yellow.Render(shortSha(c.SHA))+" "+subject)
+	}
+	if more := len(pending) - maxPending; more > 0 {
+		lines = append(lines, ui.Dim.Render(fmt.Sprintf("                ... %d earlier", more)))
+	}
+	return strings.Join(lines, "\n"), len(lines)
+}
+
+// hereStatus describes here mode for the header of the local repo's tab.
+func (m Model) hereStatus() string {
+	if !m.here || m.localTab() != m.activeTab {
+		return ""
+	}
+	if m.headError != "" {
+		return ui.Red.Render("[here: " + m.headError + "]")
+	}
+	t := m.tabs[m.activeTab]
+	if t.head == nil {
+		return ui.Dim.Render("[here]")
+	}
+	at := shortSha(t.head.SHA)
+	if t.head.Branch != "" {
+		at = t.head.Branch + " @ " + at
+	}
+	return ui.Magenta.Render("[here: " + at + "]")
+}
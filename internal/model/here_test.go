@@ -0,0 +1,82 @@
+package model
+
+import (
+	"slices"
+	"strings"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	"github.com/dzoba/github-actions-watcher/internal/gh"
+	"github.com/dzoba/github-actions-watcher/internal/types"
+)
+
+func TestHere(t *testing.T) {
+	run := func(id int, branch, sha, created string) types.WorkflowRun {
+		return types.WorkflowRun{DatabaseID: id, HeadBranch: branch, HeadSha: sha, CreatedAt: created, DisplayTitle: "run " + sha}
+	}
+	m := New(Options{Here: true})
+	m.repoLoading = false
+	m.localRepo = "o/r"
+	m.tabs = []repoTab{{id: 1, repo: "O/R", view: types.ViewList,
+		runs: []types.WorkflowRun{
+			run(5, "other", "ccc", "2024-01-05T00:00:00Z"),
+			run(4, "feature", "aaa", "2024-01-04T00:00:00Z"),
+			run(3, "feature", "bbb", "2024-01-03T00:00:00Z"),
+		},
+		history: []types.WorkflowRun{
+			run(4, "feature", "aaa", "2024-01-04T00:00:00Z"),
+			run(2, "feature", "bbb", "2024-01-02T00:00:00Z"),
+			run(1, "main", "bbb", "2024-01-01T00:00:00Z"),
+		},
+	}}
+	next, _ := m.Update(headMsg{head: &gh.Head{Commit: gh.Commit{SHA: "bbb", Subject: "pushed"}, Branch: "feature"}})
+	m = next.(Model)
+
+	// Runs of HEAD first, then the rest of the branch, newest first.
+	var ids []int
+	for _, r := range m.tabs[0].listRuns() {
+		ids = append(ids, r.DatabaseID)
+	}
+	if !slices.Equal(ids, []int{3, 2, 1, 4}) {
+		t.Errorf("runs = %v, want [3 2 1 4]", ids)
+	}
+	if len(m.tabs[0].pendingCommits()) != 0 {
+		t.Errorf("HEAD has runs but is pending: %v", m.tabs[0].pendingCommits())
+	}
+
+	// Unpushed commits are listed above the runs.
+	ahead := []gh.Commit{{SHA: "eee", Subject: "wip"}, {SHA: "ddd", Subject: "fix"}}
+	next, _ = m.Update(headMsg{head: &gh.Head{Commit: ahead[0], Branch: "feature", Ahead: ahead}})
+	m = next.(Model)
+	view := m.View()
+	for _, want := range []string{"[here: feature @ eee]", "not pushed", "wip", "fix"} {
+		if !strings.Contains(view, want) {
+			t.Errorf("view lacks %q:\n%s", want, view)
+		}
+	}
+	if strings.Contains(view, "run ccc") {
+		t.Errorf("view shows a run of another branch:\n%s", view)
+	}
+
+	// Toggling here mode off shows every run again.
+	next, _ = m.handleListKey(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("h")})
+	m = next.(Model)
+	if len(m.tabs[0].listRuns()) != 3 || strings.Contains(m.View(), "not pushed") {
+		t.Errorf("here mode still on: %v", m.tabs[0].listRuns())
+	}
+}
+
+func TestHereOverviewEnter(t *testing.T) {
+	m := overviewModel()
+	// In here mode o/web lists run 2 of HEAD before run 3.
+	m.tabs[1].head = &gh.Head{Commit: gh.Commit{SHA: "bbb"}, Branch: "main"}
+	m.tabs[1].runs[1].HeadSha = "bbb"
+	next, _ := m.handleOverviewKey(tea.KeyMsg{Type: tea.KeyDown})
+	next, _ = next.(Model).handleOverviewKey(tea.KeyMsg{Type: tea.KeyEnter})
+	m = next.(Model)
+	tab := m.tabs[1]
+	if tab.selectedRunID != 3 || tab.listRuns()[tab.selectedIndex].DatabaseID != 3 {
+		t.Errorf("opened run %d with the cursor on %+v", tab.selectedRunID, tab.listRuns()[tab.selectedIndex])
+	}
+}
@@ -2,6 +2,7 @@ package model
 
 import (
 	"strings"
+	"time"
 
 	"github.com/dzoba/github-actions-watcher/internal/format"
 	"github.com/dzoba/github-actions-watcher/internal/types"
@@ -10,7 +11,11 @@ import (
 
 func (m Model) listView() string {
 	t := m.tabs[m.activeTab]
-	if len(t.runs) == 0 {
+	runs := t.listRuns()
+	if len(runs) == 0 {
+		if t.head != nil {
+			return ui.Dim.Render("No workflow runs of this branch found.")
+		}
 		return ui.Dim.Render("No workflow runs found.")
 	}
 
@@ -19,6 +24,7 @@ func (m Model) listView() string {
 		cols = 120
 	}
 
+	showTrend := cols >= 100
 	showTime := cols >= 70
 	showBranch := cols >= 55
 	showWorkflow := cols >= 40
@@ -33,21 +39,29 @@ func (m Model) listView() string {
 	if showTime {
 		titleMax -= 9
 	}
+	if showTrend {
+		titleMax -= 13
+	}
 	if titleMax < 15 {
 		titleMax = 15
 	}
 
-	var b strings.Builder
-	for i, run := range t.runs {
-		if i > 0 {
-			b.WriteByte('\n')
-		}
+	durations := t.durationsByWorkflow()
+	now := time.Now()
+	rows := make([]string, 0, len(runs))
+	for i, run := range runs {
+		var b strings.Builder
 
-		// Selector
+		// Selector and comparison mark
 		if i == t.selectedIndex {
-			b.WriteString("> ")
+			b.WriteByte('>')
+		} else {
+			b.WriteByte(' ')
+		}
+		if t.isMarked(run.DatabaseID) {
+			b.WriteString(ui.Yellow.Render("*"))
 		} else {
-			b.WriteString("  ")
+			b.WriteByte(' ')
 		}
 
 		// Status badge (pad plain text to 14, then style)
@@ -68,7 +82,12 @@ func (m Model) listView() string {
 		}
 
 		// Title (plain text)
-		b.WriteString(format.Pad(format.Truncate(run.DisplayTitle, titleMax), titleMax))
+		title := format.Pad(format.Truncate(run.DisplayTitle, titleMax), titleMax)
+		if t.head != nil && run.HeadSha == t.head.SHA {
+			// A run of the local HEAD
+			title = ui.Bold.Render(title)
+		}
+		b.WriteString(title)
 
 		// Time column: elapsed for in-progress, relative for others
 		if showTime {
@@ -79,6 +98,15 @@ func (m Model) listView() string {
 				b.WriteString(ui.Dim.Render(format.Pad(format.RelativeTime(run.CreatedAt), 8)))
 			}
 		}
+
+		// Trend: how far an in-progress run is past its workflow's median
+		if showTrend {
+			b.WriteByte(' ')
+			b.WriteString(ui.Yellow.Render(format.Pad(trend(run, durations[run.WorkflowName], now), 12)))
+		}
+		rows = append(rows, b.String())
 	}
-	return b.String()
+
+	h := m.listHeight()
+	return t.listScroll.follow(t.selectedIndex, len(rows), h).render(rows, h)
 }
@@ -1,84 +1,276 @@
 package model
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"os/exec"
 	"runtime"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/charmbracelet/bubbles/key"
 	"github.com/charmbracelet/bubbles/textinput"
 	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
 
+	"github.com/dzoba/github-actions-watcher/internal/cost"
+	"github.com/dzoba/github-actions-watcher/internal/dag"
+	"github.com/dzoba/github-actions-watcher/internal/format"
 	"github.com/dzoba/github-actions-watcher/internal/gh"
+	"github.com/dzoba/github-actions-watcher/internal/stats"
+	"github.com/dzoba/github-actions-watcher/internal/store"
 	"github.com/dzoba/github-actions-watcher/internal/types"
 	"github.com/dzoba/github-actions-watcher/internal/ui"
 )
 
 // repoTab holds all per-repo state.
 type repoTab struct {
-	repo               string
-	runs               []types.WorkflowRun
-	runsJSON           string
-	runsLoading        bool
-	runsError          string
-	selectedIndex      int
-	selectedRunID      int
-	detail             *types.RunDetail
-	detailJSON         string
-	detailLoading      bool
-	detailError        string
-	detailScrollOffset int
-	view               types.View // ViewList or ViewDetail (per-tab)
+	id             int // stable while the tab is open; see fetchKey
+	repo           string
+	runs           []types.WorkflowRun
+	runsJSON       string
+	runsLoading    bool
+	runsAt         time.Time // when the runs shown were fetched
+	head           *gh.Head  // the local checkout, in here mode
+	runsError      string
+	fetchedAt      time.Time // last run list fetch, successful or not
+	failures       int       // run list fetches failed in a row
+	selectedIndex  int
+	selectedRunID  int
+	detail         *types.RunDetail
+	detailJSON     string
+	detailLoading  bool
+	detailError    string
+	detailAt       time.Time // when the detail shown was fetched
+	detailCursor   int
+	expanded       map[int]bool        // job DatabaseID -> steps shown, set by the user
+	expandedGroups map[string]bool     // matrix group name -> jobs shown, set by the user
+	history        []types.WorkflowRun // older runs, for trends
+	historyLoading bool
+	historyError   string
+	flaky          []stats.FlakyJob
+	flakyLoading   bool
+	flakyError     string
+	defaultBranch  string
+	statsRuns      []types.WorkflowRun // runs within the widest stats window
+	statsLoading   bool
+	statsError     string
+	queueDetails   []types.RunDetail // fresh details first, then history
+	queueLoading   bool
+	queueError     string
+	marked         []types.WorkflowRun  // runs marked for comparison, at most two
+	compareRuns    [2]types.WorkflowRun // older and newer run being compared
+	compareOld     *types.RunDetail
+	compareNew     *types.RunDetail
+	compareRange   *types.Comparison
+	compareLoading bool
+	compareError   string
+	timing         *types.RunTiming // billable time of the run in detail
+	timingRunID    int
+	costRuns       []types.WorkflowRun
+	costTimings    map[int]*types.RunTiming
+	costMissing    int // finished runs whose timing wasn't fetched yet
+	costLoading    bool
+	costError      string
+	graph          *dag.Workflow
+	graphRunID     int
+	graphLoading   bool
+	graphError     string
+	listScroll     viewport
+	detailScroll   viewport
+	scroll         viewport
+	view           types.View // per-tab; the picker and welcome screens are global
+}
+
+// showsRun reports whether the tab is on one of the views of a single run,
+// which need its detail kept fresh.
+func (t repoTab) showsRun() bool {
+	return t.view == types.ViewDetail || t.view == types.ViewGraph || t.view == types.ViewTimeline
 }
 
 // Messages
 type repoDetectedMsg struct{ repo string }
 type repoErrorMsg struct{ err error }
+
+// The messages of fetches name their tab by id, as they may arrive after
+// other tabs were closed.
 type runsMsg struct {
-	tabIndex int
-	runs     []types.WorkflowRun
-	json     string
+	tab    int
+	runs   []types.WorkflowRun
+	json   string
+	cached bool      // from the backend's cache, not fetched
+	asOf   time.Time // when recorded, for runs from the history; else now
 }
 type runsErrMsg struct {
-	tabIndex int
-	err      error
+	tab int
+	err error
 }
 type detailMsg struct {
-	tabIndex int
-	detail   *types.RunDetail
-	json     string
+	tab    int
+	runID  int
+	detail *types.RunDetail
+	json   string
+	asOf   time.Time // as for runsMsg
 }
 type detailErrMsg struct {
-	tabIndex int
+	tab   int
+	runID int
+	err   error
+}
+
+type graphMsg struct {
+	tab   int
+	runID int
+	graph *dag.Workflow
+}
+type graphErrMsg struct {
+	tab   int
+	runID int
+	err   error
+}
+type historyMsg struct {
+	tab  int
+	runs []types.WorkflowRun
+	err  error
+}
+type flakyMsg struct {
+	tab           int
+	flaky         []stats.FlakyJob
+	defaultBranch string
+	err           error
+}
+type statsMsg struct {
+	tab           int
+	runs          []types.WorkflowRun
+	defaultBranch string
+	err           error
+}
+type queueMsg struct {
+	tab     int
+	details []types.RunDetail
+	err     error
+}
+type compareMsg struct {
+	tab      int
+	ids      [2]int
+	old, new *types.RunDetail
+	commits  *types.Comparison // nil if the range couldn't be fetched
 	err      error
 }
+type timingMsg struct {
+	tab    int
+	runID  int
+	timing *types.RunTiming
+}
+type costMsg struct {
+	tab     int
+	runs    []types.WorkflowRun
+	timings map[int]*types.RunTiming
+	missing int
+	err     error
+}
+type defaultBranchMsg struct {
+	tab    int
+	branch string
+}
+type jobLogMsg struct {
+	url string
+	err error
+}
 type pollTickMsg struct{}
 type countdownTickMsg struct{}
 type repoListMsg struct{ repos []types.PickerRepo }
 type repoListErrMsg struct{ err error }
 
+// Options configures a Model.
+type Options struct {
+	// Interval is the time between polls.
+	Interval time.Duration
+	// Backend polls run lists and details. Nil means gh.CLI.
+	Backend gh.Backend
+	// Store, if set, records every run and run detail fetched.
+	Store *store.Store
+	// Windows are the periods the stats view covers. Empty means
+	// stats.DefaultWindows.
+	Windows []stats.Window
+	// Rates price billable minutes. Nil means cost.DefaultRates.
+	Rates cost.Rates
+	// QueueThreshold is how long a job may wait for a runner before it is
+	// flagged. Zero means DefaultQueueThreshold.
+	QueueThreshold time.Duration
+	// Org, if set, watches the repos of an org, or of a team as
+	// "org/team-slug", instead of the current repo.
+	Org string
+	// OrgActive limits Org to repos pushed to within it. Zero means
+	// DefaultOrgActive.
+	OrgActive time.Duration
+	// Offline shows the runs and details recorded in Store instead of
+	// fetching them.
+	Offline bool
+	// Here starts in here mode, showing the runs of the local branch.
+	Here bool
+}
+
+// DefaultOrgActive is the default Options.OrgActive.
+const DefaultOrgActive = 7 * 24 * time.Hour
+
+// DefaultQueueThreshold is the default Options.QueueThreshold.
+const DefaultQueueThreshold = 5 * time.Minute
+
 // Model is the root Bubbletea model.
 type Model struct {
 	// Config
 	interval time.Duration
-
-	// Tabs
+	backend  gh.Backend
+	store    *store.Store
+	windows  []stats.Window
+	queueMax time.Duration
+	rates    cost.Rates
+	org      string
+	orgSince time.Duration
+	offline  bool
+
+	// Here mode: the repo checked out in the current directory and why
+	// its local state can't be read, if it can't.
+	here      bool
+	localRepo string
+	headError string
+
+	// Polling: the current interval and why it isn't the configured one,
+	// the latest known API rate limit, the time of the last key press and
+	// since when fetches fail to reach GitHub.
+	pollEvery        time.Duration
+	pollWhy          string
+	rateLimit        *gh.RateLimit
+	lastInput        time.Time
+	unreachableSince time.Time
+
+	// Tabs, and the fetches of them under way
 	tabs      []repoTab
 	activeTab int
+	nextTabID int
+	fetches   map[fetchKey]*inflight
 
 	// Root-level state
 	repoLoading bool
 	repoError   string
 	countdown   int
 
+	// Overview state
+	showOverview      bool
+	overviewByStatus  bool
+	overviewAttention bool // only runs in progress, queued or failed
+	overviewSelected  int
+	overviewScroll    viewport
+
 	// Picker state
 	showPicker     bool
 	pickerRepos    []types.PickerRepo
 	pickerLoading  bool
 	pickerSelected int
+	pickerScroll   viewport
 	pickerFilter   textinput.Model
 
 	// Terminal size
@@ -87,20 +279,58 @@ type Model struct {
 }
 
 // New creates a new Model.
-func New(interval time.Duration) Model {
+func New(opts Options) Model {
 	ti := textinput.New()
 	ti.Placeholder = "filter or owner/repo"
 	ti.CharLimit = 100
 
+	windows := opts.Windows
+	if len(windows) == 0 {
+		windows = stats.DefaultWindows
+	}
+	rates := opts.Rates
+	if rates == nil {
+		rates = cost.DefaultRates
+	}
+	queueMax := opts.QueueThreshold
+	if queueMax <= 0 {
+		queueMax = DefaultQueueThreshold
+	}
+	backend := opts.Backend
+	if backend == nil {
+		backend = gh.CLI{}
+	}
+	orgSince := opts.OrgActive
+	if orgSince <= 0 {
+		orgSince = DefaultOrgActive
+	}
 	return Model{
-		interval:    interval,
-		repoLoading: true,
-		countdown:   int(interval.Seconds()),
+		interval:     opts.Interval,
+		backend:      backend,
+		org:          opts.Org,
+		orgSince:     orgSince,
+		offline:      opts.Offline,
+		here:         opts.Here,
+		store:        opts.Store,
+		windows:      windows,
+		queueMax:     queueMax,
+		rates:        rates,
+		repoLoading:  true,
+		countdown:    int(opts.Interval.Seconds()),
+		pollEvery:    opts.Interval,
+		fetches:      make(map[fetchKey]*inflight),
+		lastInput:    time.Now(),
 		pickerFilter: ti,
 	}
 }
 
 func (m Model) Init() tea.Cmd {
+	if m.org != "" && m.offline {
+		return m.snapshotOrgRepos()
+	}
+	if m.org != "" {
+		return fetchOrgRepos(m.org, m.orgSince)
+	}
 	return detectRepo
 }
 
@@ -114,14 +344,46 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 
 	case repoDetectedMsg:
 		m.repoLoading = false
+		m.nextTabID++
 		tab := repoTab{
-			repo:        msg.repo,
-			runsLoading: true,
-			view:        types.ViewList,
+			id:             m.nextTabID,
+			repo:           msg.repo,
+			runsLoading:    true,
+			historyLoading: true,
+			view:           types.ViewList,
 		}
 		m.tabs = []repoTab{tab}
 		m.activeTab = 0
-		return m, tea.Batch(fetchRuns(msg.repo, 0), pollTick(m.interval), countdownTick())
+		m.localRepo = msg.repo
+		return m, tea.Batch(m.readHead(), m.cachedRuns(msg.repo, tab.id), m.fetchRuns(msg.repo, tab.id), m.loadHistory(msg.repo, tab.id), m.loadFlaky(msg.repo, "", tab.id, false), pollTick(m.interval), countdownTick())
+
+	case orgReposMsg:
+		// Trends and flaky jobs are loaded when their views are opened, so
+		// that dozens of tabs don't start with hundreds of fetches.
+		m.repoLoading = false
+		m.tabs = nil
+		for _, r := range msg.repos {
+			m.nextTabID++
+			m.tabs = append(m.tabs, repoTab{
+				id:            m.nextTabID,
+				repo:          r.NameWithOwner,
+				defaultBranch: r.DefaultBranch,
+				runsLoading:   true,
+				view:          types.ViewList,
+			})
+		}
+		m.activeTab = 0
+		m.showOverview = true
+		m.overviewAttention = true
+		cmds := append(m.orgPoll(time.Now()), m.fetchRateLimit(), pollTick(m.interval), countdownTick())
+		for _, t := range m.tabs {
+			cmds = append(cmds, m.cachedRuns(t.repo, t.id))
+		}
+		return m, tea.Batch(cmds...)
+
+	case rateLimitMsg:
+		m.rateLimit = msg.rateLimit
+		return m, nil
 
 	case repoErrorMsg:
 		m.repoLoading = false
@@ -129,45 +391,213 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		m.showPicker = true
 		m.pickerLoading = true
 		m.pickerFilter.Focus()
-		return m, tea.Batch(m.pickerFilter.Cursor.BlinkCmd(), fetchRepoList())
+		return m, tea.Batch(m.pickerFilter.Cursor.BlinkCmd(), m.fetchRepoList())
 
 	case runsMsg:
-		if msg.tabIndex < len(m.tabs) {
-			t := &m.tabs[msg.tabIndex]
+		if i := m.indexOfTab(msg.tab); i >= 0 {
+			t := &m.tabs[i]
+			if msg.cached {
+				// Only a stand-in until the first fetch returns.
+				if t.fetchedAt.IsZero() && t.runsJSON == "" {
+					t.runsJSON = msg.json
+					t.runs = msg.runs
+				}
+				return m, nil
+			}
 			t.runsLoading = false
 			t.runsError = ""
+			t.fetchedAt = time.Now()
+			t.failures = 0
+			t.runsAt = msg.asOf
+			if t.runsAt.IsZero() {
+				t.runsAt = t.fetchedAt
+				m.noteFetch(nil)
+			}
 			if msg.json != t.runsJSON {
 				t.runsJSON = msg.json
 				t.runs = msg.runs
+				t.selectedIndex = clampCursor(t.selectedIndex, len(t.listRuns()))
 			}
 		}
 		return m, nil
 
+	case headMsg:
+		if !m.here {
+			return m, nil
+		}
+		m.headError = ""
+		if msg.err != nil {
+			m.headError = msg.err.Error()
+		}
+		if msg.head != nil {
+			m.setHead(msg.head)
+		}
+		return m, nil
+
 	case runsErrMsg:
-		if msg.tabIndex < len(m.tabs) {
-			t := &m.tabs[msg.tabIndex]
+		if i := m.indexOfTab(msg.tab); i >= 0 {
+			t := &m.tabs[i]
 			t.runsLoading = false
-			t.runsError = msg.err.Error()
+			t.runsError = gh.Explain(msg.err)
+			m.noteFetch(msg.err)
+			t.fetchedAt = time.Now()
+			t.failures++
 		}
 		return m, nil
 
 	case detailMsg:
-		if msg.tabIndex < len(m.tabs) {
-			t := &m.tabs[msg.tabIndex]
+		// A run opened since the fetch started has replaced this one.
+		if i := m.indexOfTab(msg.tab); i >= 0 && m.tabs[i].selectedRunID == msg.runID {
+			t := &m.tabs[i]
 			t.detailLoading = false
 			t.detailError = ""
+			t.detailAt = msg.asOf
+			if t.detailAt.IsZero() {
+				t.detailAt = time.Now()
+				m.noteFetch(nil)
+			}
 			if msg.json != t.detailJSON {
 				t.detailJSON = msg.json
 				t.detail = msg.detail
 			}
+			// Billable time is only final once the run has finished.
+			d := msg.detail
+			if d.Status == types.StatusCompleted && t.timingRunID != d.DatabaseID {
+				t.timingRunID = d.DatabaseID
+				t.timing = nil
+				return m, m.fetchTiming(t.repo, d.DatabaseID, t.id)
+			}
+		}
+		return m, nil
+
+	case timingMsg:
+		if i := m.indexOfTab(msg.tab); i >= 0 && m.tabs[i].timingRunID == msg.runID {
+			m.tabs[i].timing = msg.timing
+		}
+		return m, nil
+
+	case costMsg:
+		if i := m.indexOfTab(msg.tab); i >= 0 {
+			t := &m.tabs[i]
+			t.costLoading = false
+			t.costError = ""
+			if msg.err != nil {
+				t.costError = msg.err.Error()
+			} else {
+				t.costRuns, t.costTimings, t.costMissing = msg.runs, msg.timings, msg.missing
+			}
 		}
 		return m, nil
 
 	case detailErrMsg:
-		if msg.tabIndex < len(m.tabs) {
-			t := &m.tabs[msg.tabIndex]
+		if i := m.indexOfTab(msg.tab); i >= 0 && m.tabs[i].selectedRunID == msg.runID {
+			t := &m.tabs[i]
 			t.detailLoading = false
-			t.detailError = msg.err.Error()
+			t.detailError = gh.Explain(msg.err)
+			m.noteFetch(msg.err)
+		}
+		return m, nil
+
+	case graphMsg:
+		if i := m.indexOfTab(msg.tab); i >= 0 && m.tabs[i].graphRunID == msg.runID {
+			t := &m.tabs[i]
+			t.graphLoading = false
+			t.graphError = ""
+			t.graph = msg.graph
+		}
+		return m, nil
+
+	case graphErrMsg:
+		if i := m.indexOfTab(msg.tab); i >= 0 && m.tabs[i].graphRunID == msg.runID {
+			t := &m.tabs[i]
+			t.graphLoading = false
+			t.graphError = msg.err.Error()
+		}
+		return m, nil
+
+	case historyMsg:
+		if i := m.indexOfTab(msg.tab); i >= 0 {
+			t := &m.tabs[i]
+			t.historyLoading = false
+			t.historyError = ""
+			if msg.err != nil {
+				t.historyError = msg.err.Error()
+			}
+			if len(msg.runs) > 0 {
+				t.history = msg.runs
+				t.selectedIndex = clampCursor(t.selectedIndex, len(t.listRuns()))
+			}
+		}
+		return m, nil
+
+	case flakyMsg:
+		if i := m.indexOfTab(msg.tab); i >= 0 {
+			t := &m.tabs[i]
+			t.flakyLoading = false
+			t.flakyError = ""
+			if msg.err != nil {
+				t.flakyError = msg.err.Error()
+			} else {
+				t.flaky = msg.flaky
+			}
+			if msg.defaultBranch != "" {
+				t.defaultBranch = msg.defaultBranch
+			}
+		}
+		return m, nil
+
+	case statsMsg:
+		if i := m.indexOfTab(msg.tab); i >= 0 {
+			t := &m.tabs[i]
+			t.statsLoading = false
+			t.statsError = ""
+			if msg.err != nil {
+				t.statsError = msg.err.Error()
+			} else {
+				t.statsRuns = msg.runs
+			}
+			if msg.defaultBranch != "" {
+				t.defaultBranch = msg.defaultBranch
+			}
+		}
+		return m, nil
+
+	case queueMsg:
+		if i := m.indexOfTab(msg.tab); i >= 0 {
+			t := &m.tabs[i]
+			t.queueLoading = false
+			t.queueError = ""
+			if msg.err != nil {
+				t.queueError = msg.err.Error()
+			}
+			t.queueDetails = msg.details
+		}
+		return m, nil
+
+	case compareMsg:
+		if i := m.indexOfTab(msg.tab); i >= 0 && m.tabs[i].compareIDs() == msg.ids {
+			t := &m.tabs[i]
+			t.compareLoading = false
+			t.compareError = ""
+			if msg.err != nil {
+				t.compareError = msg.err.Error()
+			} else {
+				t.compareOld, t.compareNew, t.compareRange = msg.old, msg.new, msg.commits
+			}
+		}
+		return m, nil
+
+	case defaultBranchMsg:
+		if i := m.indexOfTab(msg.tab); i >= 0 && msg.branch != "" {
+			m.tabs[i].defaultBranch = msg.branch
+		}
+		return m, nil
+
+	case jobLogMsg:
+		// The log could not be shown (e.g. it was deleted or is still being
+		// written), so fall back to the job page.
+		if msg.err != nil && msg.url != "" {
+			openBrowser(msg.url)
 		}
 		return m, nil
 
@@ -181,14 +611,44 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		return m, nil
 
 	case pollTickMsg:
-		m.countdown = int(m.interval.Seconds())
-		cmds := []tea.Cmd{pollTick(m.interval)}
+		if m.offline {
+			// Recorded history doesn't change.
+			return m, nil
+		}
+		// The next interval follows from what the last poll found.
+		now := time.Now()
+		m.pollEvery, m.pollWhy = m.nextInterval(now)
+		m.countdown = int(m.pollEvery.Seconds())
+		cmds := []tea.Cmd{pollTick(m.pollEvery), m.fetchRateLimit(), m.readHead()}
+		if b, ok := m.backend.(gh.Batcher); ok {
+			cmds = append(cmds, m.fetchBatch(b, now))
+			return m, tea.Batch(cmds...)
+		}
 		for i := range m.tabs {
-			cmds = append(cmds, fetchRuns(m.tabs[i].repo, i))
-			if i == m.activeTab && m.tabs[i].view == types.ViewDetail && m.tabs[i].selectedRunID != 0 {
-				cmds = append(cmds, fetchRunDetail(m.tabs[i].repo, m.tabs[i].selectedRunID, i))
+			if m.org == "" && !m.backingOff(m.tabs[i], now) {
+				cmds = append(cmds, m.fetchRuns(m.tabs[i].repo, m.tabs[i].id))
+			}
+			if i == m.activeTab && m.tabs[i].showsRun() && m.tabs[i].selectedRunID != 0 {
+				cmds = append(cmds, m.fetchRunDetail(m.tabs[i].repo, m.tabs[i].selectedRunID, m.tabs[i].id))
 			}
 		}
+		if m.org != "" {
+			// Watching an org, there may be more repos than the rate limit
+			// allows fetching every poll.
+			cmds = append(cmds, m.orgPoll(now)...)
+		}
+		return m, tea.Batch(cmds...)
+
+	case fetchDoneMsg:
+		return m.fetchDone(msg)
+
+	case batchMsg:
+		var cmds []tea.Cmd
+		for _, msg := range msg.msgs {
+			next, cmd := m.Update(msg)
+			m = next.(Model)
+			cmds = append(cmds, cmd)
+		}
 		return m, tea.Batch(cmds...)
 
 	case countdownTickMsg:
@@ -198,6 +658,7 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		return m, countdownTick()
 
 	case tea.KeyMsg:
+		m.lastInput = time.Now()
 		return m.handleKey(msg)
 	}
 
@@ -233,23 +694,32 @@ func (m Model) handleKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 			idx := int(k[0] - '1')
 			if idx < len(m.tabs) {
 				m.activeTab = idx
+				m.showOverview = false
 				return m, nil
 			}
 		}
 	}
 
+	if m.showOverview {
+		return m.handleOverviewKey(msg)
+	}
+
 	tab := m.tabs[m.activeTab]
 	switch tab.view {
 	case types.ViewList:
 		return m.handleListKey(msg)
 	case types.ViewDetail:
 		return m.handleDetailKey(msg)
+	case types.ViewGraph, types.ViewTimeline, types.ViewWorkflows, types.ViewFlaky, types.ViewStats, types.ViewQueue, types.ViewCompare, types.ViewCost:
+		return m.handleScrollKey(msg)
 	}
 	return m, nil
 }
 
 func (m Model) handleListKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 	t := &m.tabs[m.activeTab]
+	h := m.listHeight()
+	runs := t.listRuns()
 	switch {
 	case key.Matches(msg, ui.ListKeys.Quit):
 		return m, tea.Quit
@@ -270,36 +740,122 @@ func (m Model) handleListKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 			t.selectedIndex--
 		}
 	case key.Matches(msg, ui.ListKeys.Down):
-		if t.selectedIndex < len(t.runs)-1 {
+		if t.selectedIndex < len(runs)-1 {
 			t.selectedIndex++
 		}
+	case key.Matches(msg, ui.ListKeys.PageUp):
+		t.selectedIndex = clampCursor(t.selectedIndex-pageSize(h), len(runs))
+	case key.Matches(msg, ui.ListKeys.PageDown):
+		t.selectedIndex = clampCursor(t.selectedIndex+pageSize(h), len(runs))
+	case key.Matches(msg, ui.ListKeys.Home):
+		t.selectedIndex = 0
+	case key.Matches(msg, ui.ListKeys.End):
+		t.selectedIndex = clampCursor(len(runs)-1, len(runs))
 	case key.Matches(msg, ui.ListKeys.Enter):
-		if len(t.runs) > 0 && t.selectedIndex < len(t.runs) {
-			run := t.runs[t.selectedIndex]
-			t.selectedRunID = run.DatabaseID
-			t.detail = nil
-			t.detailJSON = ""
-			t.detailScrollOffset = 0
-			t.detailLoading = true
-			t.view = types.ViewDetail
-			return m, fetchRunDetail(t.repo, run.DatabaseID, m.activeTab)
+		if len(runs) > 0 && t.selectedIndex < len(runs) {
+			return m.openRun(runs[t.selectedIndex])
 		}
+	case key.Matches(msg, ui.ListKeys.Workflows):
+		t.view = types.ViewWorkflows
+		t.scroll = viewport{}
+		if !t.historyLoading {
+			t.historyLoading = true
+			return m, m.loadHistory(t.repo, t.id)
+		}
+	case key.Matches(msg, ui.ListKeys.Flaky):
+		t.view = types.ViewFlaky
+		t.scroll = viewport{}
+		if !t.flakyLoading {
+			t.flakyLoading = true
+			return m, m.loadFlaky(t.repo, t.defaultBranch, t.id, true)
+		}
+	case key.Matches(msg, ui.ListKeys.Stats):
+		t.view = types.ViewStats
+		t.scroll = viewport{}
+		if !t.statsLoading {
+			t.statsLoading = true
+			return m, m.loadStats(t.repo, t.defaultBranch, t.id)
+		}
+	case key.Matches(msg, ui.ListKeys.Queue):
+		t.view = types.ViewQueue
+		t.scroll = viewport{}
+		if !t.queueLoading {
+			t.queueLoading = true
+			return m, m.loadQueue(t.repo, t.runs, t.id)
+		}
+	case key.Matches(msg, ui.ListKeys.Mark):
+		if len(runs) > 0 && t.selectedIndex < len(runs) {
+			t.toggleMark(runs[t.selectedIndex])
+		}
+	case key.Matches(msg, ui.ListKeys.Compare):
+		old, new, ok := t.comparePair()
+		if !ok {
+			return m, nil
+		}
+		t.view = types.ViewCompare
+		t.scroll = viewport{}
+		if t.compareIDs() != [2]int{old.DatabaseID, new.DatabaseID} {
+			t.compareOld, t.compareNew, t.compareRange = nil, nil, nil
+		}
+		t.compareRuns = [2]types.WorkflowRun{old, new}
+		t.compareLoading = true
+		return m, m.fetchComparison(t.repo, old, new, t.id)
+	case key.Matches(msg, ui.ListKeys.Cost):
+		t.view = types.ViewCost
+		t.scroll = viewport{}
+		if !t.costLoading {
+			t.costLoading = true
+			return m, m.loadCost(t.repo, t.id)
+		}
+	case key.Matches(msg, ui.ListKeys.Overview):
+		return m.openOverview()
+	case key.Matches(msg, ui.ListKeys.Here):
+		if m.localTab() != m.activeTab {
+			return m, nil
+		}
+		m.here = !m.here
+		m.headError = ""
+		if !m.here {
+			m.setHead(nil)
+			return m, nil
+		}
+		return m, m.readHead()
 	case key.Matches(msg, ui.ListKeys.Switch):
 		m.showPicker = true
 		m.pickerLoading = true
 		m.pickerSelected = 0
+		m.pickerScroll = viewport{}
 		m.pickerFilter.SetValue("")
 		m.pickerFilter.Focus()
-		return m, tea.Batch(m.pickerFilter.Cursor.BlinkCmd(), fetchRepoList())
+		return m, tea.Batch(m.pickerFilter.Cursor.BlinkCmd(), m.fetchRepoList())
 	case key.Matches(msg, ui.ListKeys.Refresh):
-		m.countdown = int(m.interval.Seconds())
-		return m, fetchRuns(t.repo, m.activeTab)
+		m.countdown = int(m.pollEvery.Seconds())
+		return m, m.fetchRuns(t.repo, t.id)
 	}
+	t.listScroll = t.listScroll.follow(t.selectedIndex, len(t.listRuns()), h)
 	return m, nil
 }
 
+// openRun shows the detail of a run of the active tab.
+func (m Model) openRun(run types.WorkflowRun) (tea.Model, tea.Cmd) {
+	t := &m.tabs[m.activeTab]
+	id := t.id
+	m.cancelFetches(func(k fetchKey) bool { return k.tab == id && k.run != 0 && k.kind == pollFetch })
+	t.selectedRunID = run.DatabaseID
+	t.detail = nil
+	t.detailJSON = ""
+	t.detailCursor = 0
+	t.expanded = nil
+	t.expandedGroups = nil
+	t.detailScroll = viewport{}
+	t.detailLoading = true
+	t.view = types.ViewDetail
+	return m, m.fetchRunDetail(t.repo, run.DatabaseID, t.id)
+}
+
 func (m Model) handleDetailKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 	t := &m.tabs[m.activeTab]
+	total, h := m.detailRowCount(), m.detailHeight()
 	switch {
 	case key.Matches(msg, ui.DetailKeys.Quit):
 		return m, tea.Quit
@@ -307,31 +863,185 @@ func (m Model) handleDetailKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 		if len(m.tabs) > 1 {
 			m.activeTab = (m.activeTab + 1) % len(m.tabs)
 		}
+		return m, nil
 	case key.Matches(msg, ui.DetailKeys.ShiftTab):
 		if len(m.tabs) > 1 {
 			m.activeTab = (m.activeTab - 1 + len(m.tabs)) % len(m.tabs)
 		}
+		return m, nil
 	case key.Matches(msg, ui.DetailKeys.CloseTab):
 		if len(m.tabs) > 1 {
 			return m.closeTab(m.activeTab), nil
 		}
 	case key.Matches(msg, ui.DetailKeys.Back):
+		id := t.id
+		m.cancelFetches(func(k fetchKey) bool { return k.tab == id && k.run != 0 && k.kind == pollFetch })
 		t.view = types.ViewList
 		t.selectedRunID = 0
 		t.detail = nil
 	case key.Matches(msg, ui.DetailKeys.Up):
-		if t.detailScrollOffset > 0 {
-			t.detailScrollOffset--
+		if t.detailCursor > 0 {
+			t.detailCursor--
 		}
 	case key.Matches(msg, ui.DetailKeys.Down):
-		t.detailScrollOffset++
+		if t.detailCursor < total-1 {
+			t.detailCursor++
+		}
+	case key.Matches(msg, ui.DetailKeys.PageUp):
+		t.detailCursor = clampCursor(t.detailCursor-pageSize(h), total)
+	case key.Matches(msg, ui.DetailKeys.PageDown):
+		t.detailCursor = clampCursor(t.detailCursor+pageSize(h), total)
+	case key.Matches(msg, ui.DetailKeys.Home):
+		t.detailCursor = 0
+	case key.Matches(msg, ui.DetailKeys.End):
+		t.detailCursor = clampCursor(total-1, total)
+	case key.Matches(msg, ui.DetailKeys.Toggle):
+		if n, ok := t.selectedNode(); ok {
+			t.setExpanded(!t.nodeExpanded(n))
+		}
+	case key.Matches(msg, ui.DetailKeys.Expand):
+		t.setExpanded(true)
+	case key.Matches(msg, ui.DetailKeys.Collapse):
+		t.setExpanded(false)
+	case key.Matches(msg, ui.DetailKeys.Select):
+		if n, ok := t.selectedNode(); ok && n.kind == nodeGroup {
+			t.setExpanded(!t.nodeExpanded(n))
+			break
+		}
+		return m, m.openSelectedNode()
 	case key.Matches(msg, ui.DetailKeys.Open):
 		if t.detail != nil && t.detail.URL != "" {
 			openBrowser(t.detail.URL)
 		}
+	case key.Matches(msg, ui.DetailKeys.Timeline):
+		if t.detail != nil {
+			t.view = types.ViewTimeline
+			t.scroll = viewport{}
+		}
+	case key.Matches(msg, ui.DetailKeys.Graph):
+		if t.detail == nil {
+			break
+		}
+		t.view = types.ViewGraph
+		t.scroll = viewport{}
+		if t.graphRunID == t.selectedRunID && t.graph != nil {
+			break
+		}
+		t.graph = nil
+		t.graphRunID = t.selectedRunID
+		t.graphLoading = true
+		t.graphError = ""
+		return m, m.fetchWorkflowGraph(t.repo, t.selectedRunID, t.detail.HeadSha, t.id)
 	case key.Matches(msg, ui.DetailKeys.Refresh):
-		m.countdown = int(m.interval.Seconds())
-		return m, tea.Batch(fetchRuns(t.repo, m.activeTab), fetchRunDetail(t.repo, t.selectedRunID, m.activeTab))
+		m.countdown = int(m.pollEvery.Seconds())
+		return m, tea.Batch(m.fetchRuns(t.repo, t.id), m.fetchRunDetail(t.repo, t.selectedRunID, t.id))
+	}
+	total = m.detailRowCount()
+	t.detailCursor = clampCursor(t.detailCursor, total)
+	t.detailScroll = t.detailScroll.follow(t.detailCursor, total, m.detailHeight())
+	return m, nil
+}
+
+// openSelectedNode shows the log of a finished job in gh's pager, or opens the
+// page of a running job or a step in the browser.
+func (m Model) openSelectedNode() tea.Cmd {
+	t := m.tabs[m.activeTab]
+	n, ok := t.selectedNode()
+	if !ok {
+		return nil
+	}
+	job := t.detail.Jobs[n.job]
+	switch n.kind {
+	case nodeGroup, nodeGrid:
+		return nil
+	case nodeStep:
+		if url := stepURL(job, job.Steps[n.step]); url != "" {
+			openBrowser(url)
+		}
+		return nil
+	}
+
+	if job.Status != types.StatusCompleted || job.DatabaseID == 0 {
+		if job.URL != "" {
+			openBrowser(job.URL)
+		}
+		return nil
+	}
+	return tea.ExecProcess(gh.JobLogCommand(t.repo, job.DatabaseID), func(err error) tea.Msg {
+		return jobLogMsg{url: job.URL, err: err}
+	})
+}
+
+// handleScrollKey handles keys for the scroll-only views. Run views go back to
+// the run's detail, repo summaries to the run list.
+func (m Model) handleScrollKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	t := &m.tabs[m.activeTab]
+	total, h := len(m.scrollRows()), m.scrollHeight()
+	switch {
+	case key.Matches(msg, ui.ScrollKeys.Quit):
+		return m, tea.Quit
+	case key.Matches(msg, ui.ScrollKeys.Tab):
+		if len(m.tabs) > 1 {
+			m.activeTab = (m.activeTab + 1) % len(m.tabs)
+		}
+	case key.Matches(msg, ui.ScrollKeys.ShiftTab):
+		if len(m.tabs) > 1 {
+			m.activeTab = (m.activeTab - 1 + len(m.tabs)) % len(m.tabs)
+		}
+	case key.Matches(msg, ui.ScrollKeys.CloseTab):
+		if len(m.tabs) > 1 {
+			return m.closeTab(m.activeTab), nil
+		}
+	case key.Matches(msg, ui.ScrollKeys.Back):
+		if t.showsRun() {
+			t.view = types.ViewDetail
+		} else {
+			t.view = types.ViewList
+		}
+	case key.Matches(msg, ui.ScrollKeys.Up):
+		t.scroll = t.scroll.scrollBy(-1, total, h)
+	case key.Matches(msg, ui.ScrollKeys.Down):
+		t.scroll = t.scroll.scrollBy(1, total, h)
+	case key.Matches(msg, ui.ScrollKeys.PageUp):
+		t.scroll = t.scroll.scrollBy(-pageSize(h), total, h)
+	case key.Matches(msg, ui.ScrollKeys.PageDown):
+		t.scroll = t.scroll.scrollBy(pageSize(h), total, h)
+	case key.Matches(msg, ui.ScrollKeys.Home):
+		t.scroll = viewport{}
+	case key.Matches(msg, ui.ScrollKeys.End):
+		t.scroll = t.scroll.scrollBy(total, total, h)
+	case key.Matches(msg, ui.ScrollKeys.Open):
+		if t.showsRun() && t.detail != nil && t.detail.URL != "" {
+			openBrowser(t.detail.URL)
+		}
+	case key.Matches(msg, ui.ScrollKeys.Refresh):
+		m.countdown = int(m.pollEvery.Seconds())
+		if t.showsRun() {
+			return m, tea.Batch(m.fetchRuns(t.repo, t.id), m.fetchRunDetail(t.repo, t.selectedRunID, t.id))
+		}
+		if t.view == types.ViewCost {
+			t.costLoading = true
+			return m, tea.Batch(m.fetchRuns(t.repo, t.id), m.loadCost(t.repo, t.id))
+		}
+		if t.view == types.ViewCompare {
+			t.compareLoading = true
+			old, new := t.compareRuns[0], t.compareRuns[1]
+			return m, tea.Batch(m.fetchRuns(t.repo, t.id), m.fetchComparison(t.repo, old, new, t.id))
+		}
+		if t.view == types.ViewQueue {
+			t.queueLoading = true
+			return m, tea.Batch(m.fetchRuns(t.repo, t.id), m.loadQueue(t.repo, t.runs, t.id))
+		}
+		if t.view == types.ViewStats {
+			t.statsLoading = true
+			return m, tea.Batch(m.fetchRuns(t.repo, t.id), m.loadStats(t.repo, t.defaultBranch, t.id))
+		}
+		if t.view == types.ViewFlaky {
+			t.flakyLoading = true
+			return m, tea.Batch(m.fetchRuns(t.repo, t.id), m.loadFlaky(t.repo, t.defaultBranch, t.id, true))
+		}
+		t.historyLoading = true
+		return m, tea.Batch(m.fetchRuns(t.repo, t.id), m.loadHistory(t.repo, t.id))
 	}
 	return m, nil
 }
@@ -354,22 +1064,34 @@ func (m Model) handlePickerKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 		if m.pickerSelected > 0 {
 			m.pickerSelected--
 		}
-		return m, nil
+		return m.followPicker(), nil
 	case key.Matches(msg, ui.PickerKeys.Down):
 		filtered := m.filteredPickerRepos()
 		if m.pickerSelected < len(filtered)-1 {
 			m.pickerSelected++
 		}
-		return m, nil
+		return m.followPicker(), nil
+	case key.Matches(msg, ui.PickerKeys.PageUp):
+		m.pickerSelected = clampCursor(m.pickerSelected-pageSize(m.pickerHeight()), len(m.filteredPickerRepos()))
+		return m.followPicker(), nil
+	case key.Matches(msg, ui.PickerKeys.PageDown):
+		m.pickerSelected = clampCursor(m.pickerSelected+pageSize(m.pickerHeight()), len(m.filteredPickerRepos()))
+		return m.followPicker(), nil
 	}
 	// Pass to text input for typing
 	var cmd tea.Cmd
 	m.pickerFilter, cmd = m.pickerFilter.Update(msg)
 	// Reset selection when filter changes
 	m.pickerSelected = 0
+	m.pickerScroll = viewport{}
 	return m, cmd
 }
 
+func (m Model) followPicker() Model {
+	m.pickerScroll = m.pickerScroll.follow(m.pickerSelected, len(m.filteredPickerRepos()), m.pickerHeight())
+	return m
+}
+
 func (m Model) handleWelcomeKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 	if msg.String() == "q" {
 		return m, tea.Quit
@@ -378,6 +1100,8 @@ func (m Model) handleWelcomeKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 }
 
 func (m Model) closeTab(idx int) Model {
+	id := m.tabs[idx].id
+	m.cancelFetches(func(k fetchKey) bool { return k.tab == id })
 	m.tabs = append(m.tabs[:idx], m.tabs[idx+1:]...)
 	if m.activeTab >= len(m.tabs) {
 		m.activeTab = len(m.tabs) - 1
@@ -442,10 +1166,13 @@ func (m Model) pickerSelect() (tea.Model, tea.Cmd) {
 	}
 
 	// Add new tab
+	m.nextTabID++
 	tab := repoTab{
-		repo:        repoName,
-		runsLoading: true,
-		view:        types.ViewList,
+		id:             m.nextTabID,
+		repo:           repoName,
+		runsLoading:    true,
+		historyLoading: true,
+		view:           types.ViewList,
 	}
 	m.tabs = append(m.tabs, tab)
 	newIdx := len(m.tabs) - 1
@@ -453,7 +1180,7 @@ func (m Model) pickerSelect() (tea.Model, tea.Cmd) {
 	m.showPicker = false
 	m.pickerFilter.Blur()
 
-	cmds := []tea.Cmd{fetchRuns(repoName, newIdx)}
+	cmds := []tea.Cmd{m.cachedRuns(repoName, tab.id), m.fetchRuns(repoName, tab.id), m.loadHistory(repoName, tab.id), m.loadFlaky(repoName, "", tab.id, false)}
 	// Start polling if this is the first tab
 	if len(m.tabs) == 1 {
 		cmds = append(cmds, pollTick(m.interval), countdownTick())
@@ -477,6 +1204,9 @@ func (m Model) pickerRemoveTab() (tea.Model, tea.Cmd) {
 
 func (m Model) View() string {
 	if m.repoLoading {
+		if m.org != "" {
+			return ui.Dim.Render("Finding active repos in " + m.org + "...")
+		}
 		return ui.Dim.Render("Detecting repository...")
 	}
 
@@ -488,12 +1218,18 @@ func (m Model) View() string {
 		return m.welcomeView()
 	}
 
+	if m.showOverview {
+		return m.overviewViewFull()
+	}
+
 	t := m.tabs[m.activeTab]
 	switch t.view {
 	case types.ViewList:
 		return m.listViewFull()
 	case types.ViewDetail:
 		return m.detailViewFull()
+	case types.ViewGraph, types.ViewTimeline, types.ViewWorkflows, types.ViewFlaky, types.ViewStats, types.ViewQueue, types.ViewCompare, types.ViewCost:
+		return m.scrollViewFull()
 	}
 	return ""
 }
@@ -505,13 +1241,23 @@ func (m Model) tabBar() string {
 	var parts []string
 	for i, t := range m.tabs {
 		label := fmt.Sprintf("%d: %s", i+1, t.repo)
-		if i == m.activeTab {
+		if i == m.activeTab && !m.showOverview {
 			parts = append(parts, ui.TabActive.Render(label))
 		} else {
 			parts = append(parts, ui.TabInactive.Render(label))
 		}
 	}
-	return strings.Join(parts, "  ") + "\n"
+	bar := strings.Join(parts, "  ")
+	if m.width > 0 && lipgloss.Width(bar) > m.width {
+		// Too many tabs to fit on one line, e.g. watching an org: name
+		// only the active one.
+		label := fmt.Sprintf("%d repos", len(m.tabs))
+		if !m.showOverview {
+			label = fmt.Sprintf("%d/%d: %s", m.activeTab+1, len(m.tabs), m.tabs[m.activeTab].repo)
+		}
+		bar = ui.TabActive.Render(label)
+	}
+	return bar + "\n"
 }
 
 func (m Model) listViewFull() string {
@@ -525,6 +1271,12 @@ func (m Model) listViewFull() string {
 	b.WriteString(ui.CyanBold.Render("GitHub Actions"))
 	b.WriteString(" - ")
 	b.WriteString(ui.Bold.Render(t.repo))
+	if here := m.hereStatus(); here != "" {
+		b.WriteString(" " + here)
+	}
+	if stale := m.staleness(t.runsAt, t.runsError != ""); stale != "" && len(t.runs) > 0 {
+		b.WriteString(" " + stale)
+	}
 	b.WriteString("\n\n")
 
 	if t.runsError != "" {
@@ -532,6 +1284,10 @@ func (m Model) listViewFull() string {
 		b.WriteString("\n")
 	}
 
+	if pending, n := m.pendingView(); n > 0 {
+		b.WriteString(pending)
+		b.WriteString("\n")
+	}
 	if t.runsLoading && len(t.runs) == 0 {
 		b.WriteString(ui.Dim.Render("Loading runs..."))
 	} else {
@@ -573,22 +1329,100 @@ func (m Model) pickerViewFull() string {
 	return b.String()
 }
 
+// bodyHeight returns the lines left for a view's body once the tab bar, the
+// header and the footer are drawn, minus extra lines the view pins itself.
+// It returns 0 until the terminal size is known.
+func (m Model) bodyHeight(extra int) int {
+	if m.height == 0 {
+		return 0
+	}
+	h := m.height - 3 - extra // header, blank line, footer
+	if len(m.tabs) > 1 {
+		h-- // tab bar
+	}
+	if h < 1 {
+		h = 1
+	}
+	return h
+}
+
+// listHeight is the number of run rows visible in the list view.
+func (m Model) listHeight() int {
+	t := m.tabs[m.activeTab]
+	extra := 0
+	if t.runsError != "" {
+		extra++
+	}
+	_, pending := m.pendingView()
+	return rowsHeight(m.bodyHeight(extra+pending), len(t.listRuns()))
+}
+
+// detailHeight is the number of job/step rows visible in the detail view.
+func (m Model) detailHeight() int {
+	return rowsHeight(m.bodyHeight(detailHeaderLines), m.detailRowCount())
+}
+
+// pickerHeight is the number of repo rows visible in the picker.
+func (m Model) pickerHeight() int {
+	if m.height == 0 {
+		return 0
+	}
+	// header, blank, search, blank ... blank, hint
+	h := m.height - 6
+	if h < 1 {
+		h = 1
+	}
+	return rowsHeight(h, len(m.filteredPickerRepos()))
+}
+
+// pageSize is the number of rows PgUp/PgDn move for a view of height h.
+func pageSize(h int) int {
+	if h <= 0 {
+		return 10
+	}
+	return h
+}
+
 func (m Model) footerView() string {
 	t := m.tabs[m.activeTab]
 	var hint string
 	switch t.view {
 	case types.ViewList:
-		hint = "up/down: navigate | enter: details | s: switch repo | r: refresh | q: quit"
+		hint = "up/down/pgup/pgdn: navigate | enter: details | m: mark | c: compare | f: workflows | F: flaky | d: stats | u: queue | $: cost | O: overview | h: here | s: switch repo | r: refresh | q: quit"
 		if len(m.tabs) > 1 {
-			hint = "up/down: navigate | enter: details | tab/shift-tab: switch tab | w: close tab | s: add repo | r: refresh | q: quit"
+			hint = "up/down/pgup/pgdn: navigate | enter: details | m: mark | c: compare | f: workflows | F: flaky | d: stats | u: queue | $: cost | O: overview | h: here | tab/shift-tab: switch tab | w: close tab | s: add repo | r: refresh | q: quit"
 		}
 	case types.ViewDetail:
-		hint = "up/down: scroll | esc: back | o: open in browser | r: refresh | q: quit"
+		hint = "up/down: select | space: expand/collapse | enter: log/open | g: graph | t: timeline | esc: back | o: open run | r: refresh | q: quit"
+		if len(m.tabs) > 1 {
+			hint = "up/down: select | space: expand | enter: log/open | g: graph | t: timeline | esc: back | tab/shift-tab: switch tab | o: open run | r: refresh | q: quit"
+		}
+	case types.ViewGraph, types.ViewTimeline:
+		hint = "up/down: scroll | esc: back | o: open run | r: refresh | q: quit"
+		if len(m.tabs) > 1 {
+			hint = "up/down: scroll | esc: back | tab/shift-tab: switch tab | o: open run | r: refresh | q: quit"
+		}
+	case types.ViewWorkflows, types.ViewFlaky, types.ViewStats, types.ViewQueue, types.ViewCompare, types.ViewCost:
+		hint = "up/down: scroll | esc: back | r: refresh | q: quit"
 		if len(m.tabs) > 1 {
-			hint = "up/down: scroll | esc: back | tab/shift-tab: switch tab | o: open | r: refresh | q: quit"
+			hint = "up/down: scroll | esc: back | tab/shift-tab: switch tab | r: refresh | q: quit"
 		}
 	}
-	return ui.Dim.Render(fmt.Sprintf("%s | next refresh: %ds", hint, m.countdown))
+	return ui.Dim.Render(hint + " | " + m.refreshStatus())
+}
+
+// refreshStatus shows when the next poll is and, when polling adapted, how
+// often polls run and why.
+func (m Model) refreshStatus() string {
+	if conn := m.connectionStatus(); m.offline {
+		return conn
+	} else if conn != "" {
+		return fmt.Sprintf("%s, next try: %ds", conn, m.countdown)
+	}
+	if m.pollWhy == "" {
+		return fmt.Sprintf("next refresh: %ds", m.countdown)
+	}
+	return fmt.Sprintf("next refresh: %ds (every %s, %s)", m.countdown, format.DurationOf(m.pollEvery), m.pollWhy)
 }
 
 // Commands
@@ -601,31 +1435,450 @@ func detectRepo() tea.Msg {
 	return repoDetectedMsg{repo}
 }
 
-func fetchRuns(repo string, tabIndex int) tea.Cmd {
-	return func() tea.Msg {
-		runs, err := gh.FetchRuns(repo)
+func (m Model) fetchRuns(repo string, tabID int) tea.Cmd {
+	if m.offline {
+		return m.snapshotRuns(repo, tabID)
+	}
+	st, b := m.store, m.backend
+	return m.fetch(fetchKey{tab: tabID}, func(ctx context.Context) tea.Msg {
+		runs, err := b.FetchRuns(ctx, repo)
 		if err != nil {
-			return runsErrMsg{tabIndex: tabIndex, err: err}
+			return runsErrMsg{tab: tabID, err: err}
+		}
+		if st != nil {
+			// History is best-effort; a full disk shouldn't break the UI.
+			_ = st.RecordRuns(repo, runs)
 		}
 		j, _ := json.Marshal(runs)
-		return runsMsg{tabIndex: tabIndex, runs: runs, json: string(j)}
-	}
+		return runsMsg{tab: tabID, runs: runs, json: string(j)}
+	})
 }
 
-func fetchRunDetail(repo string, runID int, tabIndex int) tea.Cmd {
+// cachedRuns shows the runs the backend cached, if it does, while the first
+// fetch is under way.
+func (m Model) cachedRuns(repo string, tabID int) tea.Cmd {
+	c, ok := m.backend.(gh.RunCache)
+	if !ok || m.offline {
+		return nil
+	}
 	return func() tea.Msg {
-		detail, err := gh.FetchRunDetail(repo, runID)
+		runs, ok := c.CachedRuns(repo)
+		if !ok {
+			return nil
+		}
+		j, _ := json.Marshal(runs)
+		return runsMsg{tab: tabID, runs: runs, json: string(j), cached: true}
+	}
+}
+
+func (m Model) fetchRunDetail(repo string, runID int, tabID int) tea.Cmd {
+	if m.offline {
+		return m.snapshotDetail(repo, runID, tabID)
+	}
+	st, b := m.store, m.backend
+	return m.fetch(fetchKey{tab: tabID, run: runID}, func(ctx context.Context) tea.Msg {
+		detail, err := b.FetchRunDetail(ctx, repo, runID)
 		if err != nil {
-			return detailErrMsg{tabIndex: tabIndex, err: err}
+			return detailErrMsg{tab: tabID, runID: runID, err: err}
+		}
+		if st != nil {
+			_ = st.RecordDetail(repo, detail)
 		}
 		j, _ := json.Marshal(detail)
-		return detailMsg{tabIndex: tabIndex, detail: detail, json: string(j)}
+		return detailMsg{tab: tabID, runID: runID, detail: detail, json: string(j)}
+	})
+}
+
+// historyLimit is how many runs are fetched for trends.
+const historyLimit = 200
+
+// loadHistory gathers older runs of a repo for trends: what the local store
+// has, plus, unless offline, a deeper run list from the API, which is
+// recorded as well.
+func (m Model) loadHistory(repo string, tabID int) tea.Cmd {
+	st, offline := m.store, m.offline
+	return m.fetch(fetchKey{tab: tabID, kind: historyFetch}, func(ctx context.Context) tea.Msg {
+		seen := make(map[int]bool)
+		var runs []types.WorkflowRun
+		var err error
+		if !offline {
+			runs, err = gh.FetchRunHistory(ctx, repo, historyLimit)
+		}
+		for _, r := range runs {
+			seen[r.DatabaseID] = true
+		}
+		if st != nil {
+			if err == nil {
+				_ = st.RecordRuns(repo, runs)
+			}
+			if recs, serr := st.Runs(repo); serr == nil {
+				for _, rec := range recs {
+					if !seen[rec.Run.DatabaseID] {
+						runs = append(runs, rec.Run)
+					}
+				}
+			}
+		}
+		return historyMsg{tab: tabID, runs: runs, err: err}
+	})
+}
+
+// loadStats fetches the runs of the widest stats window, and the default
+// branch for streaks and recovery times. Offline, the recorded runs stand in.
+func (m Model) loadStats(repo, defaultBranch string, tabID int) tea.Cmd {
+	st, windows := m.store, m.windows
+	if m.offline {
+		return m.fetch(fetchKey{tab: tabID, kind: statsFetch}, func(ctx context.Context) tea.Msg {
+			runs, err := recordedRuns(st, repo, stats.Since(windows, time.Now()))
+			return statsMsg{tab: tabID, runs: runs, defaultBranch: defaultBranch, err: err}
+		})
+	}
+	return m.fetch(fetchKey{tab: tabID, kind: statsFetch}, func(ctx context.Context) tea.Msg {
+		if defaultBranch == "" {
+			defaultBranch, _ = gh.FetchDefaultBranch(ctx, repo)
+		}
+		runs, err := gh.FetchRunsSince(ctx, repo, stats.Since(windows, time.Now()), stats.MaxRuns)
+		if err != nil {
+			return statsMsg{tab: tabID, defaultBranch: defaultBranch, err: err}
+		}
+		if st != nil {
+			_ = st.RecordRuns(repo, runs)
+		}
+		return statsMsg{tab: tabID, runs: runs, defaultBranch: defaultBranch}
+	})
+}
+
+// flakyBackfill caps how many run details and attempts are fetched each time
+// the flaky view loads, to fill gaps in the history.
+const flakyBackfill = 30
+
+// loadFlaky scores flaky jobs from the store's history. With backfill set it
+// first fetches the jobs of recent finished runs the store has no detail
+// for, and earlier attempts of re-run runs, unless offline.
+func (m Model) loadFlaky(repo, defaultBranch string, tabID int, backfill bool) tea.Cmd {
+	st := m.store
+	fetch := backfill && !m.offline
+	key := fetchKey{tab: tabID, kind: flakyFetch}
+	if backfill {
+		key.kind = backfillFetch
+	}
+	return m.fetch(key, func(ctx context.Context) tea.Msg {
+		if st == nil {
+			if !backfill {
+				return flakyMsg{tab: tabID}
+			}
+			return flakyMsg{tab: tabID, err: fmt.Errorf("run history is disabled")}
+		}
+		if defaultBranch == "" && fetch {
+			// Without it only reruns are detected; not worth failing over.
+			defaultBranch, _ = gh.FetchDefaultBranch(ctx, repo)
+		}
+		recs, err := st.Runs(repo)
+		if err != nil {
+			return flakyMsg{tab: tabID, err: err}
+		}
+		if fetch {
+			backfillAttempts(ctx, st, repo, recs)
+			if recs, err = st.Runs(repo); err != nil {
+				return flakyMsg{tab: tabID, err: err}
+			}
+		}
+		var attempts []types.RunDetail
+		for _, rec := range recs {
+			attempts = append(attempts, rec.AllAttempts()...)
+		}
+		return flakyMsg{
+			tab:           tabID,
+			flaky:         stats.FlakyJobs(attempts, defaultBranch),
+			defaultBranch: defaultBranch,
+		}
+	})
+}
+
+// backfillAttempts fetches missing details of finished runs, newest first.
+// Failed fetches are skipped; they are retried next load.
+func backfillAttempts(ctx context.Context, st *store.Store, repo string, recs []store.Record) {
+	var todo []detailFetch
+	for _, rec := range recs {
+		if rec.Run.Status != types.StatusCompleted {
+			continue
+		}
+		if rec.Detail == nil {
+			todo = append(todo, detailFetch{rec.Run.DatabaseID, 0})
+		}
+		for n := 1; n < rec.Run.Attempt; n++ {
+			if !rec.HasAttempt(n) {
+				todo = append(todo, detailFetch{rec.Run.DatabaseID, n})
+			}
+		}
+		if len(todo) >= flakyBackfill {
+			todo = todo[:flakyBackfill]
+			break
+		}
 	}
+	fetchDetails(ctx, st, repo, todo)
+}
+
+// detailFetch names a run attempt to fetch; attempt 0 is the latest.
+type detailFetch struct{ runID, attempt int }
+
+// fetchDetails fetches run details a few at a time, recording them in st if
+// it is set. Failed fetches are left out of the result.
+func fetchDetails(ctx context.Context, st *store.Store, repo string, todo []detailFetch) []types.RunDetail {
+	var (
+		mu      sync.Mutex
+		details []types.RunDetail
+	)
+	inParallel(todo, func(f detailFetch) {
+		var d *types.RunDetail
+		var err error
+		if f.attempt == 0 {
+			d, err = gh.FetchRunDetail(ctx, repo, f.runID)
+			if err == nil && st != nil {
+				_ = st.RecordDetail(repo, d)
+			}
+		} else {
+			d, err = gh.FetchRunAttempt(ctx, repo, f.runID, f.attempt)
+			if err == nil && st != nil {
+				_ = st.RecordAttempt(repo, d)
+			}
+		}
+		if err == nil {
+			mu.Lock()
+			details = append(details, *d)
+			mu.Unlock()
+		}
+	})
+	return details
 }
 
-func fetchRepoList() tea.Cmd {
+// inParallel calls fn for each item, a few at a time so as not to hammer
+// the API, and returns when all calls have.
+func inParallel[T any](items []T, fn func(T)) {
+	work := make(chan T)
+	var wg sync.WaitGroup
+	for range 4 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for item := range work {
+				fn(item)
+			}
+		}()
+	}
+	for _, item := range items {
+		work <- item
+	}
+	close(work)
+	wg.Wait()
+}
+
+// queueActiveRuns caps how many unfinished runs the queue view fetches jobs
+// for.
+const queueActiveRuns = 10
+
+// loadQueue gathers jobs with queue times: those of the tab's unfinished runs,
+// fetched fresh to see what is waiting now, and of recent runs in the store.
+// Offline, only those in the store are.
+func (m Model) loadQueue(repo string, runs []types.WorkflowRun, tabID int) tea.Cmd {
+	st, offline := m.store, m.offline
+	return m.fetch(fetchKey{tab: tabID, kind: queueFetch}, func(ctx context.Context) tea.Msg {
+		var todo []detailFetch
+		for _, r := range runs {
+			if r.Status != types.StatusCompleted && len(todo) < queueActiveRuns && !offline {
+				todo = append(todo, detailFetch{r.DatabaseID, 0})
+			}
+		}
+		details := fetchDetails(ctx, st, repo, todo)
+		if st == nil {
+			return queueMsg{tab: tabID, details: details}
+		}
+		recs, err := st.Runs(repo)
+		if err != nil {
+			return queueMsg{tab: tabID, details: details, err: err}
+		}
+		if !offline {
+			backfillAttempts(ctx, st, repo, recs)
+			if recs, err = st.Runs(repo); err != nil {
+				return queueMsg{tab: tabID, details: details, err: err}
+			}
+		}
+		// Fresh details first, so their jobs win over older copies.
+		for _, rec := range recs {
+			details = append(details, rec.AllAttempts()...)
+		}
+		return queueMsg{tab: tabID, details: details}
+	})
+}
+
+// fetchTiming gets the billable time of a finished run, from the store if it
+// was fetched before. Offline, there is none otherwise.
+func (m Model) fetchTiming(repo string, runID, tabID int) tea.Cmd {
+	st, offline := m.store, m.offline
+	return m.fetch(fetchKey{tab: tabID, run: runID, kind: timingFetch}, func(ctx context.Context) tea.Msg {
+		if st != nil {
+			if rec, ok, _ := st.Get(repo, runID); ok && rec.Timing != nil {
+				return timingMsg{tab: tabID, runID: runID, timing: rec.Timing}
+			}
+		}
+		if offline {
+			return timingMsg{tab: tabID, runID: runID}
+		}
+		timing, err := gh.FetchRunTiming(ctx, repo, runID)
+		if err != nil {
+			// The header just goes without it.
+			return timingMsg{tab: tabID, runID: runID}
+		}
+		if st != nil {
+			_ = st.RecordTiming(repo, runID, timing)
+		}
+		return timingMsg{tab: tabID, runID: runID, timing: timing}
+	})
+}
+
+// costFetchLimit caps how many run timings the cost view fetches per load;
+// the rest are fetched on later loads once these are in the store.
+const costFetchLimit = 200
+
+// loadCost gathers the finished runs of the widest stats window and their
+// billable time, fetching timings the store doesn't have yet. Like the other
+// summary views it fetches through gh whatever the backend: the API and
+// GraphQL backends only cover what polling needs. Offline, it goes by the
+// recorded runs and timings alone.
+func (m Model) loadCost(repo string, tabID int) tea.Cmd {
+	st, windows, offline := m.store, m.windows, m.offline
+	return m.fetch(fetchKey{tab: tabID, kind: costFetch}, func(ctx context.Context) tea.Msg {
+		since := stats.Since(windows, time.Now())
+		var runs []types.WorkflowRun
+		var err error
+		if offline {
+			runs, err = recordedRuns(st, repo, since)
+		} else {
+			runs, err = gh.FetchRunsSince(ctx, repo, since, stats.MaxRuns)
+		}
+		if err != nil {
+			return costMsg{tab: tabID, err: err}
+		}
+		if st != nil && !offline {
+			_ = st.RecordRuns(repo, runs)
+		}
+		timings := make(map[int]*types.RunTiming)
+		if st != nil {
+			if recs, err := st.Runs(repo); err == nil {
+				for _, rec := range recs {
+					if rec.Timing != nil {
+						timings[rec.Run.DatabaseID] = rec.Timing
+					}
+				}
+			}
+		}
+		var todo []int
+		missing := 0
+		for _, r := range runs {
+			if r.Status != types.StatusCompleted || timings[r.DatabaseID] != nil {
+				continue
+			}
+			if len(todo) < costFetchLimit && !offline {
+				todo = append(todo, r.DatabaseID)
+			} else {
+				missing++
+			}
+		}
+
+		var mu sync.Mutex
+		inParallel(todo, func(id int) {
+			timing, err := gh.FetchRunTiming(ctx, repo, id)
+			if err == nil && st != nil {
+				_ = st.RecordTiming(repo, id, timing)
+			}
+			mu.Lock()
+			defer mu.Unlock()
+			if err != nil {
+				missing++
+				return
+			}
+			timings[id] = timing
+		})
+		return costMsg{tab: tabID, runs: runs, timings: timings, missing: missing}
+	})
+}
+
+// fetchComparison fetches both runs of a comparison and the commits between
+// them. It replaces the fetch of another comparison under way.
+func (m Model) fetchComparison(repo string, old, new types.WorkflowRun, tabID int) tea.Cmd {
+	key := fetchKey{tab: tabID, kind: compareFetch}
+	m.cancelFetches(func(k fetchKey) bool { return k == key })
+	if m.offline {
+		st := m.store
+		return m.fetch(key, func(ctx context.Context) tea.Msg {
+			return snapshotComparison(st, repo, old, new, tabID)
+		})
+	}
+	return m.fetch(key, func(ctx context.Context) tea.Msg {
+		msg := compareMsg{tab: tabID, ids: [2]int{old.DatabaseID, new.DatabaseID}}
+		var wg sync.WaitGroup
+		var oldErr, newErr error
+		wg.Add(3)
+		go func() {
+			defer wg.Done()
+			msg.old, oldErr = gh.FetchRunDetail(ctx, repo, old.DatabaseID)
+		}()
+		go func() {
+			defer wg.Done()
+			msg.new, newErr = gh.FetchRunDetail(ctx, repo, new.DatabaseID)
+		}()
+		go func() {
+			defer wg.Done()
+			if old.HeadSha != "" && new.HeadSha != "" && old.HeadSha != new.HeadSha {
+				// Best effort: the runs can still be compared without it.
+				msg.commits, _ = gh.FetchComparison(ctx, repo, old.HeadSha, new.HeadSha)
+			}
+		}()
+		wg.Wait()
+		if oldErr != nil {
+			msg.err = oldErr
+		} else if newErr != nil {
+			msg.err = newErr
+		}
+		return msg
+	})
+}
+
+func (m Model) fetchWorkflowGraph(repo string, runID int, headSha string, tabID int) tea.Cmd {
+	if m.offline {
+		return func() tea.Msg {
+			return graphErrMsg{tab: tabID, runID: runID, err: errNotOffline}
+		}
+	}
+	return m.fetch(fetchKey{tab: tabID, run: runID, kind: graphFetch}, func(ctx context.Context) tea.Msg {
+		data, err := gh.FetchWorkflowFile(ctx, repo, runID, headSha)
+		if err != nil {
+			return graphErrMsg{tab: tabID, runID: runID, err: err}
+		}
+		wf, err := dag.Parse(data)
+		if err != nil {
+			return graphErrMsg{tab: tabID, runID: runID, err: err}
+		}
+		return graphMsg{tab: tabID, runID: runID, graph: wf}
+	})
+}
+
+func (m Model) fetchDefaultBranch(repo string, tabID int) tea.Cmd {
+	if m.offline {
+		return nil
+	}
+	return m.fetch(fetchKey{tab: tabID, kind: branchFetch}, func(ctx context.Context) tea.Msg {
+		branch, _ := gh.FetchDefaultBranch(ctx, repo)
+		return defaultBranchMsg{tab: tabID, branch: branch}
+	})
+}
+
+func (m Model) fetchRepoList() tea.Cmd {
+	if m.offline {
+		return m.snapshotRepoList()
+	}
 	return func() tea.Msg {
-		repos, err := gh.FetchRepoList()
+		repos, err := gh.FetchRepoList(context.Background())
 		if err != nil {
 			return repoListErrMsg{err}
 		}
@@ -0,0 +1,191 @@
+package model
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	"github.com/dzoba/github-actions-watcher/internal/format"
+	"github.com/dzoba/github-actions-watcher/internal/gh"
+	"github.com/dzoba/github-actions-watcher/internal/stats"
+	"github.com/dzoba/github-actions-watcher/internal/store"
+	"github.com/dzoba/github-actions-watcher/internal/types"
+	"github.com/dzoba/github-actions-watcher/internal/ui"
+)
+
+// snapshotRuns is how many recorded runs an offline tab lists, as many as a
+// fetch returns.
+const snapshotRuns = 20
+
+// Offline, tabs show the run history earlier sessions recorded instead of
+// fetching. Runs and details come with the time they were last seen. The
+// summary views go by the history too, and what it can't tell, such as the
+// workflow graph, is not available.
+
+// errNotOffline is what views that can only be fetched show offline.
+var errNotOffline = errors.New("not available offline")
+
+func (m Model) snapshotRuns(repo string, tabID int) tea.Cmd {
+	st := m.store
+	return func() tea.Msg {
+		recs, err := st.Runs(repo)
+		if err != nil {
+			return runsErrMsg{tab: tabID, err: err}
+		}
+		if len(recs) == 0 {
+			return runsErrMsg{tab: tabID, err: fmt.Errorf("no runs of %s were recorded yet", repo)}
+		}
+		recs = recs[:min(len(recs), snapshotRuns)]
+		runs := make([]types.WorkflowRun, len(recs))
+		var asOf time.Time
+		for i, rec := range recs {
+			runs[i] = rec.Run
+			if rec.LastSeen.After(asOf) {
+				asOf = rec.LastSeen
+			}
+		}
+		j, _ := json.Marshal(runs)
+		return runsMsg{tab: tabID, runs: runs, json: string(j), asOf: asOf}
+	}
+}
+
+func (m Model) snapshotDetail(repo string, runID, tabID int) tea.Cmd {
+	st := m.store
+	return func() tea.Msg {
+		rec, ok, err := st.Get(repo, runID)
+		if err != nil {
+			return detailErrMsg{tab: tabID, runID: runID, err: err}
+		}
+		if !ok || rec.Detail == nil {
+			return detailErrMsg{tab: tabID, runID: runID, err: fmt.Errorf("the jobs of run %d were never recorded", runID)}
+		}
+		j, _ := json.Marshal(rec.Detail)
+		asOf := rec.DetailSeen
+		if asOf.IsZero() {
+			// Recorded before detail fetches were dated; the run's last
+			// sighting is the closest there is.
+			asOf = rec.LastSeen
+		}
+		return detailMsg{tab: tabID, runID: runID, detail: rec.Detail, json: string(j), asOf: asOf}
+	}
+}
+
+// recordedRuns returns the recorded runs of repo created since, newest first
+// and at most stats.MaxRuns like a fetch of them.
+func recordedRuns(st *store.Store, repo string, since time.Time) ([]types.WorkflowRun, error) {
+	recs, err := st.Runs(repo)
+	if err != nil {
+		return nil, err
+	}
+	var runs []types.WorkflowRun
+	for _, rec := range recs {
+		created, err := time.Parse(time.RFC3339, rec.Run.CreatedAt)
+		if err != nil || created.Before(since) {
+			continue
+		}
+		runs = append(runs, rec.Run)
+		if len(runs) == stats.MaxRuns {
+			break
+		}
+	}
+	return runs, nil
+}
+
+// snapshotComparison compares the recorded details of two runs, without the
+// commits between them.
+func snapshotComparison(st *store.Store, repo string, old, new types.WorkflowRun, tabID int) tea.Msg {
+	msg := compareMsg{tab: tabID, ids: [2]int{old.DatabaseID, new.DatabaseID}}
+	if msg.old, msg.err = recordedDetail(st, repo, old.DatabaseID); msg.err != nil {
+		return msg
+	}
+	msg.new, msg.err = recordedDetail(st, repo, new.DatabaseID)
+	return msg
+}
+
+// recordedDetail returns the recorded detail of a run.
+func recordedDetail(st *store.Store, repo string, runID int) (*types.RunDetail, error) {
+	rec, ok, err := st.Get(repo, runID)
+	if err != nil {
+		return nil, err
+	}
+	if !ok || rec.Detail == nil {
+		return nil, fmt.Errorf("the jobs of run %d were never recorded", runID)
+	}
+	return rec.Detail, nil
+}
+
+// snapshotRepoList offers the repos with recorded history in the picker.
+func (m Model) snapshotRepoList() tea.Cmd {
+	st := m.store
+	return func() tea.Msg {
+		names, err := st.Repos()
+		if err != nil {
+			return repoListErrMsg{err}
+		}
+		repos := make([]types.PickerRepo, len(names))
+		for i, name := range names {
+			repos[i] = types.PickerRepo{NameWithOwner: name}
+		}
+		return repoListMsg{repos}
+	}
+}
+
+// snapshotOrgRepos finds the repos of an org that have recorded history.
+func (m Model) snapshotOrgRepos() tea.Cmd {
+	st, org := m.store, m.org
+	return func() tea.Msg {
+		names, err := st.Repos()
+		if err != nil {
+			return repoErrorMsg{err}
+		}
+		owner, _, _ := strings.Cut(org, "/")
+		var repos []types.OrgRepo
+		for _, name := range names {
+			if strings.HasPrefix(name, owner+"/") {
+				repos = append(repos, types.OrgRepo{NameWithOwner: name})
+			}
+		}
+		if len(repos) == 0 {
+			return repoErrorMsg{fmt.Errorf("no runs of repos in %s were recorded yet", owner)}
+		}
+		return orgReposMsg{repos}
+	}
+}
+
+// noteFetch keeps track of whether GitHub can be reached, from the outcome
+// of a fetch.
+func (m *Model) noteFetch(err error) {
+	switch {
+	case err == nil:
+		m.unreachableSince = time.Time{}
+	case gh.KindOf(err) == gh.ErrNetwork && m.unreachableSince.IsZero():
+		m.unreachableSince = time.Now()
+	}
+}
+
+// staleness marks data as of at that may be out of date, because the fetch
+// meant to replace it failed or ghaw is offline. It is empty for fresh data.
+func (m Model) staleness(at time.Time, failed bool) string {
+	if !failed && !m.offline {
+		return ""
+	}
+	if at.IsZero() {
+		return ui.Yellow.Render("stale")
+	}
+	return ui.Yellow.Render("stale, as of " + format.RelativeTime(at.Format(time.RFC3339)))
+}
+
+// connectionStatus is shown in the footer while GitHub can't be reached.
+func (m Model) connectionStatus() string {
+	switch {
+	case m.offline:
+		return "offline: browsing recorded history"
+	case !m.unreachableSince.IsZero():
+		return "offline for " + format.DurationOf(time.Since(m.unreachableSince)) + ", retrying"
+	}
+	return ""
+}
@@ -0,0 +1,147 @@
+package model
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	"github.com/dzoba/github-actions-watcher/internal/gh"
+	"github.com/dzoba/github-actions-watcher/internal/store"
+	"github.com/dzoba/github-actions-watcher/internal/types"
+)
+
+func TestOffline(t *testing.T) {
+	st, err := store.Open(t.TempDir(), store.Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	runs := []types.WorkflowRun{{DatabaseID: 2, DisplayTitle: "Second"}, {DatabaseID: 1, DisplayTitle: "First"}}
+	if err := st.RecordRuns("o/r", runs); err != nil {
+		t.Fatal(err)
+	}
+
+	m := New(Options{Store: st, Offline: true})
+	m.tabs = []repoTab{{id: 1, repo: "o/r", view: types.ViewList}}
+	m.repoLoading = false
+	next, _ := m.Update(m.fetchRuns("o/r", 1)())
+	m = next.(Model)
+	if got := len(m.tabs[0].runs); got != 2 {
+		t.Fatalf("%d runs shown from the history, want 2", got)
+	}
+	view := m.View()
+	for _, want := range []string{"stale, as of", "Second", "offline: browsing recorded history"} {
+		if !strings.Contains(view, want) {
+			t.Errorf("view lacks %q:\n%s", want, view)
+		}
+	}
+
+	// The jobs of a run never opened weren't recorded.
+	if msg, ok := m.fetchRunDetail("o/r", 1, 1)().(detailErrMsg); !ok {
+		t.Errorf("detail of an unrecorded run = %#v", msg)
+	}
+}
+
+func TestUnreachable(t *testing.T) {
+	m := New(Options{})
+	m.tabs = []repoTab{{id: 1, repo: "o/r", view: types.ViewList}}
+	m.repoLoading = false
+	next, _ := m.Update(runsMsg{tab: 1, runs: []types.WorkflowRun{{DatabaseID: 1}}, json: `[{"databaseId":1}]`})
+	m = next.(Model)
+	if view := m.View(); strings.Contains(view, "stale") {
+		t.Errorf("fresh runs are marked stale:\n%s", view)
+	}
+
+	// Runs are kept through failed fetches, and marked stale.
+	down := &gh.Error{Kind: gh.ErrNetwork, Message: "error connecting to api.github.com"}
+	next, _ = m.Update(runsErrMsg{tab: 1, err: down})
+	m = next.(Model)
+	since := m.unreachableSince
+	next, _ = m.Update(runsErrMsg{tab: 1, err: down})
+	m = next.(Model)
+	if since.IsZero() || !m.unreachableSince.Equal(since) {
+		t.Errorf("unreachable since %v, then %v", since, m.unreachableSince)
+	}
+	view := m.View()
+	if len(m.tabs[0].runs) != 1 || !strings.Contains(view, "stale, as of") || !strings.Contains(view, "offline for") {
+		t.Errorf("runs %v, view:\n%s", m.tabs[0].runs, view)
+	}
+
+	// Other failures don't mean GitHub is unreachable.
+	m.unreachableSince = time.Time{}
+	next, _ = m.Update(runsErrMsg{tab: 1, err: errors.New("boom")})
+	m = next.(Model)
+	if !m.unreachableSince.IsZero() {
+		t.Error("a non-network error counted as unreachable")
+	}
+
+	// A successful fetch resumes.
+	m.unreachableSince = since
+	next, _ = m.Update(runsMsg{tab: 1, runs: []types.WorkflowRun{{DatabaseID: 2}}, json: `[{"databaseId":2}]`})
+	m = next.(Model)
+	if !m.unreachableSince.IsZero() || m.tabs[0].runsError != "" {
+		t.Errorf("still unreachable since %v with error %q after a fetch", m.unreachableSince, m.tabs[0].runsError)
+	}
+}
+
+func TestOfflineViews(t *testing.T) {
+	// Without gh on the PATH, anything that shells out fails.
+	t.Setenv("PATH", t.TempDir())
+	st, err := store.Open(t.TempDir(), store.Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	created := time.Now().Add(-time.Hour).UTC().Format(time.RFC3339)
+	runs := []types.WorkflowRun{
+		{DatabaseID: 2, Attempt: 1, Status: types.StatusCompleted, CreatedAt: created},
+		{DatabaseID: 1, Attempt: 1, Status: types.StatusCompleted, CreatedAt: created},
+	}
+	for _, run := range runs {
+		if err := st.RecordDetail("o/r", &types.RunDetail{WorkflowRun: run}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	m := New(Options{Store: st, Offline: true})
+	m.tabs = []repoTab{{id: 1, repo: "o/r", view: types.ViewList}}
+	result := func(cmd tea.Cmd) tea.Msg {
+		if cmd == nil {
+			return nil
+		}
+		msg := cmd()
+		if done, ok := msg.(fetchDoneMsg); ok {
+			return done.msg
+		}
+		return msg
+	}
+
+	if msg := result(m.loadHistory("o/r", 1)).(historyMsg); msg.err != nil || len(msg.runs) != 2 {
+		t.Errorf("history: %d runs, %v", len(msg.runs), msg.err)
+	}
+	if msg := result(m.loadStats("o/r", "", 1)).(statsMsg); msg.err != nil || len(msg.runs) != 2 {
+		t.Errorf("stats: %d runs, %v", len(msg.runs), msg.err)
+	}
+	if msg := result(m.loadCost("o/r", 1)).(costMsg); msg.err != nil || len(msg.runs) != 2 || msg.missing != 2 {
+		t.Errorf("cost: %d runs, %d missing, %v", len(msg.runs), msg.missing, msg.err)
+	}
+	if msg := result(m.loadQueue("o/r", nil, 1)).(queueMsg); msg.err != nil || len(msg.details) != 2 {
+		t.Errorf("queue: %d details, %v", len(msg.details), msg.err)
+	}
+	if msg := result(m.loadFlaky("o/r", "", 1, true)).(flakyMsg); msg.err != nil {
+		t.Errorf("flaky: %v", msg.err)
+	}
+	if msg := result(m.fetchComparison("o/r", runs[1], runs[0], 1)).(compareMsg); msg.err != nil || msg.old == nil || msg.new == nil {
+		t.Errorf("comparison: %+v", msg)
+	}
+	if msg := result(m.fetchWorkflowGraph("o/r", 1, "abc", 1)).(graphErrMsg); !errors.Is(msg.err, errNotOffline) {
+		t.Errorf("graph: %v", msg.err)
+	}
+	if msg := result(m.fetchDefaultBranch("o/r", 1)); msg != nil {
+		t.Errorf("default branch fetched offline: %#v", msg)
+	}
+	if msg := result(m.fetchRepoList()).(repoListMsg); len(msg.repos) != 1 || msg.repos[0].NameWithOwner != "o/r" {
+		t.Errorf("picker repos: %+v", msg.repos)
+	}
+}
@@ -0,0 +1,342 @@
+package model
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/bubbles/key"
+	tea "github.com/charmbracelet/bubbletea"
+
+	"github.com/dzoba/github-actions-watcher/internal/format"
+	"github.com/dzoba/github-actions-watcher/internal/types"
+	"github.com/dzoba/github-actions-watcher/internal/ui"
+)
+
+// overviewRun is a run in the overview with the tab it belongs to.
+type overviewRun struct {
+	tab int
+	run types.WorkflowRun
+}
+
+// overviewRuns merges the runs of every tab, newest first or, when sorting
+// by status, running, queued and failed runs first. When only runs needing
+// attention are shown, the rest are left out.
+func (m Model) overviewRuns() []overviewRun {
+	var runs []overviewRun
+	for i, t := range m.tabs {
+		for _, run := range t.runs {
+			if m.overviewAttention && statusRank(run) > 2 {
+				continue
+			}
+			runs = append(runs, overviewRun{i, run})
+		}
+	}
+	sort.SliceStable(runs, func(i, j int) bool {
+		a, b := runs[i].run, runs[j].run
+		if m.overviewByStatus {
+			if ra, rb := statusRank(a), statusRank(b); ra != rb {
+				return ra < rb
+			}
+		}
+		return a.CreatedAt > b.CreatedAt
+	})
+	return runs
+}
+
+// statusRank orders runs by how much attention they need.
+func statusRank(run types.WorkflowRun) int {
+	switch run.Status {
+	case types.StatusInProgress:
+		return 0
+	case types.StatusQueued, types.StatusWaiting, types.StatusPending, types.StatusRequested:
+		return 1
+	}
+	switch run.Conclusion {
+	case types.ConclusionFailure, types.ConclusionTimedOut:
+		return 2
+	}
+	return 3
+}
+
+// latestOnDefault returns the latest finished run on the default branch.
+func (t repoTab) latestOnDefault() (types.WorkflowRun, bool) {
+	for _, run := range t.runs {
+		if run.HeadBranch == t.defaultBranch && run.Status == types.StatusCompleted {
+			return run, true
+		}
+	}
+	return types.WorkflowRun{}, false
+}
+
+// needsAttention reports whether a tab failed to load, is red on the default
+// branch or has runs in progress.
+func (t repoTab) needsAttention() bool {
+	if t.runsError != "" || t.active() {
+		return true
+	}
+	run, ok := t.latestOnDefault()
+	return ok && statusRank(run) == 2
+}
+
+// overviewHealthMax is how many health lines the overview shows before
+// leaving out repos that need no attention.
+const overviewHealthMax = 8
+
+// healthTabs returns the tabs to show a health line for, in tab order: all
+// of them when they fit, otherwise those needing attention first.
+func (m Model) healthTabs() (tabs []int, hidden int) {
+	for i := range m.tabs {
+		tabs = append(tabs, i)
+	}
+	if len(tabs) <= overviewHealthMax {
+		return tabs, 0
+	}
+	sort.SliceStable(tabs, func(a, b int) bool {
+		return m.tabs[tabs[a]].needsAttention() && !m.tabs[tabs[b]].needsAttention()
+	})
+	tabs = tabs[:overviewHealthMax]
+	sort.Ints(tabs)
+	return tabs, len(m.tabs) - overviewHealthMax
+}
+
+// repoHealth summarizes a tab in one line: the latest finished run on the
+// default branch and how many runs are running or queued.
+func (t repoTab) repoHealth() string {
+	var b strings.Builder
+	b.WriteString(ui.Bold.Render(format.Pad(format.Truncate(t.repo, 30), 30)))
+	b.WriteByte(' ')
+
+	switch {
+	case t.runsError != "":
+		b.WriteString(ui.Red.Render("error: " + format.Truncate(t.runsError, 60)))
+		return b.String()
+	case t.runsLoading && len(t.runs) == 0:
+		b.WriteString(ui.Dim.Render("loading..."))
+		return b.String()
+	}
+
+	// Until the default branch is known, leave its columns blank.
+	branch, latest := "", format.Pad("", 12)
+	if t.defaultBranch != "" {
+		branch = format.Truncate(t.defaultBranch, 16)
+		latest = ui.Dim.Render(format.Pad("no runs", 12))
+		if run, ok := t.latestOnDefault(); ok {
+			text, color := format.StatusBadge(run.Status, run.Conclusion)
+			latest = ui.BadgeStyle(color).Render(format.Pad(text, 12))
+		}
+	}
+	b.WriteString(ui.Magenta.Render(format.Pad(branch, 16)) + " " + latest)
+
+	var running, queued int
+	for _, run := range t.runs {
+		switch statusRank(run) {
+		case 0:
+			running++
+		case 1:
+			queued++
+		}
+	}
+	if running > 0 {
+		b.WriteString(ui.Yellow.Render(fmt.Sprintf("  %d running", running)))
+	}
+	if queued > 0 {
+		b.WriteString(ui.Gray.Render(fmt.Sprintf("  %d queued", queued)))
+	}
+	return b.String()
+}
+
+// overviewHeaderLines is the number of lines above the overview's run rows:
+// the health lines, a blank line, the title and a blank line.
+func (m Model) overviewHeaderLines() int {
+	tabs, hidden := m.healthTabs()
+	if hidden > 0 {
+		return len(tabs) + 4
+	}
+	return len(tabs) + 3
+}
+
+// overviewHeight is the number of run rows visible in the overview.
+func (m Model) overviewHeight() int {
+	return rowsHeight(m.bodyHeight(m.overviewHeaderLines()), len(m.overviewRuns()))
+}
+
+func (m Model) overviewView() string {
+	var b strings.Builder
+	tabs, hidden := m.healthTabs()
+	for _, i := range tabs {
+		b.WriteString(m.tabs[i].repoHealth())
+		b.WriteString("\n")
+	}
+	if hidden > 0 {
+		b.WriteString(ui.Dim.Render(fmt.Sprintf("and %d more repos", hidden)))
+		b.WriteString("\n")
+	}
+	b.WriteString("\n")
+	title := "All runs, newest first"
+	if m.overviewAttention {
+		title = "Running, queued and failed runs, newest first"
+	}
+	if m.overviewByStatus {
+		title = strings.Replace(title, "newest first", "by status", 1)
+	}
+	b.WriteString(ui.Dim.Render(title))
+	b.WriteString("\n\n")
+
+	runs := m.overviewRuns()
+	if len(runs) == 0 {
+		b.WriteString(ui.Dim.Render("No workflow runs found."))
+		return b.String()
+	}
+
+	cols := m.width
+	if cols == 0 {
+		cols = 120
+	}
+	showTime := cols >= 70
+	showBranch := cols >= 80
+	showWorkflow := cols >= 55
+	titleMax := cols - (2 + 14 + 21)
+	if showWorkflow {
+		titleMax -= 19
+	}
+	if showBranch {
+		titleMax -= 17
+	}
+	if showTime {
+		titleMax -= 9
+	}
+	if titleMax < 15 {
+		titleMax = 15
+	}
+
+	rows := make([]string, 0, len(runs))
+	for i, r := range runs {
+		run := r.run
+		var b strings.Builder
+		if i == m.overviewSelected {
+			b.WriteString("> ")
+		} else {
+			b.WriteString("  ")
+		}
+		badgeText, badgeColor := format.StatusBadge(run.Status, run.Conclusion)
+		b.WriteString(ui.BadgeStyle(badgeColor).Render(format.Pad(badgeText, 13)))
+		b.WriteByte(' ')
+		b.WriteString(ui.Bold.Render(format.Pad(format.Truncate(m.tabs[r.tab].repo, 20), 20)))
+		b.WriteByte(' ')
+		if showWorkflow {
+			b.WriteString(ui.Blue.Render(format.Pad(format.Truncate(run.WorkflowName, 18), 18)))
+			b.WriteByte(' ')
+		}
+		if showBranch {
+			b.WriteString(ui.Magenta.Render(format.Pad(format.Truncate(run.HeadBranch, 16), 16)))
+			b.WriteByte(' ')
+		}
+		b.WriteString(format.Pad(format.Truncate(run.DisplayTitle, titleMax), titleMax))
+		if showTime {
+			b.WriteByte(' ')
+			if run.Status == types.StatusInProgress {
+				b.WriteString(ui.Yellow.Render(format.Pad(format.Elapsed(run.CreatedAt), 8)))
+			} else {
+				b.WriteString(ui.Dim.Render(format.Pad(format.RelativeTime(run.CreatedAt), 8)))
+			}
+		}
+		rows = append(rows, b.String())
+	}
+	h := m.overviewHeight()
+	b.WriteString(m.overviewScroll.follow(m.overviewSelected, len(rows), h).render(rows, h))
+	return b.String()
+}
+
+func (m Model) overviewViewFull() string {
+	var b strings.Builder
+	b.WriteString(m.tabBar())
+	b.WriteString(ui.CyanBold.Render("GitHub Actions"))
+	b.WriteString(" - ")
+	if m.org != "" {
+		b.WriteString(ui.Bold.Render(fmt.Sprintf("%s (%d active repos)", m.org, len(m.tabs))))
+	} else {
+		b.WriteString(ui.Bold.Render(fmt.Sprintf("Overview of %d repos", len(m.tabs))))
+	}
+	b.WriteString("\n\n")
+	b.WriteString(m.overviewView())
+	b.WriteString("\n")
+	b.WriteString(ui.Dim.Render("up/down/pgup/pgdn: navigate | enter: details | s: sort by time/status | a: all/failing runs | esc: back | r: refresh | q: quit | " + m.refreshStatus()))
+	return b.String()
+}
+
+func (m Model) handleOverviewKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	total, h := len(m.overviewRuns()), m.overviewHeight()
+	switch {
+	case key.Matches(msg, ui.OverviewKeys.Quit):
+		return m, tea.Quit
+	case key.Matches(msg, ui.OverviewKeys.Back):
+		m.showOverview = false
+		return m, nil
+	case key.Matches(msg, ui.OverviewKeys.Up):
+		if m.overviewSelected > 0 {
+			m.overviewSelected--
+		}
+	case key.Matches(msg, ui.OverviewKeys.Down):
+		if m.overviewSelected < total-1 {
+			m.overviewSelected++
+		}
+	case key.Matches(msg, ui.OverviewKeys.PageUp):
+		m.overviewSelected = clampCursor(m.overviewSelected-pageSize(h), total)
+	case key.Matches(msg, ui.OverviewKeys.PageDown):
+		m.overviewSelected = clampCursor(m.overviewSelected+pageSize(h), total)
+	case key.Matches(msg, ui.OverviewKeys.Home):
+		m.overviewSelected = 0
+	case key.Matches(msg, ui.OverviewKeys.End):
+		m.overviewSelected = clampCursor(total-1, total)
+	case key.Matches(msg, ui.OverviewKeys.Sort):
+		m.overviewByStatus = !m.overviewByStatus
+		m.overviewSelected = 0
+	case key.Matches(msg, ui.OverviewKeys.Attention):
+		m.overviewAttention = !m.overviewAttention
+		m.overviewSelected = 0
+	case key.Matches(msg, ui.OverviewKeys.Enter):
+		runs := m.overviewRuns()
+		if m.overviewSelected >= len(runs) {
+			return m, nil
+		}
+		r := runs[m.overviewSelected]
+		m.showOverview = false
+		m.activeTab = r.tab
+		t := &m.tabs[r.tab]
+		for i, run := range t.listRuns() {
+			if run.DatabaseID == r.run.DatabaseID {
+				t.selectedIndex = i
+			}
+		}
+		return m.openRun(r.run)
+	case key.Matches(msg, ui.OverviewKeys.Refresh):
+		m.countdown = int(m.pollEvery.Seconds())
+		if m.org != "" {
+			return m, tea.Batch(m.orgPoll(time.Time{})...)
+		}
+		var cmds []tea.Cmd
+		for i := range m.tabs {
+			cmds = append(cmds, m.fetchRuns(m.tabs[i].repo, m.tabs[i].id))
+		}
+		return m, tea.Batch(cmds...)
+	}
+	m.overviewScroll = m.overviewScroll.follow(m.overviewSelected, total, m.overviewHeight())
+	return m, nil
+}
+
+// openOverview shows the overview, fetching the default branches the
+// health lines need.
+func (m Model) openOverview() (tea.Model, tea.Cmd) {
+	m.showOverview = true
+	m.overviewSelected = 0
+	m.overviewScroll = viewport{}
+	var cmds []tea.Cmd
+	for _, t := range m.tabs {
+		if t.defaultBranch == "" {
+			cmds = append(cmds, m.fetchDefaultBranch(t.repo, t.id))
+		}
+	}
+	return m, tea.Batch(cmds...)
+}
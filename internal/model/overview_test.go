@@ -0,0 +1,83 @@
+package model
+
+import (
+	"strings"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	"github.com/dzoba/github-actions-watcher/internal/types"
+)
+
+func overviewModel() Model {
+	run := func(id int, status types.RunStatus, conclusion types.RunConclusion, created string) types.WorkflowRun {
+		return types.WorkflowRun{DatabaseID: id, HeadBranch: "main", Status: status, Conclusion: conclusion, CreatedAt: created}
+	}
+	m := New(Options{})
+	m.tabs = []repoTab{
+		{repo: "o/api", defaultBranch: "main", view: types.ViewList, runs: []types.WorkflowRun{
+			run(4, types.StatusCompleted, types.ConclusionSuccess, "2024-01-04T00:00:00Z"),
+			run(1, types.StatusCompleted, types.ConclusionFailure, "2024-01-01T00:00:00Z"),
+		}},
+		{repo: "o/web", view: types.ViewList, runs: []types.WorkflowRun{
+			run(3, types.StatusInProgress, "", "2024-01-03T00:00:00Z"),
+			run(2, types.StatusCompleted, types.ConclusionFailure, "2024-01-02T00:00:00Z"),
+		}},
+	}
+	m.showOverview = true
+	return m
+}
+
+func overviewIDs(m Model) []int {
+	var ids []int
+	for _, r := range m.overviewRuns() {
+		ids = append(ids, r.run.DatabaseID)
+	}
+	return ids
+}
+
+func TestOverviewSort(t *testing.T) {
+	m := overviewModel()
+	if got := overviewIDs(m); len(got) != 4 || got[0] != 4 || got[1] != 3 || got[2] != 2 || got[3] != 1 {
+		t.Errorf("newest first = %v, want [4 3 2 1]", got)
+	}
+	m.overviewByStatus = true
+	if got := overviewIDs(m); got[0] != 3 || got[1] != 2 || got[2] != 1 || got[3] != 4 {
+		t.Errorf("by status = %v, want [3 2 1 4]", got)
+	}
+}
+
+func TestOverviewHealth(t *testing.T) {
+	m := overviewModel()
+	if got := m.tabs[0].repoHealth(); !strings.Contains(got, "main") || !strings.Contains(got, "passed") {
+		t.Errorf("o/api health = %q", got)
+	}
+	if got := m.tabs[1].repoHealth(); !strings.Contains(got, "1 running") {
+		t.Errorf("o/web health = %q", got)
+	}
+}
+
+func TestOverviewEnter(t *testing.T) {
+	m := overviewModel()
+	next, _ := m.handleOverviewKey(tea.KeyMsg{Type: tea.KeyDown})
+	next, cmd := next.(Model).handleOverviewKey(tea.KeyMsg{Type: tea.KeyEnter})
+	m = next.(Model)
+	if m.showOverview || m.activeTab != 1 || cmd == nil {
+		t.Fatalf("enter: overview %v, tab %d", m.showOverview, m.activeTab)
+	}
+	if tab := m.tabs[1]; tab.view != types.ViewDetail || tab.selectedRunID != 3 || tab.selectedIndex != 0 {
+		t.Errorf("tab = view %v, run %d, index %d", tab.view, tab.selectedRunID, tab.selectedIndex)
+	}
+}
+
+func TestOverviewHealthTabs(t *testing.T) {
+	m := New(Options{})
+	for i := 0; i < overviewHealthMax+2; i++ {
+		m.tabs = append(m.tabs, repoTab{repo: "o/quiet"})
+	}
+	m.tabs[9].runs = []types.WorkflowRun{{Status: types.StatusInProgress}}
+	tabs, hidden := m.healthTabs()
+	if len(tabs) != overviewHealthMax || hidden != 2 || tabs[len(tabs)-1] != 9 {
+		t.Errorf("healthTabs() = %v, %d hidden; want the running tab kept", tabs, hidden)
+	}
+}
@@ -0,0 +1,121 @@
+package model
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/dzoba/github-actions-watcher/internal/format"
+	"github.com/dzoba/github-actions-watcher/internal/stats"
+	"github.com/dzoba/github-actions-watcher/internal/types"
+	"github.com/dzoba/github-actions-watcher/internal/ui"
+)
+
+// jobTiming describes how long a job waited for a runner and ran, e.g.
+// "(queued 2m 5s, ran 4m 10s)", or how long it has been waiting so far.
+func (m Model) jobTiming(job types.Job, now time.Time) string {
+	wait, hasWait := stats.QueueTime(job, now)
+	if stats.Waiting(job) {
+		if !hasWait {
+			return ""
+		}
+		style := ui.Yellow
+		if wait > m.queueMax {
+			style = ui.Red
+		}
+		return style.Render("(waiting " + format.DurationOf(wait) + ")")
+	}
+	if job.StartedAt == "" {
+		return ""
+	}
+	ran := format.Duration(job.StartedAt, job.CompletedAt)
+	if !hasWait || wait < time.Second {
+		return ui.Dim.Render("(" + ran + ")")
+	}
+	queued := "queued " + format.DurationOf(wait)
+	if wait > m.queueMax {
+		return ui.Dim.Render("(") + ui.Yellow.Render(queued) + ui.Dim.Render(", ran "+ran+")")
+	}
+	return ui.Dim.Render("(" + queued + ", ran " + ran + ")")
+}
+
+// queueRows lists jobs waiting longer than the threshold, then queue time
+// statistics per runner label set and per workflow.
+func (m Model) queueRows() []string {
+	t := m.tabs[m.activeTab]
+	if len(t.queueDetails) == 0 {
+		return nil
+	}
+	q := stats.QueueTimes(t.queueDetails, m.queueMax, time.Now())
+
+	var rows []string
+	if len(q.Waiting) > 0 {
+		rows = append(rows, ui.Red.Render(fmt.Sprintf("  Waiting longer than %s:", format.DurationOf(m.queueMax))))
+		for _, w := range q.Waiting {
+			rows = append(rows, fmt.Sprintf("  %s  %s %s  %s",
+				ui.Red.Render(format.Pad(format.DurationOf(w.Waited), 9)),
+				ui.Blue.Render(w.Run.WorkflowName),
+				ui.Bold.Render(w.Job.Name),
+				ui.Dim.Render(stats.LabelsKey(w.Job)),
+			))
+		}
+		rows = append(rows, "")
+	}
+
+	table := func(title string, groups []stats.QueueRow) {
+		if len(groups) == 0 {
+			return
+		}
+		nameWidth := len(title)
+		for _, g := range groups {
+			nameWidth = max(nameWidth, len(g.Name))
+		}
+		nameWidth = min(nameWidth, 32)
+		rows = append(rows, ui.Dim.Render(fmt.Sprintf("  %s %5s %7s %7s %7s",
+			format.Pad(title, nameWidth), "JOBS", "P50", "P90", "MAX")))
+		for _, g := range groups {
+			style := ui.Dim
+			if g.P90 > m.queueMax {
+				style = ui.Yellow
+			}
+			rows = append(rows, fmt.Sprintf("  %s %5d %7s %s %7s",
+				format.Pad(format.Truncate(g.Name, nameWidth), nameWidth),
+				g.Jobs,
+				format.Short(g.Median),
+				style.Render(fmt.Sprintf("%7s", format.Short(g.P90))),
+				format.Short(g.Max),
+			))
+		}
+		rows = append(rows, "")
+	}
+	table("RUNNER LABELS", q.ByLabels)
+	table("WORKFLOW", q.ByWorkflow)
+	if len(rows) == 0 {
+		return nil
+	}
+	return rows[:len(rows)-1]
+}
+
+func (m Model) queueView() string {
+	t := m.tabs[m.activeTab]
+	title := ui.Bold.Render("Queue times")
+	switch {
+	case t.queueLoading:
+		title += ui.Dim.Render(" (loading jobs...)")
+	case t.queueError != "":
+		title += ui.Red.Render(" (" + t.queueError + ")")
+	}
+	lines := []string{title, ""}
+
+	rows := m.queueRows()
+	if len(rows) == 0 {
+		if t.queueLoading {
+			lines = append(lines, ui.Dim.Render("Fetching jobs of recent runs..."))
+		} else {
+			lines = append(lines, ui.Dim.Render("No jobs with queue times yet."))
+		}
+	} else {
+		lines = append(lines, t.scroll.render(rows, m.scrollHeight()))
+	}
+	return strings.Join(lines, "\n")
+}
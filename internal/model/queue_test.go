@@ -0,0 +1,36 @@
+package model
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/dzoba/github-actions-watcher/internal/types"
+)
+
+func TestJobTiming(t *testing.T) {
+	m := New(Options{QueueThreshold: 5 * time.Minute})
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	at := func(ago time.Duration) string { return now.Add(-ago).Format(time.RFC3339) }
+
+	ran := types.Job{
+		Status:      types.StatusCompleted,
+		CreatedAt:   at(10 * time.Minute),
+		StartedAt:   at(8 * time.Minute),
+		CompletedAt: at(5 * time.Minute),
+	}
+	if got := m.jobTiming(ran, now); !strings.Contains(got, "queued 2m 0s, ran 3m 0s") {
+		t.Errorf("jobTiming(ran) = %q", got)
+	}
+
+	waiting := types.Job{Status: types.StatusQueued, CreatedAt: at(7 * time.Minute)}
+	if got := m.jobTiming(waiting, now); !strings.Contains(got, "waiting 7m 0s") {
+		t.Errorf("jobTiming(waiting) = %q", got)
+	}
+
+	// Without the REST metadata only the run time is known.
+	ran.CreatedAt = ""
+	if got := m.jobTiming(ran, now); !strings.Contains(got, "(3m 0s)") {
+		t.Errorf("jobTiming without created_at = %q", got)
+	}
+}
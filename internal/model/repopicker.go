@@ -31,7 +31,10 @@ func (m Model) pickerView() string {
 				b.WriteString("\n")
 			}
 		} else {
+			rows := make([]string, 0, len(filtered))
 			for i, repo := range filtered {
+				var b strings.Builder
+
 				// Selector
 				if i == m.pickerSelected {
 					b.WriteString("> ")
@@ -54,12 +57,15 @@ func (m Model) pickerView() string {
 					b.WriteString(ui.Dim.Render(format.RelativeTime(repo.PushedAt)))
 				}
 
-				b.WriteByte('\n')
+				rows = append(rows, b.String())
 			}
+			h := m.pickerHeight()
+			b.WriteString(m.pickerScroll.follow(m.pickerSelected, len(rows), h).render(rows, h))
+			b.WriteByte('\n')
 		}
 	}
 
 	b.WriteString("\n")
-	b.WriteString(ui.Dim.Render("up/down: navigate | enter: select | x: remove tab | esc: cancel"))
+	b.WriteString(ui.Dim.Render("up/down/pgup/pgdn: navigate | enter: select | x: remove tab | esc: cancel"))
 	return b.String()
 }
@@ -0,0 +1,305 @@
+package model
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	"github.com/dzoba/github-actions-watcher/internal/format"
+	"github.com/dzoba/github-actions-watcher/internal/gh"
+	"github.com/dzoba/github-actions-watcher/internal/types"
+)
+
+// orgPollMax caps how many repos are fetched per poll in org mode, which
+// also bounds how many gh processes run at once.
+const orgPollMax = 20
+
+// pollBudget is how many run lists can be fetched per poll without running
+// out of rate limit before it resets, leaving half of what remains for
+// everything else. Without a known rate limit it is orgPollMax.
+func pollBudget(rl *gh.RateLimit, interval time.Duration, now time.Time) int {
+	if rl == nil {
+		return orgPollMax
+	}
+	if interval <= 0 {
+		interval = time.Second
+	}
+	polls := int(time.Unix(rl.Reset, 0).Sub(now) / interval)
+	if polls < 1 {
+		polls = 1
+	}
+	n := rl.Remaining / 2 / polls
+	if n < 1 && rl.Remaining > 0 {
+		n = 1
+	}
+	return min(n, orgPollMax)
+}
+
+// active reports whether a tab has runs in progress or queued.
+func (t repoTab) active() bool {
+	for _, run := range t.runs {
+		if statusRank(run) <= 1 {
+			return true
+		}
+	}
+	return false
+}
+
+// scheduledTabs returns up to budget tabs to fetch, most urgent first: tabs
+// never fetched, the active tab, tabs with runs in progress or queued, then
+// the rest. Within each group the longest unfetched go first. Tabs backing
+// off at now are left out; a zero now leaves none out, for refreshes the user
+// asked for.
+func (m Model) scheduledTabs(budget int, now time.Time) []int {
+	urgency := func(i int) int {
+		t := m.tabs[i]
+		switch {
+		case t.fetchedAt.IsZero():
+			return 0
+		case i == m.activeTab && !m.showOverview:
+			return 1
+		case t.active():
+			return 2
+		}
+		return 3
+	}
+	order := make([]int, 0, len(m.tabs))
+	for i, t := range m.tabs {
+		if now.IsZero() || !m.backingOff(t, now) {
+			order = append(order, i)
+		}
+	}
+	sort.SliceStable(order, func(a, b int) bool {
+		ua, ub := urgency(order[a]), urgency(order[b])
+		if ua != ub {
+			return ua < ub
+		}
+		return m.tabs[order[a]].fetchedAt.Before(m.tabs[order[b]].fetchedAt)
+	})
+	if len(order) > budget {
+		order = order[:budget]
+	}
+	return order
+}
+
+// Adaptive polling. The interval the user asks for is used while runs are in
+// progress; otherwise polls slow down, and they back off on errors and when
+// the rate limit runs low.
+const (
+	quietFactor     = 3               // nothing running or queued
+	idleFactor      = 2               // and nobody at the keyboard
+	idleAfter       = 5 * time.Minute // without a key press
+	maxBackoff      = 5               // doublings while a tab is failing
+	maxPollInterval = 5 * time.Minute
+)
+
+// retryAt returns when a tab whose run list fetches keep failing is fetched
+// again: the interval doubles with each failure in a row. It is the zero
+// time for tabs that aren't failing.
+func (m Model) retryAt(t repoTab) time.Time {
+	if t.failures == 0 {
+		return time.Time{}
+	}
+	return t.fetchedAt.Add(min(m.interval<<min(t.failures, maxBackoff), maxPollInterval))
+}
+
+// backingOff reports whether polls at now skip a failing tab. fetchedAt is
+// when the last fetch came back rather than when it started, so a poll
+// within a second of the retry time fetches it.
+func (m Model) backingOff(t repoTab, now time.Time) bool {
+	return m.retryAt(t).Sub(now) > time.Second
+}
+
+// pollCost is how many requests the next poll makes, at least. Details
+// cost one more while jobs are being added to the run shown.
+func (m Model) pollCost() int {
+	if _, ok := m.backend.(gh.Batcher); ok {
+		return 1
+	}
+	n := len(m.tabs)
+	if m.org != "" {
+		n = min(n, pollBudget(m.rateLimit, m.pollEvery, time.Now()))
+	}
+	if len(m.tabs) > 0 && m.tabs[m.activeTab].showsRun() {
+		n++
+	}
+	return n
+}
+
+// nextInterval returns the time until the next poll and why it differs from
+// the configured interval, if it does.
+func (m Model) nextInterval(now time.Time) (time.Duration, string) {
+	d, why := m.interval, ""
+
+	// Each failing tab backs off on its own; polls only wait longer while
+	// all of them do, until the first is due again.
+	active, failing := false, len(m.tabs) > 0
+	var retry time.Time
+	for _, t := range m.tabs {
+		active = active || t.active()
+		if t.failures == 0 {
+			failing = false
+		} else if at := m.retryAt(t); retry.IsZero() || at.Before(retry) {
+			retry = at
+		}
+	}
+	switch {
+	case failing:
+		d, why = max(retry.Sub(now), m.interval), "backing off"
+	case !active && now.Sub(m.lastInput) >= idleAfter:
+		d, why = m.interval*quietFactor*idleFactor, "idle"
+	case !active:
+		d, why = m.interval*quietFactor, "nothing running"
+	}
+
+	// Spread half of the remaining rate limit over the time until it resets.
+	if rl := m.rateLimit; rl != nil {
+		untilReset := time.Unix(rl.Reset, 0).Sub(now)
+		if untilReset > 0 {
+			var floor time.Duration
+			if polls := rl.Remaining / 2 / max(m.pollCost(), 1); polls > 0 {
+				floor = untilReset / time.Duration(polls)
+			} else {
+				floor = untilReset
+			}
+			if floor > d {
+				d, why = floor, "rate limit low"
+			}
+		}
+	}
+	if d > maxPollInterval {
+		d = maxPollInterval
+	}
+	return d, why
+}
+
+// orgPoll fetches the run lists of the tabs the rate limit allows this poll.
+// now is as for scheduledTabs.
+func (m Model) orgPoll(now time.Time) []tea.Cmd {
+	var cmds []tea.Cmd
+	if m.offline {
+		// Reading the history costs no requests; load every tab at once.
+		for _, t := range m.tabs {
+			cmds = append(cmds, m.fetchRuns(t.repo, t.id))
+		}
+		return cmds
+	}
+	for _, i := range m.scheduledTabs(pollBudget(m.rateLimit, m.pollEvery, time.Now()), now) {
+		cmds = append(cmds, m.fetchRuns(m.tabs[i].repo, m.tabs[i].id))
+	}
+	return cmds
+}
+
+// batchMsg carries the results of a batched poll, as the messages separate
+// fetches would have sent.
+type batchMsg struct{ msgs []tea.Msg }
+
+// fetchBatch polls every tab not backing off at now, or in org mode those
+// the rate limit allows, and the run the active tab shows, in one request.
+func (m Model) fetchBatch(b gh.Batcher, now time.Time) tea.Cmd {
+	var idx []int
+	for i, t := range m.tabs {
+		if !m.backingOff(t, now) {
+			idx = append(idx, i)
+		}
+	}
+	if m.org != "" {
+		idx = m.scheduledTabs(pollBudget(m.rateLimit, m.pollEvery, now), now)
+	}
+	repos := make([]string, len(idx))
+	ids := make([]int, len(idx))
+	for i, j := range idx {
+		repos[i], ids[i] = m.tabs[j].repo, m.tabs[j].id
+	}
+	var detail *gh.RunRef
+	detailTab := 0
+	if len(m.tabs) > 0 && m.tabs[m.activeTab].showsRun() && m.tabs[m.activeTab].selectedRunID != 0 {
+		t := m.tabs[m.activeTab]
+		detail = &gh.RunRef{Repo: t.repo, RunID: t.selectedRunID}
+		if t.detail != nil && t.detail.DatabaseID == t.selectedRunID {
+			detail.URL = t.detail.URL
+		}
+		detailTab = t.id
+	}
+	if len(repos) == 0 && detail == nil {
+		return nil
+	}
+	st := m.store
+	return m.fetch(fetchKey{}, func(ctx context.Context) tea.Msg {
+		res, err := b.FetchBatch(ctx, repos, detail)
+		var msgs []tea.Msg
+		for i, repo := range repos {
+			rerr := err
+			if rerr == nil {
+				rerr = res.Errors[repo]
+			}
+			if rerr != nil {
+				msgs = append(msgs, runsErrMsg{tab: ids[i], err: rerr})
+				continue
+			}
+			runs := res.Runs[repo]
+			if st != nil {
+				_ = st.RecordRuns(repo, runs)
+			}
+			j, _ := json.Marshal(runs)
+			msgs = append(msgs, runsMsg{tab: ids[i], runs: runs, json: string(j)})
+		}
+		if detail != nil {
+			if err == nil {
+				err = res.DetailErr
+			}
+			if err != nil {
+				msgs = append(msgs, detailErrMsg{tab: detailTab, runID: detail.RunID, err: err})
+			} else {
+				if st != nil {
+					_ = st.RecordDetail(detail.Repo, res.Detail)
+				}
+				j, _ := json.Marshal(res.Detail)
+				msgs = append(msgs, detailMsg{tab: detailTab, runID: detail.RunID, detail: res.Detail, json: string(j)})
+			}
+		}
+		return batchMsg{msgs}
+	})
+}
+
+type orgReposMsg struct{ repos []types.OrgRepo }
+type rateLimitMsg struct{ rateLimit *gh.RateLimit }
+
+// fetchOrgRepos finds the repos of the org pushed to within active.
+func fetchOrgRepos(org string, active time.Duration) tea.Cmd {
+	return func() tea.Msg {
+		repos, err := gh.FetchOrgRepos(context.Background(), org)
+		if err != nil {
+			return repoErrorMsg{err}
+		}
+		since := time.Now().Add(-active)
+		var recent []types.OrgRepo
+		for _, r := range repos {
+			if pushed, err := time.Parse(time.RFC3339, r.PushedAt); err == nil && pushed.After(since) {
+				recent = append(recent, r)
+			}
+		}
+		if len(recent) == 0 {
+			return repoErrorMsg{fmt.Errorf("no repos in %s were pushed to in the last %s", org, format.DurationOf(active))}
+		}
+		return orgReposMsg{recent}
+	}
+}
+
+func (m Model) fetchRateLimit() tea.Cmd {
+	if m.offline {
+		return nil
+	}
+	b := m.backend
+	return func() tea.Msg {
+		rl, err := b.FetchRateLimit(context.Background())
+		if err != nil {
+			return nil
+		}
+		return rateLimitMsg{rl}
+	}
+}
@@ -0,0 +1,137 @@
+package model
+
+import (
+	"testing"
+	"time"
+
+	"github.com/dzoba/github-actions-watcher/internal/gh"
+	"github.com/dzoba/github-actions-watcher/internal/types"
+)
+
+func TestPollBudget(t *testing.T) {
+	now := time.Unix(1_000_000, 0)
+	tests := []struct {
+		name string
+		rl   *gh.RateLimit
+		want int
+	}{
+		{"unknown", nil, orgPollMax},
+		{"plenty", &gh.RateLimit{Remaining: 5000, Reset: now.Add(10 * time.Minute).Unix()}, orgPollMax},
+		// 60 polls left before the reset, half of 600 spread over them.
+		{"tight", &gh.RateLimit{Remaining: 600, Reset: now.Add(10 * time.Minute).Unix()}, 5},
+		{"nearly out", &gh.RateLimit{Remaining: 3, Reset: now.Add(time.Hour).Unix()}, 1},
+		{"out", &gh.RateLimit{Remaining: 0, Reset: now.Add(time.Hour).Unix()}, 0},
+		{"resetting", &gh.RateLimit{Remaining: 40, Reset: now.Unix()}, orgPollMax},
+	}
+	for _, tt := range tests {
+		if got := pollBudget(tt.rl, 10*time.Second, now); got != tt.want {
+			t.Errorf("%s: pollBudget() = %d, want %d", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestScheduledTabs(t *testing.T) {
+	now := time.Now()
+	running := []types.WorkflowRun{{Status: types.StatusInProgress}}
+	done := []types.WorkflowRun{{Status: types.StatusCompleted}}
+	m := New(Options{})
+	m.tabs = []repoTab{
+		{repo: "idle-recent", runs: done, fetchedAt: now.Add(-time.Minute)},
+		{repo: "idle-old", runs: done, fetchedAt: now.Add(-time.Hour)},
+		{repo: "running", runs: running, fetchedAt: now},
+		{repo: "new"},
+		{repo: "viewed", runs: done, fetchedAt: now},
+		{repo: "failing", failures: 2, fetchedAt: now.Add(-time.Second)},
+	}
+	m.activeTab = 4
+
+	var got []string
+	for _, i := range m.scheduledTabs(4, now) {
+		got = append(got, m.tabs[i].repo)
+	}
+	want := []string{"new", "viewed", "running", "idle-old"}
+	if len(got) != len(want) {
+		t.Fatalf("scheduledTabs() = %v, want %v", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("scheduledTabs() = %v, want %v", got, want)
+		}
+	}
+	// A refresh the user asked for retries the failing tab too.
+	if got := m.scheduledTabs(10, time.Time{}); len(got) != len(m.tabs) {
+		t.Errorf("scheduledTabs() on refresh = %v, want every tab", got)
+	}
+}
+
+func TestNextInterval(t *testing.T) {
+	now := time.Unix(1_000_000, 0)
+	running := []types.WorkflowRun{{Status: types.StatusInProgress}}
+	done := []types.WorkflowRun{{Status: types.StatusCompleted}}
+	base := func() Model {
+		m := New(Options{Interval: 10 * time.Second})
+		m.lastInput = now
+		m.tabs = []repoTab{{runs: running}, {runs: done}}
+		return m
+	}
+
+	tests := []struct {
+		name    string
+		setup   func(*Model)
+		want    time.Duration
+		wantWhy string
+	}{
+		{"running", func(m *Model) {}, 10 * time.Second, ""},
+		{"quiet", func(m *Model) { m.tabs[0].runs = done }, 30 * time.Second, "nothing running"},
+		{"idle", func(m *Model) {
+			m.tabs[0].runs = done
+			m.lastInput = now.Add(-time.Hour)
+		}, time.Minute, "idle"},
+		{"one tab failing", func(m *Model) { m.tabs[1].failures, m.tabs[1].fetchedAt = 3, now }, 10 * time.Second, ""},
+		// Until the first of them is due again.
+		{"all failing", func(m *Model) {
+			m.tabs[0].failures, m.tabs[0].fetchedAt = 2, now.Add(-10*time.Second)
+			m.tabs[1].failures, m.tabs[1].fetchedAt = 3, now
+		}, 30 * time.Second, "backing off"},
+		{"failing for long", func(m *Model) {
+			m.tabs[0].failures, m.tabs[0].fetchedAt = 9, now
+			m.tabs[1].failures, m.tabs[1].fetchedAt = 9, now
+		}, maxPollInterval, "backing off"},
+		// 2 requests per poll, 40 left: 10 polls over the 20 minutes to the reset.
+		{"rate limit low", func(m *Model) {
+			m.rateLimit = &gh.RateLimit{Remaining: 40, Reset: now.Add(20 * time.Minute).Unix()}
+		}, 2 * time.Minute, "rate limit low"},
+		{"rate limit fine", func(m *Model) {
+			m.rateLimit = &gh.RateLimit{Remaining: 4000, Reset: now.Add(20 * time.Minute).Unix()}
+		}, 10 * time.Second, ""},
+	}
+	for _, tt := range tests {
+		m := base()
+		tt.setup(&m)
+		got, why := m.nextInterval(now)
+		if got != tt.want || why != tt.wantWhy {
+			t.Errorf("%s: nextInterval() = %v %q, want %v %q", tt.name, got, why, tt.want, tt.wantWhy)
+		}
+	}
+}
+
+func TestBackoffPerTab(t *testing.T) {
+	now := time.Unix(1_000_000, 0)
+	m := New(Options{Interval: 10 * time.Second})
+	m.tabs = []repoTab{
+		{repo: "o/ok", fetchedAt: now.Add(-10 * time.Second)},
+		{repo: "o/failing", failures: 2, fetchedAt: now.Add(-10 * time.Second)},
+	}
+
+	// The failing tab waits 40s after its last fetch; the other one is
+	// polled as usual meanwhile.
+	if m.backingOff(m.tabs[0], now) || !m.backingOff(m.tabs[1], now) {
+		t.Errorf("backing off: ok %v, failing %v; want only the failing tab", m.backingOff(m.tabs[0], now), m.backingOff(m.tabs[1], now))
+	}
+	if m.backingOff(m.tabs[1], now.Add(30*time.Second)) {
+		t.Error("failing tab is still skipped once its backoff is over")
+	}
+	if got := m.retryAt(m.tabs[0]); !got.IsZero() {
+		t.Errorf("retryAt() of a tab that isn't failing = %v", got)
+	}
+}
@@ -0,0 +1,81 @@
+package model
+
+import (
+	"strings"
+
+	"github.com/dzoba/github-actions-watcher/internal/types"
+	"github.com/dzoba/github-actions-watcher/internal/ui"
+)
+
+// summaryHeaderLines is the number of lines above the rows of a repo summary
+// view: a title and a blank line.
+const summaryHeaderLines = 2
+
+// scrollRows returns the scrolling rows of the active tab's scroll-only view.
+func (m Model) scrollRows() []string {
+	switch m.tabs[m.activeTab].view {
+	case types.ViewGraph:
+		return m.graphRows()
+	case types.ViewTimeline:
+		return m.timelineRows()
+	case types.ViewWorkflows:
+		return m.workflowsRows()
+	case types.ViewFlaky:
+		return m.flakyRows()
+	case types.ViewStats:
+		return m.statsRows()
+	case types.ViewQueue:
+		return m.queueRows()
+	case types.ViewCompare:
+		return m.compareRows()
+	case types.ViewCost:
+		return m.costRows()
+	}
+	return nil
+}
+
+// scrollHeight is the number of rows visible in a scroll-only view.
+func (m Model) scrollHeight() int {
+	header := detailHeaderLines
+	if !m.tabs[m.activeTab].showsRun() {
+		header = summaryHeaderLines
+	}
+	return rowsHeight(m.bodyHeight(header), len(m.scrollRows()))
+}
+
+func (m Model) scrollViewFull() string {
+	t := m.tabs[m.activeTab]
+	var b strings.Builder
+
+	// Tab bar
+	b.WriteString(m.tabBar())
+
+	// Header
+	b.WriteString(ui.CyanBold.Render("GitHub Actions"))
+	b.WriteString(" - ")
+	b.WriteString(ui.Bold.Render(t.repo))
+	b.WriteString("\n\n")
+
+	switch t.view {
+	case types.ViewTimeline:
+		b.WriteString(m.timelineView())
+	case types.ViewWorkflows:
+		b.WriteString(m.workflowsView())
+	case types.ViewFlaky:
+		b.WriteString(m.flakyView())
+	case types.ViewStats:
+		b.WriteString(m.statsView())
+	case types.ViewQueue:
+		b.WriteString(m.queueView())
+	case types.ViewCompare:
+		b.WriteString(m.compareView())
+	case types.ViewCost:
+		b.WriteString(m.costView())
+	default:
+		b.WriteString(m.graphView())
+	}
+
+	b.WriteString("\n")
+	b.WriteString(m.footerView())
+	return b.String()
+}
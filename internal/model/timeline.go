@@ -0,0 +1,162 @@
+package model
+
+import (
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/lipgloss"
+
+	"github.com/dzoba/github-actions-watcher/internal/format"
+	"github.com/dzoba/github-actions-watcher/internal/timeline"
+	"github.com/dzoba/github-actions-watcher/internal/ui"
+)
+
+// timelineDeps maps the run's jobs onto the needs of the workflow graph, if
+// it has been loaded for this run. Without it the timeline infers them.
+func (t repoTab) timelineDeps() [][]int {
+	if t.graph == nil || t.graphRunID != t.selectedRunID {
+		return nil
+	}
+	specOf := make([]int, len(t.detail.Jobs))
+	for i, job := range t.detail.Jobs {
+		specOf[i] = -1
+		for s, spec := range t.graph.Jobs {
+			if spec.Matches(job.Name) {
+				specOf[i] = s
+				break
+			}
+		}
+	}
+	deps := make([][]int, len(t.detail.Jobs))
+	for i := range t.detail.Jobs {
+		if specOf[i] < 0 {
+			continue
+		}
+		for _, need := range t.graph.Jobs[specOf[i]].Needs {
+			for j := range t.detail.Jobs {
+				if specOf[j] >= 0 && t.graph.Jobs[specOf[j]].ID == need {
+					deps[i] = append(deps[i], j)
+				}
+			}
+		}
+	}
+	return deps
+}
+
+// timelineRows draws a bar per job and step of the active tab's run on a
+// shared time axis scaled to the terminal width. Time spent queued is shaded
+// and jobs on the critical path are marked.
+func (m Model) timelineRows() []string {
+	t := m.tabs[m.activeTab]
+	if t.detail == nil || len(t.detail.Jobs) == 0 {
+		return nil
+	}
+	tl := timeline.Build(t.detail, t.timelineDeps(), time.Now())
+
+	cols := m.width
+	if cols == 0 {
+		cols = 120
+	}
+	nameWidth := 8
+	for _, job := range tl.Jobs {
+		nameWidth = max(nameWidth, len(job.Name))
+		for _, step := range job.Steps {
+			nameWidth = max(nameWidth, len(step.Name)+2)
+		}
+	}
+	nameWidth = min(nameWidth, 28)
+	// marker + name + space ... space + duration and queue time
+	barWidth := max(cols-2-nameWidth-1-1-18, 10)
+
+	var rows []string
+	rows = append(rows,
+		ui.Dim.Render(format.Pad("", 2+nameWidth+1)+timelineAxis(tl.Total, barWidth)),
+		"",
+	)
+	for _, job := range tl.Jobs {
+		var b strings.Builder
+		if job.Critical {
+			b.WriteString(ui.Yellow.Render("◆ "))
+		} else {
+			b.WriteString("  ")
+		}
+		b.WriteString(ui.Bold.Render(format.Pad(format.Truncate(job.Name, nameWidth), nameWidth)))
+		b.WriteByte(' ')
+		b.WriteString(timelineBar(tl, job, barWidth, "█"))
+		if job.Started {
+			b.WriteString(" " + format.DurationOf(job.End-job.Start))
+			if q := job.Queue(); q >= time.Second {
+				b.WriteString(ui.Dim.Render(" +" + format.DurationOf(q) + " queued"))
+			}
+		}
+		rows = append(rows, b.String())
+
+		for _, step := range job.Steps {
+			var b strings.Builder
+			b.WriteString("    ")
+			b.WriteString(ui.Dim.Render(format.Pad(format.Truncate(step.Name, nameWidth-2), nameWidth-2)))
+			b.WriteByte(' ')
+			b.WriteString(timelineBar(tl, step, barWidth, "▬"))
+			if step.Started {
+				b.WriteString(ui.Dim.Render(" " + format.DurationOf(step.End-step.Start)))
+			}
+			rows = append(rows, b.String())
+		}
+	}
+	rows = append(rows, "", ui.Dim.Render("◆ critical path  ░ queued  █ running/ran"))
+	return rows
+}
+
+// timelineAxis labels the start, middle and end of the time axis.
+func timelineAxis(total time.Duration, width int) string {
+	axis := []byte(strings.Repeat(" ", width))
+	put := func(col int, label string) {
+		col = max(0, min(col, width-len(label)))
+		copy(axis[col:], label)
+	}
+	put(0, "0s")
+	if width >= 30 {
+		mid := format.DurationOf(total / 2)
+		put(width/2-len(mid)/2, mid)
+	}
+	put(width, format.DurationOf(total))
+	return string(axis)
+}
+
+// timelineBar draws a span as queue shading followed by a bar colored by its
+// status, fill being the bar character.
+func timelineBar(tl timeline.Timeline, s timeline.Span, width int, fill string) string {
+	if !s.Started {
+		return strings.Repeat(" ", width)
+	}
+	ready, start, end := tl.Col(s.Ready, width), tl.Col(s.Start, width), tl.Col(s.End, width)
+	if s.Queue() == 0 {
+		ready = start
+	}
+	_, color := format.StatusBadge(s.Status, s.Conclusion)
+	style := ui.BadgeStyle(color)
+	if s.Status == "" {
+		style = lipgloss.NewStyle()
+	}
+
+	var b strings.Builder
+	b.WriteString(strings.Repeat(" ", ready))
+	b.WriteString(ui.Dim.Render(strings.Repeat("░", start-ready)))
+	b.WriteString(style.Render(strings.Repeat(fill, end-start+1)))
+	b.WriteString(strings.Repeat(" ", width-end-1))
+	return b.String()
+}
+
+func (m Model) timelineView() string {
+	t := m.tabs[m.activeTab]
+	if t.detail == nil {
+		return ui.Dim.Render("Loading run details...")
+	}
+	lines := m.runHeader()
+	if len(t.detail.Jobs) == 0 {
+		lines = append(lines, ui.Dim.Render("No jobs yet."))
+	} else {
+		lines = append(lines, t.scroll.render(m.timelineRows(), m.scrollHeight()))
+	}
+	return strings.Join(lines, "\n")
+}
@@ -0,0 +1,101 @@
+package model
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/dzoba/github-actions-watcher/internal/ui"
+)
+
+// viewport is a vertical window over a slice of rendered rows. offset is the
+// index of the first visible row. A height of 0 means the terminal size is not
+// known yet and every row is shown.
+type viewport struct {
+	offset int
+}
+
+// rowsHeight returns how many content rows fit in avail lines when total rows
+// need to be shown, reserving one line for the scroll indicator on overflow.
+func rowsHeight(avail, total int) int {
+	if avail <= 0 {
+		return 0
+	}
+	if total > avail {
+		if avail > 1 {
+			return avail - 1
+		}
+		return 1
+	}
+	return avail
+}
+
+// clamp keeps the offset within the scrollable range.
+func (v viewport) clamp(total, height int) viewport {
+	if height <= 0 {
+		v.offset = 0
+		return v
+	}
+	maxOffset := total - height
+	if maxOffset < 0 {
+		maxOffset = 0
+	}
+	if v.offset > maxOffset {
+		v.offset = maxOffset
+	}
+	if v.offset < 0 {
+		v.offset = 0
+	}
+	return v
+}
+
+// follow scrolls the minimum amount needed to keep cursor on screen.
+func (v viewport) follow(cursor, total, height int) viewport {
+	if height > 0 {
+		if cursor < v.offset {
+			v.offset = cursor
+		} else if cursor >= v.offset+height {
+			v.offset = cursor - height + 1
+		}
+	}
+	return v.clamp(total, height)
+}
+
+// scrollBy moves the window by delta rows.
+func (v viewport) scrollBy(delta, total, height int) viewport {
+	v.offset += delta
+	return v.clamp(total, height)
+}
+
+// render returns the visible rows, followed by a position indicator when
+// the rows do not all fit.
+func (v viewport) render(rows []string, height int) string {
+	if height <= 0 || len(rows) <= height {
+		return strings.Join(rows, "\n")
+	}
+	v = v.clamp(len(rows), height)
+	end := v.offset + height
+	visible := rows[v.offset:end]
+	return strings.Join(visible, "\n") + "\n" + scrollIndicator(v.offset, end, len(rows))
+}
+
+func scrollIndicator(start, end, total int) string {
+	up, down := " ", " "
+	if start > 0 {
+		up = "↑"
+	}
+	if end < total {
+		down = "↓"
+	}
+	return ui.Dim.Render(fmt.Sprintf("%s%s %d-%d of %d", up, down, start+1, end, total))
+}
+
+// clampCursor keeps a cursor within [0, total).
+func clampCursor(cursor, total int) int {
+	if cursor >= total {
+		cursor = total - 1
+	}
+	if cursor < 0 {
+		cursor = 0
+	}
+	return cursor
+}
@@ -0,0 +1,53 @@
+package model
+
+import "testing"
+
+func TestViewportFollow(t *testing.T) {
+	tests := []struct {
+		name   string
+		offset int
+		cursor int
+		total  int
+		height int
+		want   int
+	}{
+		{"visible", 0, 3, 20, 5, 0},
+		{"below", 0, 7, 20, 5, 3},
+		{"above", 10, 4, 20, 5, 4},
+		{"clamped to end", 18, 19, 20, 5, 15},
+		{"fits", 3, 2, 4, 5, 0},
+		{"unbounded", 5, 9, 20, 0, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := viewport{offset: tt.offset}.follow(tt.cursor, tt.total, tt.height).offset
+			if got != tt.want {
+				t.Errorf("follow() offset = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestViewportScrollBy(t *testing.T) {
+	v := viewport{}
+	v = v.scrollBy(-3, 20, 5)
+	if v.offset != 0 {
+		t.Errorf("scroll above top: offset = %d, want 0", v.offset)
+	}
+	v = v.scrollBy(100, 20, 5)
+	if v.offset != 15 {
+		t.Errorf("scroll past end: offset = %d, want 15", v.offset)
+	}
+}
+
+func TestRowsHeight(t *testing.T) {
+	if got := rowsHeight(10, 5); got != 10 {
+		t.Errorf("rowsHeight(10, 5) = %d, want 10", got)
+	}
+	if got := rowsHeight(10, 30); got != 9 {
+		t.Errorf("rowsHeight(10, 30) = %d, want 9", got)
+	}
+	if got := rowsHeight(0, 30); got != 0 {
+		t.Errorf("rowsHeight(0, 30) = %d, want 0", got)
+	}
+}
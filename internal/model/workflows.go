@@ -0,0 +1,115 @@
+package model
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/dzoba/github-actions-watcher/internal/format"
+	"github.com/dzoba/github-actions-watcher/internal/stats"
+	"github.com/dzoba/github-actions-watcher/internal/types"
+	"github.com/dzoba/github-actions-watcher/internal/ui"
+)
+
+// sparklineRuns is how many recent durations a sparkline shows.
+const sparklineRuns = 20
+
+// allRuns returns the tab's current runs followed by its older history.
+// Runs may repeat; the stats helpers count each run once.
+func (t repoTab) allRuns() []types.WorkflowRun {
+	runs := make([]types.WorkflowRun, 0, len(t.runs)+len(t.history))
+	runs = append(runs, t.runs...)
+	return append(runs, t.history...)
+}
+
+// durationsByWorkflow summarizes the tab's finished runs per workflow.
+func (t repoTab) durationsByWorkflow() map[string]stats.Durations {
+	out := make(map[string]stats.Durations)
+	for _, d := range stats.WorkflowDurations(t.allRuns(), sparklineRuns) {
+		out[d.Workflow] = d
+	}
+	return out
+}
+
+// workflowsRows draws a row per workflow with its run count, median and p90
+// durations, the last duration and a sparkline of recent durations.
+func (m Model) workflowsRows() []string {
+	t := m.tabs[m.activeTab]
+	ds := stats.WorkflowDurations(t.allRuns(), sparklineRuns)
+	if len(ds) == 0 {
+		return nil
+	}
+
+	cols := m.width
+	if cols == 0 {
+		cols = 120
+	}
+	nameWidth := 8
+	for _, d := range ds {
+		nameWidth = max(nameWidth, len(d.Workflow))
+	}
+	// name, then runs, p50, p90, last and the sparkline
+	nameWidth = min(nameWidth, max(cols-2-6-8*3-sparklineRuns-1, 12))
+
+	rows := []string{
+		ui.Dim.Render(fmt.Sprintf("  %s %5s %7s %7s %7s  %s",
+			format.Pad("WORKFLOW", nameWidth), "RUNS", "P50", "P90", "LAST", "RECENT")),
+	}
+	for _, d := range ds {
+		last := d.Recent[len(d.Recent)-1]
+		values := make([]float64, len(d.Recent))
+		for i, r := range d.Recent {
+			values[i] = r.Seconds()
+		}
+		lastStyle := ui.Dim
+		if last > d.P90 {
+			lastStyle = ui.Yellow
+		}
+		rows = append(rows, fmt.Sprintf("  %s %5d %7s %7s %s  %s",
+			ui.Blue.Render(format.Pad(format.Truncate(d.Workflow, nameWidth), nameWidth)),
+			d.Runs,
+			format.Short(d.Median),
+			format.Short(d.P90),
+			lastStyle.Render(fmt.Sprintf("%7s", format.Short(last))),
+			ui.Green.Render(format.Sparkline(values)),
+		))
+	}
+	return rows
+}
+
+// trend describes how an in-progress run compares to its workflow's median,
+// e.g. "+3m vs p50", or "" if it is within it or there is no history.
+func trend(run types.WorkflowRun, d stats.Durations, now time.Time) string {
+	if run.Status != types.StatusInProgress || d.Runs == 0 {
+		return ""
+	}
+	elapsed, ok := stats.Elapsed(run, now)
+	if !ok || elapsed <= d.Median {
+		return ""
+	}
+	return "+" + format.Short(elapsed-d.Median) + " vs p50"
+}
+
+func (m Model) workflowsView() string {
+	t := m.tabs[m.activeTab]
+	title := ui.Bold.Render("Workflow durations")
+	switch {
+	case t.historyLoading:
+		title += ui.Dim.Render(" (loading history...)")
+	case t.historyError != "":
+		title += ui.Red.Render(" (" + t.historyError + ")")
+	}
+	lines := []string{title, ""}
+
+	rows := m.workflowsRows()
+	if len(rows) == 0 {
+		if t.historyLoading {
+			lines = append(lines, ui.Dim.Render("Loading run history..."))
+		} else {
+			lines = append(lines, ui.Dim.Render("No finished runs yet."))
+		}
+	} else {
+		lines = append(lines, t.scroll.render(rows, m.scrollHeight()))
+	}
+	return strings.Join(lines, "\n")
+}
@@ -0,0 +1,31 @@
+package model
+
+import (
+	"testing"
+	"time"
+
+	"github.com/dzoba/github-actions-watcher/internal/stats"
+	"github.com/dzoba/github-actions-watcher/internal/types"
+)
+
+func TestTrend(t *testing.T) {
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	d := stats.Durations{Workflow: "CI", Runs: 5, Median: 5 * time.Minute}
+	run := types.WorkflowRun{
+		WorkflowName: "CI",
+		Status:       types.StatusInProgress,
+		StartedAt:    now.Add(-8 * time.Minute).Format(time.RFC3339),
+	}
+	if got := trend(run, d, now); got != "+3m vs p50" {
+		t.Errorf("trend = %q, want %q", got, "+3m vs p50")
+	}
+
+	run.StartedAt = now.Add(-4 * time.Minute).Format(time.RFC3339)
+	if got := trend(run, d, now); got != "" {
+		t.Errorf("trend within median = %q, want empty", got)
+	}
+
+	if got := trend(run, stats.Durations{}, now); got != "" {
+		t.Errorf("trend without history = %q, want empty", got)
+	}
+}
@@ -0,0 +1,61 @@
+package otlp
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// Exporter sends traces to an OTLP/HTTP endpoint.
+type Exporter struct {
+	// Endpoint is the collector's base URL, e.g. http://localhost:4318, or
+	// its full traces URL ending in /v1/traces.
+	Endpoint string
+	// Headers are added to every request, e.g. for authentication.
+	Headers map[string]string
+	// Client defaults to a client with a 10s timeout.
+	Client *http.Client
+}
+
+// URL returns where traces are posted.
+func (e *Exporter) URL() string {
+	if strings.HasSuffix(e.Endpoint, "/v1/traces") {
+		return e.Endpoint
+	}
+	return strings.TrimRight(e.Endpoint, "/") + "/v1/traces"
+}
+
+// Export posts one export request.
+func (e *Exporter) Export(ctx context.Context, req ExportRequest) error {
+	body, err := json.Marshal(req)
+	if err != nil {
+		return fmt.Errorf("failed to encode traces: %w", err)
+	}
+	r, err := http.NewRequestWithContext(ctx, http.MethodPost, e.URL(), bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	r.Header.Set("Content-Type", "application/json")
+	for k, v := range e.Headers {
+		r.Header.Set(k, v)
+	}
+	client := e.Client
+	if client == nil {
+		client = &http.Client{Timeout: 10 * time.Second}
+	}
+	resp, err := client.Do(r)
+	if err != nil {
+		return fmt.Errorf("trace export failed: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode/100 != 2 {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("trace export failed: %s: %s", resp.Status, strings.TrimSpace(string(msg)))
+	}
+	return nil
+}
@@ -0,0 +1,241 @@
+// Package otlp converts workflow runs into OpenTelemetry traces and exports
+// them over OTLP/HTTP with JSON encoding: the run is the root span, its jobs
+// are children and their steps grandchildren.
+//
+// Only the small part of the OTLP data model traces need is defined here,
+// which keeps the OpenTelemetry SDK out of the binary.
+package otlp
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/dzoba/github-actions-watcher/internal/types"
+)
+
+// ExportRequest is the body of an OTLP/HTTP trace export.
+type ExportRequest struct {
+	ResourceSpans []ResourceSpans `json:"resourceSpans"`
+}
+
+type ResourceSpans struct {
+	Resource   Resource     `json:"resource"`
+	ScopeSpans []ScopeSpans `json:"scopeSpans"`
+}
+
+type Resource struct {
+	Attributes []KeyValue `json:"attributes"`
+}
+
+type ScopeSpans struct {
+	Scope Scope  `json:"scope"`
+	Spans []Span `json:"spans"`
+}
+
+type Scope struct {
+	Name string `json:"name"`
+}
+
+// Span kinds and status codes used here.
+const (
+	SpanKindInternal = 1
+	StatusUnset      = 0
+	StatusOK         = 1
+	StatusError      = 2
+)
+
+type Span struct {
+	TraceID           string     `json:"traceId"`
+	SpanID            string     `json:"spanId"`
+	ParentSpanID      string     `json:"parentSpanId,omitempty"`
+	Name              string     `json:"name"`
+	Kind              int        `json:"kind"`
+	StartTimeUnixNano string     `json:"startTimeUnixNano"`
+	EndTimeUnixNano   string     `json:"endTimeUnixNano"`
+	Attributes        []KeyValue `json:"attributes,omitempty"`
+	Status            Status     `json:"status"`
+}
+
+type Status struct {
+	Code    int    `json:"code,omitempty"`
+	Message string `json:"message,omitempty"`
+}
+
+type KeyValue struct {
+	Key   string   `json:"key"`
+	Value AnyValue `json:"value"`
+}
+
+type AnyValue struct {
+	StringValue *string   `json:"stringValue,omitempty"`
+	IntValue    *string   `json:"intValue,omitempty"` // int64 as a decimal string
+	ArrayValue  *ArrayVal `json:"arrayValue,omitempty"`
+}
+
+type ArrayVal struct {
+	Values []AnyValue `json:"values"`
+}
+
+func str(key, v string) KeyValue { return KeyValue{Key: key, Value: AnyValue{StringValue: &v}} }
+
+func num(key string, v int) KeyValue {
+	s := strconv.Itoa(v)
+	return KeyValue{Key: key, Value: AnyValue{IntValue: &s}}
+}
+
+func strs(key string, vs []string) KeyValue {
+	arr := &ArrayVal{}
+	for _, v := range vs {
+		arr.Values = append(arr.Values, AnyValue{StringValue: &v})
+	}
+	return KeyValue{Key: key, Value: AnyValue{ArrayValue: arr}}
+}
+
+// Attr returns the string form of an attribute's value, for tests and
+// debugging.
+func (kv KeyValue) Attr() string {
+	switch v := kv.Value; {
+	case v.StringValue != nil:
+		return *v.StringValue
+	case v.IntValue != nil:
+		return *v.IntValue
+	case v.ArrayValue != nil:
+		parts := make([]string, len(v.ArrayValue.Values))
+		for i, e := range v.ArrayValue.Values {
+			parts[i] = KeyValue{Value: e}.Attr()
+		}
+		return strings.Join(parts, ",")
+	}
+	return ""
+}
+
+// id derives a stable ID of n bytes from parts, so exporting the same run
+// twice yields the same trace.
+func id(n int, parts ...any) string {
+	sum := sha256.Sum256([]byte(fmt.Sprint(parts...)))
+	return hex.EncodeToString(sum[:n])
+}
+
+// ServiceName is the service.name of exported traces.
+const ServiceName = "github-actions"
+
+// Trace converts a finished run into an export request holding one trace.
+// Jobs and steps that never started are left out.
+func Trace(repo string, d *types.RunDetail) ExportRequest {
+	traceID := id(16, repo, d.DatabaseID, d.Attempt)
+	runSpanID := id(8, repo, d.DatabaseID, d.Attempt, "run")
+
+	runStart := d.StartedAt
+	if runStart == "" {
+		runStart = d.CreatedAt
+	}
+	root := Span{
+		TraceID: traceID,
+		SpanID:  runSpanID,
+		Name:    d.WorkflowName,
+		Kind:    SpanKindInternal,
+		Attributes: []KeyValue{
+			str("cicd.pipeline.name", d.WorkflowName),
+			num("cicd.pipeline.run.id", d.DatabaseID),
+			num("github.run.number", d.Number),
+			num("github.run.attempt", d.Attempt),
+			str("github.event", d.Event),
+			str("github.conclusion", string(d.Conclusion)),
+			str("vcs.repository.ref.name", d.HeadBranch),
+			str("vcs.ref.head.revision", d.HeadSha),
+			str("url.full", d.URL),
+		},
+		Status: status(d.Conclusion),
+	}
+	root.StartTimeUnixNano, root.EndTimeUnixNano = nanos(runStart, d.UpdatedAt)
+	spans := []Span{root}
+
+	for i, job := range d.Jobs {
+		if job.StartedAt == "" {
+			continue
+		}
+		jobSpanID := id(8, repo, d.DatabaseID, d.Attempt, "job", i, job.DatabaseID)
+		js := Span{
+			TraceID:      traceID,
+			SpanID:       jobSpanID,
+			ParentSpanID: runSpanID,
+			Name:         job.Name,
+			Kind:         SpanKindInternal,
+			Attributes: []KeyValue{
+				str("cicd.pipeline.task.name", job.Name),
+				num("cicd.pipeline.task.run.id", job.DatabaseID),
+				str("github.conclusion", string(job.Conclusion)),
+				str("vcs.repository.ref.name", d.HeadBranch),
+				str("url.full", job.URL),
+			},
+			Status: status(job.Conclusion),
+		}
+		if job.RunnerName != "" {
+			js.Attributes = append(js.Attributes, str("github.runner.name", job.RunnerName))
+		}
+		if len(job.Labels) > 0 {
+			js.Attributes = append(js.Attributes, strs("github.runner.labels", job.Labels))
+		}
+		js.StartTimeUnixNano, js.EndTimeUnixNano = nanos(job.StartedAt, job.CompletedAt)
+		spans = append(spans, js)
+
+		for _, step := range job.Steps {
+			if step.StartedAt == "" {
+				continue
+			}
+			ss := Span{
+				TraceID:      traceID,
+				SpanID:       id(8, repo, d.DatabaseID, d.Attempt, "step", i, job.DatabaseID, step.Number),
+				ParentSpanID: jobSpanID,
+				Name:         step.Name,
+				Kind:         SpanKindInternal,
+				Attributes: []KeyValue{
+					num("github.step.number", step.Number),
+					str("github.conclusion", string(step.Conclusion)),
+				},
+				Status: status(step.Conclusion),
+			}
+			ss.StartTimeUnixNano, ss.EndTimeUnixNano = nanos(step.StartedAt, step.CompletedAt)
+			spans = append(spans, ss)
+		}
+	}
+
+	return ExportRequest{ResourceSpans: []ResourceSpans{{
+		Resource: Resource{Attributes: []KeyValue{
+			str("service.name", ServiceName),
+			str("vcs.repository.name", repo),
+		}},
+		ScopeSpans: []ScopeSpans{{
+			Scope: Scope{Name: "ghaw"},
+			Spans: spans,
+		}},
+	}}}
+}
+
+func status(c types.RunConclusion) Status {
+	switch c {
+	case types.ConclusionSuccess:
+		return Status{Code: StatusOK}
+	case types.ConclusionFailure, types.ConclusionTimedOut:
+		return Status{Code: StatusError, Message: string(c)}
+	}
+	return Status{}
+}
+
+// nanos converts start and end timestamps to Unix nanoseconds. A missing or
+// earlier end is taken as the start.
+func nanos(start, end string) (string, string) {
+	s, err := time.Parse(time.RFC3339, start)
+	if err != nil {
+		return "0", "0"
+	}
+	e, err := time.Parse(time.RFC3339, end)
+	if err != nil || e.Before(s) {
+		e = s
+	}
+	return strconv.FormatInt(s.UnixNano(), 10), strconv.FormatInt(e.UnixNano(), 10)
+}
@@ -0,0 +1,118 @@
+package otlp_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/dzoba/github-actions-watcher/internal/otlp"
+	"github.com/dzoba/github-actions-watcher/internal/otlp/otlptest"
+	"github.com/dzoba/github-actions-watcher/internal/types"
+)
+
+func detail() *types.RunDetail {
+	return &types.RunDetail{
+		WorkflowRun: types.WorkflowRun{
+			DatabaseID:   42,
+			WorkflowName: "CI",
+			HeadBranch:   "main",
+			Attempt:      1,
+			Status:       types.StatusCompleted,
+			Conclusion:   types.ConclusionFailure,
+			CreatedAt:    "2026-01-01T10:00:00Z",
+			StartedAt:    "2026-01-01T10:00:00Z",
+			UpdatedAt:    "2026-01-01T10:05:00Z",
+		},
+		Jobs: []types.Job{
+			{
+				Name: "build", DatabaseID: 1, Conclusion: types.ConclusionSuccess,
+				StartedAt: "2026-01-01T10:00:10Z", CompletedAt: "2026-01-01T10:02:00Z",
+				RunnerName: "GitHub Actions 3", Labels: []string{"ubuntu-latest"},
+				Steps: []types.Step{
+					{Name: "checkout", Number: 1, Conclusion: types.ConclusionSuccess, StartedAt: "2026-01-01T10:00:10Z", CompletedAt: "2026-01-01T10:00:20Z"},
+					{Name: "make", Number: 2, Conclusion: types.ConclusionSuccess, StartedAt: "2026-01-01T10:00:20Z", CompletedAt: "2026-01-01T10:02:00Z"},
+				},
+			},
+			{
+				Name: "test", DatabaseID: 2, Conclusion: types.ConclusionFailure,
+				StartedAt: "2026-01-01T10:02:10Z", CompletedAt: "2026-01-01T10:05:00Z",
+				Steps: []types.Step{
+					{Name: "go test", Number: 1, Conclusion: types.ConclusionFailure, StartedAt: "2026-01-01T10:02:10Z", CompletedAt: "2026-01-01T10:05:00Z"},
+				},
+			},
+			{Name: "deploy", DatabaseID: 3, Conclusion: types.ConclusionSkipped},
+		},
+	}
+}
+
+func attrs(s otlp.Span) map[string]string {
+	m := make(map[string]string)
+	for _, kv := range s.Attributes {
+		m[kv.Key] = kv.Attr()
+	}
+	return m
+}
+
+func TestTrace(t *testing.T) {
+	spans := otlp.Trace("o/r", detail()).ResourceSpans[0].ScopeSpans[0].Spans
+	if len(spans) != 6 {
+		t.Fatalf("got %d spans, want run + 2 jobs + 3 steps", len(spans))
+	}
+	byName := make(map[string]otlp.Span)
+	for _, s := range spans {
+		if s.TraceID != spans[0].TraceID {
+			t.Errorf("span %q in trace %s", s.Name, s.TraceID)
+		}
+		byName[s.Name] = s
+	}
+
+	run := byName["CI"]
+	if run.ParentSpanID != "" || run.Status.Code != otlp.StatusError {
+		t.Errorf("run span = %+v", run)
+	}
+	if a := attrs(run); a["github.conclusion"] != "failure" || a["vcs.repository.ref.name"] != "main" {
+		t.Errorf("run attributes = %v", a)
+	}
+	if run.StartTimeUnixNano != "1767261600000000000" || run.EndTimeUnixNano != "1767261900000000000" {
+		t.Errorf("run times = %s..%s", run.StartTimeUnixNano, run.EndTimeUnixNano)
+	}
+
+	build := byName["build"]
+	if build.ParentSpanID != run.SpanID || build.Status.Code != otlp.StatusOK {
+		t.Errorf("build span = %+v", build)
+	}
+	if a := attrs(build); a["github.runner.name"] != "GitHub Actions 3" || a["github.runner.labels"] != "ubuntu-latest" {
+		t.Errorf("build attributes = %v", a)
+	}
+	if byName["make"].ParentSpanID != build.SpanID || byName["go test"].ParentSpanID != byName["test"].SpanID {
+		t.Error("steps are not children of their jobs")
+	}
+	if _, ok := byName["deploy"]; ok {
+		t.Error("skipped job exported")
+	}
+
+	again := otlp.Trace("o/r", detail()).ResourceSpans[0].ScopeSpans[0].Spans
+	if again[0].TraceID != run.TraceID || again[0].SpanID != run.SpanID {
+		t.Error("IDs are not stable across exports")
+	}
+}
+
+func TestExport(t *testing.T) {
+	c := otlptest.NewCollector()
+	defer c.Close()
+
+	exp := &otlp.Exporter{Endpoint: c.URL, Headers: map[string]string{"Authorization": "Bearer x"}}
+	if err := exp.Export(context.Background(), otlp.Trace("o/r", detail())); err != nil {
+		t.Fatal(err)
+	}
+	if n := len(c.Spans()); n != 6 {
+		t.Errorf("collector got %d spans, want 6", n)
+	}
+	if h := c.Headers(); len(h) != 1 || h[0].Get("Authorization") != "Bearer x" {
+		t.Errorf("headers = %v", h)
+	}
+
+	bad := &otlp.Exporter{Endpoint: c.URL + "/nowhere"}
+	if err := bad.Export(context.Background(), otlp.Trace("o/r", detail())); err == nil {
+		t.Error("export to a bad path succeeded")
+	}
+}
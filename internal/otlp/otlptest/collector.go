@@ -0,0 +1,77 @@
+// Package otlptest provides an in-process stand-in for an OpenTelemetry
+// collector, to test trace export without running one.
+package otlptest
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+
+	"github.com/dzoba/github-actions-watcher/internal/otlp"
+)
+
+// Collector accepts OTLP/HTTP JSON trace exports and keeps them.
+type Collector struct {
+	*httptest.Server
+
+	mu       sync.Mutex
+	requests []otlp.ExportRequest
+	headers  []http.Header
+}
+
+// NewCollector starts a collector. Close it when done.
+func NewCollector() *Collector {
+	c := &Collector{}
+	c.Server = httptest.NewServer(http.HandlerFunc(c.handle))
+	return c
+}
+
+func (c *Collector) handle(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost || r.URL.Path != "/v1/traces" {
+		http.NotFound(w, r)
+		return
+	}
+	if r.Header.Get("Content-Type") != "application/json" {
+		http.Error(w, "only JSON is supported", http.StatusUnsupportedMediaType)
+		return
+	}
+	var req otlp.ExportRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	c.mu.Lock()
+	c.requests = append(c.requests, req)
+	c.headers = append(c.headers, r.Header.Clone())
+	c.mu.Unlock()
+	w.Header().Set("Content-Type", "application/json")
+	w.Write([]byte("{}"))
+}
+
+// Requests returns the export requests received so far.
+func (c *Collector) Requests() []otlp.ExportRequest {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return append([]otlp.ExportRequest(nil), c.requests...)
+}
+
+// Headers returns the headers of the requests received so far.
+func (c *Collector) Headers() []http.Header {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return append([]http.Header(nil), c.headers...)
+}
+
+// Spans returns every span received so far.
+func (c *Collector) Spans() []otlp.Span {
+	var spans []otlp.Span
+	for _, req := range c.Requests() {
+		for _, rs := range req.ResourceSpans {
+			for _, ss := range rs.ScopeSpans {
+				spans = append(spans, ss.Spans...)
+			}
+		}
+	}
+	return spans
+}
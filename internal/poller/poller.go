@@ -0,0 +1,360 @@
+// Package poller runs ghaw's fetch loop without a terminal: it polls the run
+// lists of a set of repos, and the details of the runs being watched, on an
+// interval and keeps the latest state for exporters such as the metrics
+// endpoint to read.
+package poller
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/dzoba/github-actions-watcher/internal/gh"
+	"github.com/dzoba/github-actions-watcher/internal/types"
+)
+
+// RepoState is what the poller knows about one repo.
+type RepoState struct {
+	Repo      string
+	Runs      []types.WorkflowRun // newest first, as of FetchedAt
+	FetchedAt time.Time           // last successful fetch
+	Fetches   int                 // fetch attempts
+	Errors    int                 // failed fetch attempts
+	LastError string              // error of the last attempt, if it failed
+}
+
+// State is a snapshot of everything the poller knows.
+type State struct {
+	Repos     []RepoState   // in the order they were configured
+	RateLimit *gh.RateLimit // nil until it has been fetched
+	UpdatedAt time.Time
+}
+
+// Poller polls repos through a Backend. It is safe for concurrent use.
+type Poller struct {
+	backend  gh.Backend
+	repos    []string
+	interval time.Duration
+	now      func() time.Time
+
+	mu          sync.RWMutex
+	state       State
+	subscribers map[chan struct{}]bool
+	// watched counts the watchers of each run; details holds the latest
+	// details of watched runs and of listed finished runs fetched before.
+	watched map[runKey]int
+	details map[runKey]detail
+
+	// pollMu serializes polls. completed holds, per repo, the run attempts
+	// seen completed.
+	pollMu      sync.Mutex
+	onCompleted []func(repo string, d *types.RunDetail)
+	completed   map[string]map[runAttempt]bool
+}
+
+type runAttempt struct{ id, attempt int }
+
+type runKey struct {
+	repo string
+	id   int
+}
+
+type detail struct {
+	d   *types.RunDetail
+	err error
+}
+
+// New returns a poller of repos that polls every interval once run.
+func New(backend gh.Backend, repos []string, interval time.Duration) *Poller {
+	p := &Poller{
+		backend:   backend,
+		repos:     repos,
+		interval:  interval,
+		now:       time.Now,
+		completed: make(map[string]map[runAttempt]bool),
+		watched:   make(map[runKey]int),
+		details:   make(map[runKey]detail),
+	}
+	for _, repo := range repos {
+		p.state.Repos = append(p.state.Repos, RepoState{Repo: repo})
+	}
+	return p
+}
+
+// OnCompleted registers fn to be called with the detail of every run that
+// completes while the poller runs, once per attempt. Runs already completed
+// on the first poll of a repo are not reported. It must be called before Run.
+func (p *Poller) OnCompleted(fn func(repo string, d *types.RunDetail)) {
+	p.onCompleted = append(p.onCompleted, fn)
+}
+
+// Run polls immediately and then every interval until ctx is done.
+func (p *Poller) Run(ctx context.Context) {
+	ticker := time.NewTicker(p.interval)
+	defer ticker.Stop()
+	for {
+		p.Poll(ctx)
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Poll fetches every repo's runs, the details of watched runs and the rate
+// limit once.
+func (p *Poller) Poll(ctx context.Context) {
+	p.pollMu.Lock()
+	defer p.pollMu.Unlock()
+
+	type result struct {
+		runs []types.WorkflowRun
+		err  error
+	}
+	results := make([]result, len(p.repos))
+	if b, ok := p.backend.(gh.Batcher); ok {
+		res, err := b.FetchBatch(ctx, p.repos, nil)
+		for i, repo := range p.repos {
+			if err != nil {
+				results[i] = result{err: err}
+				continue
+			}
+			results[i] = result{res.Runs[repo], res.Errors[repo]}
+		}
+	} else {
+		var wg sync.WaitGroup
+		for i, repo := range p.repos {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				runs, err := p.backend.FetchRuns(ctx, repo)
+				results[i] = result{runs, err}
+			}()
+		}
+		wg.Wait()
+	}
+	rl, rlErr := p.backend.FetchRateLimit(ctx)
+
+	now := p.now()
+	p.mu.Lock()
+	for i, r := range results {
+		rs := &p.state.Repos[i]
+		rs.Fetches++
+		if r.err != nil {
+			rs.Errors++
+			rs.LastError = gh.Explain(r.err)
+			continue
+		}
+		rs.Runs = r.runs
+		rs.FetchedAt = now
+		rs.LastError = ""
+	}
+	if rlErr == nil {
+		p.state.RateLimit = rl
+	}
+	p.state.UpdatedAt = now
+	p.mu.Unlock()
+
+	p.pollDetails(ctx)
+
+	p.mu.Lock()
+	for ch := range p.subscribers {
+		select {
+		case ch <- struct{}{}:
+		default: // a notification is already pending
+		}
+	}
+	p.mu.Unlock()
+
+	if len(p.onCompleted) > 0 {
+		for i, r := range results {
+			if r.err == nil {
+				p.reportCompleted(ctx, p.repos[i], r.runs)
+			}
+		}
+	}
+}
+
+// reportCompleted calls the OnCompleted hooks for runs that completed since
+// the last poll. A run whose detail can't be fetched is retried next poll.
+// Runs of backends that don't report attempts all have attempt 0, so a run
+// seen running again is reported again once it completes.
+func (p *Poller) reportCompleted(ctx context.Context, repo string, runs []types.WorkflowRun) {
+	seen, ok := p.completed[repo]
+	if !ok {
+		seen = make(map[runAttempt]bool)
+		p.completed[repo] = seen
+	}
+	for _, run := range runs {
+		key := runAttempt{run.DatabaseID, run.Attempt}
+		if run.Status != types.StatusCompleted {
+			delete(seen, key)
+			continue
+		}
+		if seen[key] {
+			continue
+		}
+		if !ok {
+			seen[key] = true
+			continue
+		}
+		d, err := p.backend.FetchRunDetail(ctx, repo, run.DatabaseID)
+		if err != nil || d.Status != types.StatusCompleted {
+			continue
+		}
+		seen[key] = true
+		for _, fn := range p.onCompleted {
+			fn(repo, d)
+		}
+	}
+}
+
+// Watch asks for the detail of a run to be fetched every poll, however many
+// watch it, until the returned function is called.
+func (p *Poller) Watch(repo string, id int) (stop func()) {
+	key := runKey{repo, id}
+	p.mu.Lock()
+	p.watched[key]++
+	p.mu.Unlock()
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			p.mu.Lock()
+			defer p.mu.Unlock()
+			if p.watched[key]--; p.watched[key] <= 0 {
+				delete(p.watched, key)
+			}
+		})
+	}
+}
+
+// ErrUnknownRun is returned for the details of runs the poller doesn't know.
+var ErrUnknownRun = errors.New("run is not among the latest runs")
+
+// Known reports whether the poller serves the detail of a run: whether it
+// is in its repo's latest run list or is watched.
+func (p *Poller) Known(repo string, id int) bool {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	key := runKey{repo, id}
+	return p.watched[key] > 0 || p.listed(key) != nil
+}
+
+// Detail returns the detail of a run as of the last poll if it is watched
+// or had finished then, or else fetches it. Only known runs are fetched, so
+// that the cache holds no more than the runs listed and watched.
+func (p *Poller) Detail(ctx context.Context, repo string, id int) (*types.RunDetail, error) {
+	key := runKey{repo, id}
+	p.mu.RLock()
+	cached, ok := p.details[key]
+	fresh := ok && (p.watched[key] > 0 || p.finished(key, cached.d))
+	known := p.watched[key] > 0 || p.listed(key) != nil
+	p.mu.RUnlock()
+	if fresh {
+		return cached.d, cached.err
+	}
+	if !known {
+		return nil, ErrUnknownRun
+	}
+	d, err := p.backend.FetchRunDetail(ctx, repo, id)
+	p.mu.Lock()
+	p.details[key] = detail{d, err}
+	p.mu.Unlock()
+	return d, err
+}
+
+// pollDetails fetches the details of the watched runs that may have
+// changed, and forgets those of runs no longer watched that can still
+// change.
+func (p *Poller) pollDetails(ctx context.Context) {
+	var todo []runKey
+	p.mu.Lock()
+	for key, cached := range p.details {
+		if p.watched[key] == 0 && !p.finished(key, cached.d) {
+			delete(p.details, key)
+		}
+	}
+	for key := range p.watched {
+		if cached, ok := p.details[key]; !ok || !p.finished(key, cached.d) {
+			todo = append(todo, key)
+		}
+	}
+	p.mu.Unlock()
+
+	for _, key := range todo {
+		d, err := p.backend.FetchRunDetail(ctx, key.repo, key.id)
+		p.mu.Lock()
+		if cached := p.details[key]; err != nil && cached.d != nil {
+			// Keep showing what was last fetched.
+			d = cached.d
+		}
+		p.details[key] = detail{d, err}
+		p.mu.Unlock()
+	}
+}
+
+// finished reports whether d is the detail of a finished run that is still
+// the latest attempt in its repo's run list, so it won't change. A run
+// listed without an attempt is taken to be the same one until it is seen
+// running again. A finished run that dropped off the list counts only while
+// it is watched, so that its detail is dropped once nobody watches it.
+// Callers must hold p.mu.
+func (p *Poller) finished(key runKey, d *types.RunDetail) bool {
+	if d == nil || d.Status != types.StatusCompleted {
+		return false
+	}
+	run := p.listed(key)
+	if run == nil {
+		return p.watched[key] > 0
+	}
+	return (run.Attempt == 0 || run.Attempt == d.Attempt) && run.Status == types.StatusCompleted
+}
+
+// listed returns a run as its repo's latest run list has it, or nil.
+// Callers must hold p.mu.
+func (p *Poller) listed(key runKey) *types.WorkflowRun {
+	for _, rs := range p.state.Repos {
+		if rs.Repo != key.repo {
+			continue
+		}
+		for i, run := range rs.Runs {
+			if run.DatabaseID == key.id {
+				return &rs.Runs[i]
+			}
+		}
+	}
+	return nil
+}
+
+// Subscribe returns a channel that receives after every poll, and a function
+// to stop receiving. Notifications don't queue up: a slow reader sees one for
+// any number of polls it missed.
+func (p *Poller) Subscribe() (<-chan struct{}, func()) {
+	ch := make(chan struct{}, 1)
+	p.mu.Lock()
+	if p.subscribers == nil {
+		p.subscribers = make(map[chan struct{}]bool)
+	}
+	p.subscribers[ch] = true
+	p.mu.Unlock()
+	return ch, func() {
+		p.mu.Lock()
+		delete(p.subscribers, ch)
+		p.mu.Unlock()
+	}
+}
+
+// State returns a snapshot of the latest state.
+func (p *Poller) State() State {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	s := p.state
+	s.Repos = append([]RepoState(nil), p.state.Repos...)
+	if s.RateLimit != nil {
+		rl := *s.RateLimit
+		s.RateLimit = &rl
+	}
+	return s
+}
@@ -0,0 +1,141 @@
+package poller
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/dzoba/github-actions-watcher/internal/gh"
+	"github.com/dzoba/github-actions-watcher/internal/poller/pollertest"
+	"github.com/dzoba/github-actions-watcher/internal/types"
+)
+
+func TestPoll(t *testing.T) {
+	b := &pollertest.Backend{
+		Runs:      map[string][]types.WorkflowRun{"o/a": {{DatabaseID: 1}, {DatabaseID: 2}}},
+		RateLimit: gh.RateLimit{Limit: 5000, Remaining: 4321},
+	}
+	p := New(b, []string{"o/a", "o/missing"}, 0)
+	p.Poll(context.Background())
+	p.Poll(context.Background())
+
+	s := p.State()
+	if len(s.Repos) != 2 || s.Repos[0].Repo != "o/a" {
+		t.Fatalf("repos = %+v", s.Repos)
+	}
+	if a := s.Repos[0]; len(a.Runs) != 2 || a.Fetches != 2 || a.Errors != 0 || a.FetchedAt.IsZero() {
+		t.Errorf("o/a = %+v", a)
+	}
+	if m := s.Repos[1]; m.Fetches != 2 || m.Errors != 2 || m.LastError != "HTTP 404" {
+		t.Errorf("o/missing = %+v", m)
+	}
+	if s.RateLimit == nil || s.RateLimit.Remaining != 4321 {
+		t.Errorf("rate limit = %+v", s.RateLimit)
+	}
+}
+
+func TestOnCompleted(t *testing.T) {
+	b := &pollertest.Backend{Runs: map[string][]types.WorkflowRun{
+		"o/a": {
+			{DatabaseID: 1, Attempt: 1, Status: types.StatusCompleted},
+			{DatabaseID: 2, Attempt: 1, Status: types.StatusInProgress},
+		},
+	}}
+	p := New(b, []string{"o/a"}, 0)
+	var got []int
+	p.OnCompleted(func(repo string, d *types.RunDetail) {
+		got = append(got, d.DatabaseID*10+d.Attempt)
+	})
+
+	p.Poll(context.Background()) // run 1 was already done before the poller started
+	b.Runs["o/a"][1].Status = types.StatusCompleted
+	p.Poll(context.Background())
+	p.Poll(context.Background())
+	b.Runs["o/a"][0].Attempt = 2 // re-run
+	p.Poll(context.Background())
+
+	if len(got) != 2 || got[0] != 21 || got[1] != 12 {
+		t.Errorf("completed = %v, want [21 12]", got)
+	}
+}
+
+// TestOnCompletedWithoutAttempts checks that re-runs are reported from
+// backends that list runs without their attempt.
+func TestOnCompletedWithoutAttempts(t *testing.T) {
+	b := &pollertest.Backend{Runs: map[string][]types.WorkflowRun{
+		"o/a": {{DatabaseID: 1, Status: types.StatusCompleted}},
+	}}
+	p := New(b, []string{"o/a"}, 0)
+	reported := 0
+	p.OnCompleted(func(repo string, d *types.RunDetail) { reported++ })
+
+	p.Poll(context.Background())
+	b.Runs["o/a"][0].Status = types.StatusInProgress // re-run
+	p.Poll(context.Background())
+	b.Runs["o/a"][0].Status = types.StatusCompleted
+	p.Poll(context.Background())
+	p.Poll(context.Background())
+
+	if reported != 1 {
+		t.Errorf("re-run reported %d times, want 1", reported)
+	}
+}
+
+func TestWatch(t *testing.T) {
+	b := &pollertest.Backend{Runs: map[string][]types.WorkflowRun{
+		"o/a": {{DatabaseID: 1, Attempt: 1, Status: types.StatusInProgress}},
+	}}
+	p := New(b, []string{"o/a"}, 0)
+	ctx := context.Background()
+
+	// However many watch a running run, its detail is fetched once a poll.
+	stop1 := p.Watch("o/a", 1)
+	stop2 := p.Watch("o/a", 1)
+	p.Poll(ctx)
+	p.Poll(ctx)
+	for range 3 {
+		if d, err := p.Detail(ctx, "o/a", 1); err != nil || d.DatabaseID != 1 {
+			t.Fatalf("detail = %+v, %v", d, err)
+		}
+	}
+	if b.DetailFetches() != 2 {
+		t.Errorf("%d detail fetches over 2 polls with 2 watchers, want 2", b.DetailFetches())
+	}
+
+	stop1()
+	stop1()
+	p.Poll(ctx)
+	if b.DetailFetches() != 3 {
+		t.Errorf("%d detail fetches with a watcher left, want 3", b.DetailFetches())
+	}
+	stop2()
+	p.Poll(ctx)
+	if b.DetailFetches() != 3 {
+		t.Errorf("%d detail fetches with no watchers, want 3", b.DetailFetches())
+	}
+
+	// A finished run's detail is fetched once, then served until re-run.
+	b.Runs["o/a"][0].Status = types.StatusCompleted
+	p.Poll(ctx)
+	p.Detail(ctx, "o/a", 1)
+	p.Detail(ctx, "o/a", 1)
+	if b.DetailFetches() != 4 {
+		t.Errorf("%d detail fetches of a finished run, want 4", b.DetailFetches())
+	}
+	b.Runs["o/a"][0].Attempt = 2
+	p.Poll(ctx)
+	if d, _ := p.Detail(ctx, "o/a", 1); d.Attempt != 2 || b.DetailFetches() != 5 {
+		t.Errorf("re-run detail attempt %d after %d fetches, want 2 after 5", d.Attempt, b.DetailFetches())
+	}
+
+	// Runs not in the list aren't fetched, and finished ones that drop off
+	// it are forgotten.
+	if _, err := p.Detail(ctx, "o/a", 99); !errors.Is(err, ErrUnknownRun) || b.DetailFetches() != 5 {
+		t.Errorf("detail of an unlisted run: %v after %d fetches, want ErrUnknownRun after 5", err, b.DetailFetches())
+	}
+	b.Runs["o/a"] = []types.WorkflowRun{{DatabaseID: 2, Attempt: 1, Status: types.StatusCompleted}}
+	p.Poll(ctx)
+	if p.Known("o/a", 1) || len(p.details) != 0 {
+		t.Errorf("run 1 is still known after dropping off the list: %v", p.details)
+	}
+}
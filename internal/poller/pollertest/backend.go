@@ -0,0 +1,67 @@
+// Package pollertest provides a stand-in for GitHub behind the poller, to
+// test it and what serves its state without fetching anything.
+package pollertest
+
+import (
+	"context"
+	"errors"
+	"sync"
+
+	"github.com/dzoba/github-actions-watcher/internal/gh"
+	"github.com/dzoba/github-actions-watcher/internal/types"
+)
+
+// Backend serves the run lists in Runs and the details in Details. Tests
+// may change them between polls.
+type Backend struct {
+	// Runs holds the run list of each repo. Other repos fail to fetch
+	// with a 404.
+	Runs map[string][]types.WorkflowRun
+	// Details holds run details by run ID. A listed run without one has
+	// a detail without jobs.
+	Details   map[int]*types.RunDetail
+	RateLimit gh.RateLimit
+
+	mu      sync.Mutex
+	fetches int
+}
+
+// FetchRuns returns the run list of repo in Runs.
+func (b *Backend) FetchRuns(ctx context.Context, repo string) ([]types.WorkflowRun, error) {
+	runs, ok := b.Runs[repo]
+	if !ok {
+		return nil, notFound()
+	}
+	return runs, nil
+}
+
+// FetchRunDetail returns the detail of a run and counts the fetch.
+func (b *Backend) FetchRunDetail(ctx context.Context, repo string, runID int) (*types.RunDetail, error) {
+	b.mu.Lock()
+	b.fetches++
+	b.mu.Unlock()
+	if d, ok := b.Details[runID]; ok {
+		return d, nil
+	}
+	for _, run := range b.Runs[repo] {
+		if run.DatabaseID == runID {
+			return &types.RunDetail{WorkflowRun: run}, nil
+		}
+	}
+	return nil, notFound()
+}
+
+// FetchRateLimit returns RateLimit.
+func (b *Backend) FetchRateLimit(ctx context.Context) (*gh.RateLimit, error) {
+	rl := b.RateLimit
+	return &rl, nil
+}
+
+// DetailFetches returns how many run details have been fetched.
+func (b *Backend) DetailFetches() int {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.fetches
+}
+
+func notFound() error { return errors.New("HTTP 404") }
@@ -0,0 +1,176 @@
+// Package rundiff compares two runs of a workflow job by job and step, to
+// see what changed between a good run and a bad one.
+package rundiff
+
+import (
+	"time"
+
+	"github.com/dzoba/github-actions-watcher/internal/types"
+)
+
+// Change is how a job or step differs between the two runs.
+type Change int
+
+const (
+	Same      Change = iota
+	Added            // only in the newer run
+	Removed          // only in the older run
+	Concluded        // in both, with a different conclusion
+)
+
+// StepDiff compares one step. Old or New is nil when the step is only in one
+// run.
+type StepDiff struct {
+	Name     string
+	Old, New *types.Step
+	Change   Change
+	// Delta is how much longer the step took in the newer run. HasDelta is
+	// false unless both runs finished the step.
+	Delta    time.Duration
+	HasDelta bool
+}
+
+// JobDiff compares one job and its steps.
+type JobDiff struct {
+	Name     string
+	Old, New *types.Job
+	Change   Change
+	Delta    time.Duration
+	HasDelta bool
+	Steps    []StepDiff
+}
+
+// Diff compares the jobs of an older and a newer run. Jobs and steps are
+// matched by name, in order for repeated names. Jobs come in the newer run's
+// order, with jobs only in the older run after the job they followed.
+func Diff(old, new *types.RunDetail) []JobDiff {
+	oldJobs := matchByName(len(old.Jobs), func(i int) string { return old.Jobs[i].Name },
+		len(new.Jobs), func(i int) string { return new.Jobs[i].Name })
+
+	var out []JobDiff
+	emitted := make([]bool, len(old.Jobs))
+	emitRemovedUpTo := func(limit int) {
+		for i := 0; i < limit; i++ {
+			if !emitted[i] && oldJobs.matched[i] < 0 {
+				emitted[i] = true
+				out = append(out, jobDiff(&old.Jobs[i], nil))
+			}
+		}
+	}
+	for j := range new.Jobs {
+		i := oldJobs.of[j]
+		if i < 0 {
+			out = append(out, jobDiff(nil, &new.Jobs[j]))
+			continue
+		}
+		emitRemovedUpTo(i)
+		emitted[i] = true
+		out = append(out, jobDiff(&old.Jobs[i], &new.Jobs[j]))
+	}
+	emitRemovedUpTo(len(old.Jobs))
+	return out
+}
+
+func jobDiff(old, new *types.Job) JobDiff {
+	d := JobDiff{Old: old, New: new}
+	switch {
+	case old == nil:
+		d.Name, d.Change = new.Name, Added
+	case new == nil:
+		d.Name, d.Change = old.Name, Removed
+	default:
+		d.Name = new.Name
+		if old.Conclusion != new.Conclusion {
+			d.Change = Concluded
+		}
+		d.Delta, d.HasDelta = delta(old.StartedAt, old.CompletedAt, new.StartedAt, new.CompletedAt)
+	}
+
+	var oldSteps, newSteps []types.Step
+	if old != nil {
+		oldSteps = old.Steps
+	}
+	if new != nil {
+		newSteps = new.Steps
+	}
+	m := matchByName(len(oldSteps), func(i int) string { return oldSteps[i].Name },
+		len(newSteps), func(i int) string { return newSteps[i].Name })
+	for j := range newSteps {
+		if i := m.of[j]; i >= 0 {
+			d.Steps = append(d.Steps, stepDiff(&oldSteps[i], &newSteps[j]))
+		} else {
+			d.Steps = append(d.Steps, stepDiff(nil, &newSteps[j]))
+		}
+	}
+	for i := range oldSteps {
+		if m.matched[i] < 0 {
+			d.Steps = append(d.Steps, stepDiff(&oldSteps[i], nil))
+		}
+	}
+	return d
+}
+
+func stepDiff(old, new *types.Step) StepDiff {
+	d := StepDiff{Old: old, New: new}
+	switch {
+	case old == nil:
+		d.Name, d.Change = new.Name, Added
+	case new == nil:
+		d.Name, d.Change = old.Name, Removed
+	default:
+		d.Name = new.Name
+		if old.Conclusion != new.Conclusion {
+			d.Change = Concluded
+		}
+		d.Delta, d.HasDelta = delta(old.StartedAt, old.CompletedAt, new.StartedAt, new.CompletedAt)
+	}
+	return d
+}
+
+// matching pairs up two lists by name: of[j] is the index in the old list
+// matched to new item j, matched[i] the new index matched to old item i, or
+// -1 for none.
+type matching struct {
+	of, matched []int
+}
+
+func matchByName(nOld int, oldName func(int) string, nNew int, newName func(int) string) matching {
+	m := matching{of: make([]int, nNew), matched: make([]int, nOld)}
+	byName := make(map[string][]int)
+	for i := 0; i < nOld; i++ {
+		m.matched[i] = -1
+		byName[oldName(i)] = append(byName[oldName(i)], i)
+	}
+	for j := 0; j < nNew; j++ {
+		m.of[j] = -1
+		if queue := byName[newName(j)]; len(queue) > 0 {
+			m.of[j], m.matched[queue[0]] = queue[0], j
+			byName[newName(j)] = queue[1:]
+		}
+	}
+	return m
+}
+
+func delta(oldStart, oldEnd, newStart, newEnd string) (time.Duration, bool) {
+	o, ok := took(oldStart, oldEnd)
+	if !ok {
+		return 0, false
+	}
+	n, ok := took(newStart, newEnd)
+	if !ok {
+		return 0, false
+	}
+	return n - o, true
+}
+
+func took(start, end string) (time.Duration, bool) {
+	s, err := time.Parse(time.RFC3339, start)
+	if err != nil {
+		return 0, false
+	}
+	e, err := time.Parse(time.RFC3339, end)
+	if err != nil || e.Before(s) {
+		return 0, false
+	}
+	return e.Sub(s), true
+}
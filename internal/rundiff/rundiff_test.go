@@ -0,0 +1,90 @@
+package rundiff
+
+import (
+	"testing"
+	"time"
+
+	"github.com/dzoba/github-actions-watcher/internal/types"
+)
+
+var t0 = time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+func at(d time.Duration) string { return t0.Add(d).Format(time.RFC3339) }
+
+func step(name string, c types.RunConclusion, took time.Duration) types.Step {
+	return types.Step{Name: name, Status: types.StatusCompleted, Conclusion: c, StartedAt: at(0), CompletedAt: at(took)}
+}
+
+func job(name string, c types.RunConclusion, took time.Duration, steps ...types.Step) types.Job {
+	return types.Job{Name: name, Status: types.StatusCompleted, Conclusion: c, StartedAt: at(0), CompletedAt: at(took), Steps: steps}
+}
+
+func TestDiff(t *testing.T) {
+	pass, fail := types.ConclusionSuccess, types.ConclusionFailure
+	old := &types.RunDetail{Jobs: []types.Job{
+		job("lint", pass, time.Minute),
+		job("docs", pass, time.Minute),
+		job("test", pass, 3*time.Minute,
+			step("checkout", pass, 5*time.Second),
+			step("test", pass, 2*time.Minute),
+			step("upload", pass, 10*time.Second),
+		),
+	}}
+	new := &types.RunDetail{Jobs: []types.Job{
+		job("lint", pass, time.Minute),
+		job("test", fail, 4*time.Minute,
+			step("checkout", pass, 5*time.Second),
+			step("setup", pass, 20*time.Second),
+			step("test", fail, 3*time.Minute),
+		),
+		job("deploy", pass, time.Minute),
+	}}
+	got := Diff(old, new)
+
+	want := []struct {
+		name   string
+		change Change
+	}{{"lint", Same}, {"docs", Removed}, {"test", Concluded}, {"deploy", Added}}
+	if len(got) != len(want) {
+		t.Fatalf("got %d jobs, want %d: %+v", len(got), len(want), got)
+	}
+	for i, w := range want {
+		if got[i].Name != w.name || got[i].Change != w.change {
+			t.Errorf("job %d = %s %v, want %s %v", i, got[i].Name, got[i].Change, w.name, w.change)
+		}
+	}
+
+	test := got[2]
+	if !test.HasDelta || test.Delta != time.Minute {
+		t.Errorf("test delta = %v %v, want +1m", test.Delta, test.HasDelta)
+	}
+	steps := []struct {
+		name   string
+		change Change
+		delta  time.Duration
+	}{
+		{"checkout", Same, 0},
+		{"setup", Added, 0},
+		{"test", Concluded, time.Minute},
+		{"upload", Removed, 0},
+	}
+	if len(test.Steps) != len(steps) {
+		t.Fatalf("got %d steps, want %d", len(test.Steps), len(steps))
+	}
+	for i, w := range steps {
+		s := test.Steps[i]
+		if s.Name != w.name || s.Change != w.change || s.Delta != w.delta {
+			t.Errorf("step %d = %s %v %v, want %s %v %v", i, s.Name, s.Change, s.Delta, w.name, w.change, w.delta)
+		}
+	}
+}
+
+func TestDiffRepeatedNames(t *testing.T) {
+	pass := types.ConclusionSuccess
+	old := &types.RunDetail{Jobs: []types.Job{job("build", pass, time.Minute), job("build", pass, 2*time.Minute)}}
+	new := &types.RunDetail{Jobs: []types.Job{job("build", pass, 2*time.Minute)}}
+	got := Diff(old, new)
+	if len(got) != 2 || got[0].Change != Same || got[0].Delta != time.Minute || got[1].Change != Removed {
+		t.Errorf("got %+v", got)
+	}
+}
@@ -0,0 +1,119 @@
+// Package stats computes summaries over run history: durations, success
+// rates and flakiness.
+package stats
+
+import (
+	"math"
+	"sort"
+	"time"
+
+	"github.com/dzoba/github-actions-watcher/internal/types"
+)
+
+// RunDuration returns how long a finished run took, from when it started
+// (or was created) to its last update. ok is false for unfinished runs and
+// runs that were cancelled or skipped, whose durations say little.
+func RunDuration(r types.WorkflowRun) (d time.Duration, ok bool) {
+	if r.Status != types.StatusCompleted {
+		return 0, false
+	}
+	switch r.Conclusion {
+	case types.ConclusionSuccess, types.ConclusionFailure, types.ConclusionTimedOut:
+	default:
+		return 0, false
+	}
+	start := r.StartedAt
+	if start == "" {
+		start = r.CreatedAt
+	}
+	s, err := time.Parse(time.RFC3339, start)
+	if err != nil {
+		return 0, false
+	}
+	e, err := time.Parse(time.RFC3339, r.UpdatedAt)
+	if err != nil || e.Before(s) {
+		return 0, false
+	}
+	return e.Sub(s), true
+}
+
+// Elapsed returns how long an unfinished run has been going at now.
+func Elapsed(r types.WorkflowRun, now time.Time) (time.Duration, bool) {
+	start := r.StartedAt
+	if start == "" {
+		start = r.CreatedAt
+	}
+	s, err := time.Parse(time.RFC3339, start)
+	if err != nil {
+		return 0, false
+	}
+	return max(now.Sub(s), 0), true
+}
+
+// Durations summarizes the finished runs of one workflow.
+type Durations struct {
+	Workflow string
+	Runs     int
+	Median   time.Duration
+	P90      time.Duration
+	// Recent holds the durations of up to the last N runs, oldest first.
+	Recent []time.Duration
+}
+
+// WorkflowDurations computes duration statistics per workflow, keeping the
+// last n durations of each for sparklines. Runs may be in any order and may
+// repeat; each DatabaseID is counted once. Results are sorted by workflow.
+func WorkflowDurations(runs []types.WorkflowRun, n int) []Durations {
+	byWorkflow := make(map[string][]types.WorkflowRun)
+	seen := make(map[int]bool)
+	for _, r := range runs {
+		if seen[r.DatabaseID] {
+			continue
+		}
+		seen[r.DatabaseID] = true
+		if _, ok := RunDuration(r); ok {
+			byWorkflow[r.WorkflowName] = append(byWorkflow[r.WorkflowName], r)
+		}
+	}
+
+	out := make([]Durations, 0, len(byWorkflow))
+	for name, rs := range byWorkflow {
+		sort.Slice(rs, func(i, j int) bool { return rs[i].CreatedAt < rs[j].CreatedAt })
+		ds := make([]time.Duration, len(rs))
+		for i, r := range rs {
+			ds[i], _ = RunDuration(r)
+		}
+		s := Durations{
+			Workflow: name,
+			Runs:     len(ds),
+			Median:   Percentile(ds, 50),
+			P90:      Percentile(ds, 90),
+		}
+		if len(ds) > n {
+			s.Recent = ds[len(ds)-n:]
+		} else {
+			s.Recent = ds
+		}
+		out = append(out, s)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Workflow < out[j].Workflow })
+	return out
+}
+
+// Percentile returns the p-th percentile (0-100) of ds using the
+// nearest-rank method. It returns 0 for no durations.
+func Percentile(ds []time.Duration, p float64) time.Duration {
+	if len(ds) == 0 {
+		return 0
+	}
+	sorted := append([]time.Duration(nil), ds...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+	rank := int(math.Ceil(p/100*float64(len(sorted)))) - 1
+	if rank < 0 {
+		rank = 0
+	}
+	if rank >= len(sorted) {
+		rank = len(sorted) - 1
+	}
+	return sorted[rank]
+}
@@ -0,0 +1,57 @@
+package stats
+
+import (
+	"testing"
+	"time"
+
+	"github.com/dzoba/github-actions-watcher/internal/types"
+)
+
+func finished(id int, workflow string, start time.Time, took time.Duration, conclusion types.RunConclusion) types.WorkflowRun {
+	return types.WorkflowRun{
+		DatabaseID:   id,
+		WorkflowName: workflow,
+		Status:       types.StatusCompleted,
+		Conclusion:   conclusion,
+		CreatedAt:    start.Format(time.RFC3339),
+		StartedAt:    start.Format(time.RFC3339),
+		UpdatedAt:    start.Add(took).Format(time.RFC3339),
+	}
+}
+
+func TestPercentile(t *testing.T) {
+	ds := []time.Duration{5, 1, 4, 2, 3, 6, 7, 8, 9, 10}
+	if got := Percentile(ds, 50); got != 5 {
+		t.Errorf("p50 = %d, want 5", got)
+	}
+	if got := Percentile(ds, 90); got != 9 {
+		t.Errorf("p90 = %d, want 9", got)
+	}
+	if got := Percentile(nil, 50); got != 0 {
+		t.Errorf("p50 of nothing = %d, want 0", got)
+	}
+}
+
+func TestWorkflowDurations(t *testing.T) {
+	base := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	runs := []types.WorkflowRun{
+		finished(3, "CI", base.Add(2*time.Hour), 3*time.Minute, types.ConclusionFailure),
+		finished(1, "CI", base, 1*time.Minute, types.ConclusionSuccess),
+		finished(2, "CI", base.Add(time.Hour), 2*time.Minute, types.ConclusionSuccess),
+		finished(2, "CI", base.Add(time.Hour), 2*time.Minute, types.ConclusionSuccess), // duplicate
+		finished(4, "CI", base.Add(3*time.Hour), time.Hour, types.ConclusionCancelled),
+		finished(5, "Deploy", base, 10*time.Minute, types.ConclusionSuccess),
+		{DatabaseID: 6, WorkflowName: "CI", Status: types.StatusInProgress},
+	}
+	got := WorkflowDurations(runs, 2)
+	if len(got) != 2 || got[0].Workflow != "CI" || got[1].Workflow != "Deploy" {
+		t.Fatalf("WorkflowDurations() = %+v", got)
+	}
+	ci := got[0]
+	if ci.Runs != 3 || ci.Median != 2*time.Minute || ci.P90 != 3*time.Minute {
+		t.Errorf("CI stats = %+v", ci)
+	}
+	if len(ci.Recent) != 2 || ci.Recent[0] != 2*time.Minute || ci.Recent[1] != 3*time.Minute {
+		t.Errorf("CI recent = %v, want [2m 3m]", ci.Recent)
+	}
+}
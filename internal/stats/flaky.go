@@ -0,0 +1,180 @@
+package stats
+
+import (
+	"sort"
+
+	"github.com/dzoba/github-actions-watcher/internal/types"
+)
+
+// Reasons a failure is counted as flaky.
+const (
+	ReasonRerun    = "passed on rerun" // a later attempt of the same commit passed
+	ReasonFlipFlop = "flip-flop"       // the next commit on the default branch passed again
+)
+
+// Flake is a job failure that looks flaky rather than caused by the code.
+type Flake struct {
+	RunID   int
+	Number  int
+	Attempt int
+	URL     string // the failed job
+	Reason  string
+}
+
+// FlakyJob ranks one job of a workflow by how often it fails flakily.
+type FlakyJob struct {
+	Workflow string
+	Job      string
+	// Score is the share of the job's finished attempts that were flaky
+	// failures, from 0 to 1.
+	Score  float64
+	Runs   int // finished attempts seen
+	Flakes []Flake
+}
+
+// Key identifies a job across runs, for looking up flaky jobs by name.
+func Key(workflow, job string) string {
+	return workflow + "\x00" + job
+}
+
+type outcome struct {
+	detail *types.RunDetail
+	job    types.Job
+	passed bool
+}
+
+// FlakyJobs finds flaky jobs in the given run attempts: every attempt of every
+// run that has been seen, in any order. A failure counts as flaky when a later
+// attempt of the same commit passed, or when the job passed on the commits
+// before and after it on the default branch. Jobs without flaky failures are
+// left out; the rest are sorted by score, most flaky first.
+func FlakyJobs(attempts []types.RunDetail, defaultBranch string) []FlakyJob {
+	sorted := make([]*types.RunDetail, len(attempts))
+	for i := range attempts {
+		sorted[i] = &attempts[i]
+	}
+	sort.SliceStable(sorted, func(i, j int) bool {
+		a, b := sorted[i], sorted[j]
+		if a.CreatedAt != b.CreatedAt {
+			return a.CreatedAt < b.CreatedAt
+		}
+		if a.DatabaseID != b.DatabaseID {
+			return a.DatabaseID < b.DatabaseID
+		}
+		return a.Attempt < b.Attempt
+	})
+
+	byJob := make(map[string][]outcome)
+	var keys []string
+	seen := make(map[[2]int]bool)
+	for _, d := range sorted {
+		if seen[[2]int{d.DatabaseID, d.Attempt}] {
+			continue
+		}
+		seen[[2]int{d.DatabaseID, d.Attempt}] = true
+		for _, job := range d.Jobs {
+			if job.Status != types.StatusCompleted {
+				continue
+			}
+			var passed bool
+			switch job.Conclusion {
+			case types.ConclusionSuccess:
+				passed = true
+			case types.ConclusionFailure, types.ConclusionTimedOut:
+			default:
+				continue // cancelled and skipped jobs say nothing
+			}
+			k := Key(d.WorkflowName, job.Name)
+			if _, ok := byJob[k]; !ok {
+				keys = append(keys, k)
+			}
+			byJob[k] = append(byJob[k], outcome{detail: d, job: job, passed: passed})
+		}
+	}
+
+	var out []FlakyJob
+	for _, k := range keys {
+		outcomes := byJob[k]
+		flaky := make(map[int]string) // index into outcomes -> reason
+		markReruns(outcomes, flaky)
+		markFlipFlops(outcomes, defaultBranch, flaky)
+		if len(flaky) == 0 {
+			continue
+		}
+		fj := FlakyJob{
+			Workflow: outcomes[0].detail.WorkflowName,
+			Job:      outcomes[0].job.Name,
+			Runs:     len(outcomes),
+			Score:    float64(len(flaky)) / float64(len(outcomes)),
+		}
+		for i, o := range outcomes {
+			if reason, ok := flaky[i]; ok {
+				fj.Flakes = append(fj.Flakes, Flake{
+					RunID:   o.detail.DatabaseID,
+					Number:  o.detail.Number,
+					Attempt: o.detail.Attempt,
+					URL:     o.job.URL,
+					Reason:  reason,
+				})
+			}
+		}
+		out = append(out, fj)
+	}
+	sort.SliceStable(out, func(i, j int) bool {
+		if out[i].Score != out[j].Score {
+			return out[i].Score > out[j].Score
+		}
+		if len(out[i].Flakes) != len(out[j].Flakes) {
+			return len(out[i].Flakes) > len(out[j].Flakes)
+		}
+		return Key(out[i].Workflow, out[i].Job) < Key(out[j].Workflow, out[j].Job)
+	})
+	return out
+}
+
+// markReruns flags failures followed by a pass of the same commit.
+func markReruns(outcomes []outcome, flaky map[int]string) {
+	passedAfter := make(map[string]bool)
+	for i := len(outcomes) - 1; i >= 0; i-- {
+		o := outcomes[i]
+		sha := o.detail.HeadSha
+		if sha == "" {
+			continue
+		}
+		if o.passed {
+			passedAfter[sha] = true
+		} else if passedAfter[sha] {
+			flaky[i] = ReasonRerun
+		}
+	}
+}
+
+// markFlipFlops flags failures on the default branch whose neighbouring
+// commits both passed. Only the last attempt of each commit counts.
+func markFlipFlops(outcomes []outcome, defaultBranch string, flaky map[int]string) {
+	if defaultBranch == "" {
+		return
+	}
+	var commits []int // index of each commit's last outcome, oldest first
+	last := make(map[string]int)
+	for i, o := range outcomes {
+		if o.detail.HeadBranch != defaultBranch || o.detail.HeadSha == "" {
+			continue
+		}
+		if j, ok := last[o.detail.HeadSha]; ok {
+			commits[j] = i
+			continue
+		}
+		last[o.detail.HeadSha] = len(commits)
+		commits = append(commits, i)
+	}
+	for c := 1; c+1 < len(commits); c++ {
+		i := commits[c]
+		if outcomes[i].passed || !outcomes[commits[c-1]].passed || !outcomes[commits[c+1]].passed {
+			continue
+		}
+		if _, ok := flaky[i]; !ok {
+			flaky[i] = ReasonFlipFlop
+		}
+	}
+}
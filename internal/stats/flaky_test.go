@@ -0,0 +1,84 @@
+package stats
+
+import (
+	"testing"
+
+	"github.com/dzoba/github-actions-watcher/internal/types"
+)
+
+func attempt(id, n int, sha, branch, created string, jobs ...types.Job) types.RunDetail {
+	return types.RunDetail{
+		WorkflowRun: types.WorkflowRun{
+			DatabaseID:   id,
+			Number:       id,
+			Attempt:      n,
+			HeadSha:      sha,
+			HeadBranch:   branch,
+			CreatedAt:    created,
+			WorkflowName: "CI",
+		},
+		Jobs: jobs,
+	}
+}
+
+func job(name string, c types.RunConclusion) types.Job {
+	return types.Job{Name: name, Status: types.StatusCompleted, Conclusion: c, URL: "https://example.com/" + name}
+}
+
+func TestFlakyJobsRerun(t *testing.T) {
+	pass, fail := types.ConclusionSuccess, types.ConclusionFailure
+	got := FlakyJobs([]types.RunDetail{
+		// attempt 2 passed after attempt 1 failed: flaky
+		attempt(1, 2, "a", "feature", "2024-01-01T00:00:00Z", job("test", pass), job("lint", pass)),
+		attempt(1, 1, "a", "feature", "2024-01-01T00:00:00Z", job("test", fail), job("lint", pass)),
+		// a real failure, never passed on that commit
+		attempt(2, 1, "b", "feature", "2024-01-02T00:00:00Z", job("test", fail), job("lint", fail)),
+	}, "main")
+	if len(got) != 1 {
+		t.Fatalf("got %d flaky jobs, want 1: %+v", len(got), got)
+	}
+	fj := got[0]
+	if fj.Job != "test" || fj.Runs != 3 || len(fj.Flakes) != 1 {
+		t.Fatalf("got %+v", fj)
+	}
+	if f := fj.Flakes[0]; f.RunID != 1 || f.Attempt != 1 || f.Reason != ReasonRerun || f.URL != "https://example.com/test" {
+		t.Errorf("flake = %+v", f)
+	}
+	if fj.Score < 0.33 || fj.Score > 0.34 {
+		t.Errorf("score = %v, want 1/3", fj.Score)
+	}
+}
+
+func TestFlakyJobsFlipFlop(t *testing.T) {
+	pass, fail := types.ConclusionSuccess, types.ConclusionFailure
+	got := FlakyJobs([]types.RunDetail{
+		attempt(1, 1, "a", "main", "2024-01-01T00:00:00Z", job("e2e", pass)),
+		attempt(2, 1, "b", "main", "2024-01-02T00:00:00Z", job("e2e", fail)),
+		attempt(3, 1, "c", "main", "2024-01-03T00:00:00Z", job("e2e", pass)),
+		// two failures in a row look like a real breakage
+		attempt(4, 1, "d", "main", "2024-01-04T00:00:00Z", job("e2e", fail)),
+		attempt(5, 1, "e", "main", "2024-01-05T00:00:00Z", job("e2e", fail)),
+		attempt(6, 1, "f", "main", "2024-01-06T00:00:00Z", job("e2e", pass)),
+		// off the default branch flips don't count
+		attempt(7, 1, "g", "dev", "2024-01-07T00:00:00Z", job("e2e", fail)),
+	}, "main")
+	if len(got) != 1 || len(got[0].Flakes) != 1 {
+		t.Fatalf("got %+v, want one flake", got)
+	}
+	if f := got[0].Flakes[0]; f.RunID != 2 || f.Reason != ReasonFlipFlop {
+		t.Errorf("flake = %+v, want run 2 flip-flop", f)
+	}
+}
+
+func TestFlakyJobsRanking(t *testing.T) {
+	pass, fail := types.ConclusionSuccess, types.ConclusionFailure
+	got := FlakyJobs([]types.RunDetail{
+		attempt(1, 1, "a", "x", "2024-01-01T00:00:00Z", job("often", fail), job("rarely", fail)),
+		attempt(1, 2, "a", "x", "2024-01-01T00:00:00Z", job("often", pass), job("rarely", pass)),
+		attempt(2, 1, "b", "x", "2024-01-02T00:00:00Z", job("often", fail), job("rarely", pass)),
+		attempt(2, 2, "b", "x", "2024-01-02T00:00:00Z", job("often", pass), job("rarely", pass)),
+	}, "")
+	if len(got) != 2 || got[0].Job != "often" || got[1].Job != "rarely" {
+		t.Fatalf("got %+v, want often before rarely", got)
+	}
+}
@@ -0,0 +1,138 @@
+package stats
+
+import (
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/dzoba/github-actions-watcher/internal/types"
+)
+
+// QueueTime returns how long a job waited for a runner, from being queued to
+// starting. For a job still waiting it is how long it has waited so far at
+// now. ok is false when the job's queue time isn't known.
+//
+// The REST API sets started_at on jobs that haven't got a runner yet, so it
+// only counts once the job is running or done.
+func QueueTime(job types.Job, now time.Time) (d time.Duration, ok bool) {
+	created, err := time.Parse(time.RFC3339, job.CreatedAt)
+	if err != nil {
+		return 0, false
+	}
+	if Waiting(job) {
+		return max(now.Sub(created), 0), true
+	}
+	switch {
+	case job.Status != types.StatusInProgress && job.Status != types.StatusCompleted:
+		return 0, false
+	case job.StartedAt == "", job.Conclusion == types.ConclusionSkipped:
+		return 0, false // skipped or cancelled before it got a runner
+	}
+	started, err := time.Parse(time.RFC3339, job.StartedAt)
+	if err != nil {
+		return 0, false
+	}
+	return max(started.Sub(created), 0), true
+}
+
+// Waiting reports whether a job is queued for a runner.
+func Waiting(job types.Job) bool {
+	switch job.Status {
+	case types.StatusQueued, types.StatusWaiting, types.StatusPending, types.StatusRequested:
+		return true
+	}
+	return false
+}
+
+// LabelsKey joins a job's runner labels into one name, e.g.
+// "self-hosted,linux,x64".
+func LabelsKey(job types.Job) string {
+	if len(job.Labels) == 0 {
+		return "(none)"
+	}
+	return strings.Join(job.Labels, ",")
+}
+
+// QueueRow summarizes the queue times of the jobs of one runner label set or
+// workflow.
+type QueueRow struct {
+	Name   string
+	Jobs   int
+	Median time.Duration
+	P90    time.Duration
+	Max    time.Duration
+}
+
+// WaitingJob is a job that is still waiting for a runner.
+type WaitingJob struct {
+	Run    types.WorkflowRun
+	Job    types.Job
+	Waited time.Duration
+}
+
+// Queues summarizes queue times over a set of runs.
+type Queues struct {
+	ByLabels   []QueueRow
+	ByWorkflow []QueueRow
+	// Waiting lists jobs queued for longer than the threshold, longest
+	// first.
+	Waiting []WaitingJob
+}
+
+// QueueTimes computes queue time statistics per runner label set and per
+// workflow over the jobs of the given run attempts, and picks out jobs that
+// have been waiting longer than threshold at now. Jobs without queue times are
+// ignored.
+func QueueTimes(details []types.RunDetail, threshold time.Duration, now time.Time) Queues {
+	byLabels := make(map[string][]time.Duration)
+	byWorkflow := make(map[string][]time.Duration)
+	var q Queues
+	seen := make(map[int]bool)
+	for _, d := range details {
+		for _, job := range d.Jobs {
+			if job.DatabaseID != 0 {
+				if seen[job.DatabaseID] {
+					continue
+				}
+				seen[job.DatabaseID] = true
+			}
+			wait, ok := QueueTime(job, now)
+			if !ok {
+				continue
+			}
+			if Waiting(job) {
+				if wait > threshold {
+					q.Waiting = append(q.Waiting, WaitingJob{Run: d.WorkflowRun, Job: job, Waited: wait})
+				}
+				continue
+			}
+			byLabels[LabelsKey(job)] = append(byLabels[LabelsKey(job)], wait)
+			byWorkflow[d.WorkflowName] = append(byWorkflow[d.WorkflowName], wait)
+		}
+	}
+	q.ByLabels = queueRows(byLabels)
+	q.ByWorkflow = queueRows(byWorkflow)
+	sort.Slice(q.Waiting, func(i, j int) bool { return q.Waiting[i].Waited > q.Waiting[j].Waited })
+	return q
+}
+
+// queueRows summarizes each group, slowest median first.
+func queueRows(groups map[string][]time.Duration) []QueueRow {
+	out := make([]QueueRow, 0, len(groups))
+	for name, ds := range groups {
+		out = append(out, QueueRow{
+			Name:   name,
+			Jobs:   len(ds),
+			Median: Percentile(ds, 50),
+			P90:    Percentile(ds, 90),
+			Max:    Percentile(ds, 100),
+		})
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].Median != out[j].Median {
+			return out[i].Median > out[j].Median
+		}
+		return out[i].Name < out[j].Name
+	})
+	return out
+}
@@ -0,0 +1,92 @@
+package stats
+
+import (
+	"testing"
+	"time"
+
+	"github.com/dzoba/github-actions-watcher/internal/types"
+)
+
+func TestQueueTime(t *testing.T) {
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	at := func(ago time.Duration) string { return now.Add(-ago).Format(time.RFC3339) }
+
+	ran := types.Job{Status: types.StatusCompleted, CreatedAt: at(10 * time.Minute), StartedAt: at(7 * time.Minute)}
+	if d, ok := QueueTime(ran, now); !ok || d != 3*time.Minute {
+		t.Errorf("QueueTime(ran) = %v, %v; want 3m", d, ok)
+	}
+	waiting := types.Job{Status: types.StatusQueued, CreatedAt: at(4 * time.Minute)}
+	if d, ok := QueueTime(waiting, now); !ok || d != 4*time.Minute || !Waiting(waiting) {
+		t.Errorf("QueueTime(waiting) = %v, %v; want 4m so far", d, ok)
+	}
+	skipped := types.Job{Status: types.StatusCompleted, Conclusion: types.ConclusionSkipped, CreatedAt: at(time.Minute)}
+	if _, ok := QueueTime(skipped, now); ok {
+		t.Error("skipped job has a queue time")
+	}
+
+	// The REST API sets started_at before a job gets a runner, and on
+	// skipped jobs.
+	for _, status := range []types.RunStatus{types.StatusQueued, types.StatusWaiting, types.StatusPending, types.StatusRequested} {
+		job := types.Job{Status: status, CreatedAt: at(6 * time.Minute), StartedAt: at(6 * time.Minute)}
+		if d, ok := QueueTime(job, now); !ok || d != 6*time.Minute || !Waiting(job) {
+			t.Errorf("QueueTime(%s from the API) = %v, %v; want 6m so far", status, d, ok)
+		}
+	}
+	skipped.StartedAt, skipped.CompletedAt = at(time.Minute), at(time.Minute)
+	if _, ok := QueueTime(skipped, now); ok {
+		t.Error("skipped job from the API has a queue time")
+	}
+	running := types.Job{Status: types.StatusInProgress, CreatedAt: at(5 * time.Minute), StartedAt: at(4 * time.Minute)}
+	if d, ok := QueueTime(running, now); !ok || d != time.Minute || Waiting(running) {
+		t.Errorf("QueueTime(running) = %v, %v; want 1m", d, ok)
+	}
+	if _, ok := QueueTime(types.Job{StartedAt: at(0)}, now); ok {
+		t.Error("job without created_at has a queue time")
+	}
+}
+
+func TestQueueTimes(t *testing.T) {
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	at := func(ago time.Duration) string { return now.Add(-ago).Format(time.RFC3339) }
+	job := func(id int, labels []string, queued, started time.Duration) types.Job {
+		j := types.Job{DatabaseID: id, Labels: labels, Status: types.StatusCompleted, CreatedAt: at(queued)}
+		if started >= 0 {
+			j.StartedAt = at(started)
+		} else {
+			// As the REST API reports jobs waiting for a runner.
+			j.Status, j.StartedAt = types.StatusQueued, at(queued)
+		}
+		return j
+	}
+	hosted := []string{"ubuntu-latest"}
+	self := []string{"self-hosted", "linux"}
+	details := []types.RunDetail{
+		{WorkflowRun: types.WorkflowRun{WorkflowName: "CI"}, Jobs: []types.Job{
+			job(1, hosted, time.Hour, time.Hour-10*time.Second),
+			job(2, self, time.Hour, 50*time.Minute),
+			job(3, self, 30*time.Minute, 10*time.Minute),
+		}},
+		{WorkflowRun: types.WorkflowRun{WorkflowName: "Deploy"}, Jobs: []types.Job{
+			job(4, self, 12*time.Minute, -1),
+			job(5, hosted, 2*time.Minute, -1),
+			job(1, hosted, time.Hour, time.Hour-10*time.Second), // seen already
+		}},
+	}
+	q := QueueTimes(details, 5*time.Minute, now)
+
+	if len(q.ByLabels) != 2 || q.ByLabels[0].Name != "self-hosted,linux" {
+		t.Fatalf("by labels = %+v, want self-hosted first", q.ByLabels)
+	}
+	if r := q.ByLabels[0]; r.Jobs != 2 || r.Median != 10*time.Minute || r.Max != 20*time.Minute {
+		t.Errorf("self-hosted = %+v", r)
+	}
+	if r := q.ByLabels[1]; r.Jobs != 1 || r.Median != 10*time.Second {
+		t.Errorf("hosted = %+v", r)
+	}
+	if len(q.ByWorkflow) != 1 || q.ByWorkflow[0].Jobs != 3 {
+		t.Errorf("by workflow = %+v", q.ByWorkflow)
+	}
+	if len(q.Waiting) != 1 || q.Waiting[0].Job.DatabaseID != 4 || q.Waiting[0].Waited != 12*time.Minute {
+		t.Errorf("waiting = %+v, want job 4 for 12m", q.Waiting)
+	}
+}
@@ -0,0 +1,269 @@
+package stats
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/dzoba/github-actions-watcher/internal/types"
+)
+
+// Window is a trailing period of time rates are computed over.
+type Window struct {
+	Label string
+	Dur   time.Duration
+}
+
+// DefaultWindows are the last day, week and month.
+var DefaultWindows = []Window{
+	{"24h", 24 * time.Hour},
+	{"7d", 7 * 24 * time.Hour},
+	{"30d", 30 * 24 * time.Hour},
+}
+
+// ParseWindows parses a comma-separated list of windows such as "24h,7d,30d".
+func ParseWindows(s string) ([]Window, error) {
+	var out []Window
+	for _, part := range strings.Split(s, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		d, err := ParseDuration(part)
+		if err != nil {
+			return nil, fmt.Errorf("invalid window %q", part)
+		}
+		out = append(out, Window{Label: part, Dur: d})
+	}
+	if len(out) == 0 {
+		return nil, fmt.Errorf("no windows given")
+	}
+	return out, nil
+}
+
+// ParseDuration parses a positive period. Besides Go durations, a "d" suffix
+// counts days and "w" weeks.
+func ParseDuration(s string) (time.Duration, error) {
+	var d time.Duration
+	var err error
+	if s == "" {
+		return 0, fmt.Errorf("invalid duration %q", s)
+	}
+	switch unit := s[len(s)-1]; unit {
+	case 'd', 'w':
+		var n int
+		n, err = strconv.Atoi(s[:len(s)-1])
+		d = time.Duration(n) * 24 * time.Hour
+		if unit == 'w' {
+			d *= 7
+		}
+	default:
+		d, err = time.ParseDuration(s)
+	}
+	if err != nil || d <= 0 {
+		return 0, fmt.Errorf("invalid duration %q", s)
+	}
+	return d, nil
+}
+
+// Rates counts the outcomes of finished runs. Skipped and other neutral
+// outcomes count toward Runs only.
+type Rates struct {
+	Runs      int
+	Success   int
+	Failure   int
+	Cancelled int
+}
+
+func (r Rates) rate(n int) float64 {
+	if r.Runs == 0 {
+		return 0
+	}
+	return float64(n) / float64(r.Runs)
+}
+
+// SuccessRate returns the share of runs that succeeded, from 0 to 1.
+func (r Rates) SuccessRate() float64 { return r.rate(r.Success) }
+
+// FailureRate returns the share of runs that failed or timed out.
+func (r Rates) FailureRate() float64 { return r.rate(r.Failure) }
+
+// CancelRate returns the share of runs that were cancelled.
+func (r Rates) CancelRate() float64 { return r.rate(r.Cancelled) }
+
+func (r *Rates) add(run types.WorkflowRun) {
+	r.Runs++
+	switch {
+	case run.Conclusion == types.ConclusionSuccess:
+		r.Success++
+	case failed(run.Conclusion):
+		r.Failure++
+	case run.Conclusion == types.ConclusionCancelled:
+		r.Cancelled++
+	}
+}
+
+func failed(c types.RunConclusion) bool {
+	return c == types.ConclusionFailure || c == types.ConclusionTimedOut
+}
+
+// Streak is the current run of consecutive green or red results, newest
+// first. Runs is 0 when there are no passed or failed runs.
+type Streak struct {
+	Green bool `json:"green"`
+	Runs  int  `json:"runs"`
+}
+
+// RateRow holds the rates of one workflow or branch, one per window.
+type RateRow struct {
+	Workflow string
+	Branch   string
+	Windows  []Rates
+	Streak   Streak
+}
+
+// Dashboard summarizes the health of a repo's CI.
+type Dashboard struct {
+	Windows       []Window
+	DefaultBranch string
+	Workflows     []RateRow
+	Branches      []RateRow
+	// MTTR is the mean time from a workflow failing on the default branch
+	// to it passing again, over Recoveries such recoveries.
+	MTTR       time.Duration
+	Recoveries int
+	// Partial is set for each window reaching back before Since: MaxRuns
+	// runs were fetched, so runs older than the oldest of them are missing
+	// and the window's counts are only a lower bound. Since is zero when
+	// every run was fetched.
+	Partial []bool
+	Since   time.Time
+}
+
+// BuildDashboard computes rates per workflow and per branch over each window
+// ending at now, from runs in any order. Workflow streaks and recovery times
+// only look at the default branch, when it is known. Runs are taken to be at
+// most MaxRuns of the latest, so with MaxRuns of them windows older than
+// the oldest are marked partial.
+func BuildDashboard(runs []types.WorkflowRun, windows []Window, defaultBranch string, now time.Time) Dashboard {
+	type finishedRun struct {
+		run     types.WorkflowRun
+		created time.Time
+	}
+	var done []finishedRun
+	seen := make(map[int]bool)
+	for _, r := range runs {
+		if seen[r.DatabaseID] || r.Status != types.StatusCompleted {
+			continue
+		}
+		seen[r.DatabaseID] = true
+		created, err := time.Parse(time.RFC3339, r.CreatedAt)
+		if err != nil {
+			continue
+		}
+		done = append(done, finishedRun{r, created})
+	}
+	// Oldest first, for streaks and recoveries.
+	sort.Slice(done, func(i, j int) bool { return done[i].created.Before(done[j].created) })
+
+	d := Dashboard{Windows: windows, DefaultBranch: defaultBranch, Partial: make([]bool, len(windows))}
+	if len(runs) >= MaxRuns {
+		for _, r := range runs {
+			if created, err := time.Parse(time.RFC3339, r.CreatedAt); err == nil && (d.Since.IsZero() || created.Before(d.Since)) {
+				d.Since = created
+			}
+		}
+		for i, w := range windows {
+			d.Partial[i] = d.Since.After(now.Add(-w.Dur))
+		}
+	}
+	workflows := make(map[string]*RateRow)
+	branches := make(map[string]*RateRow)
+	row := func(rows map[string]*RateRow, key string, wf, branch string) *RateRow {
+		r, ok := rows[key]
+		if !ok {
+			r = &RateRow{Workflow: wf, Branch: branch, Windows: make([]Rates, len(windows))}
+			rows[key] = r
+		}
+		return r
+	}
+	streak := func(s *Streak, c types.RunConclusion) {
+		green := c == types.ConclusionSuccess
+		if !green && !failed(c) {
+			return
+		}
+		if s.Runs == 0 || s.Green != green {
+			*s = Streak{Green: green}
+		}
+		s.Runs++
+	}
+
+	redSince := make(map[string]time.Time) // workflow -> when it went red
+	var recovery time.Duration
+	for _, f := range done {
+		wf := row(workflows, f.run.WorkflowName, f.run.WorkflowName, "")
+		br := row(branches, f.run.HeadBranch, "", f.run.HeadBranch)
+		for i, w := range windows {
+			if now.Sub(f.created) <= w.Dur {
+				wf.Windows[i].add(f.run)
+				br.Windows[i].add(f.run)
+			}
+		}
+		streak(&br.Streak, f.run.Conclusion)
+		if defaultBranch == "" || f.run.HeadBranch == defaultBranch {
+			streak(&wf.Streak, f.run.Conclusion)
+		}
+
+		if f.run.HeadBranch != defaultBranch || defaultBranch == "" {
+			continue
+		}
+		end, err := time.Parse(time.RFC3339, f.run.UpdatedAt)
+		if err != nil {
+			end = f.created
+		}
+		since, red := redSince[f.run.WorkflowName]
+		switch {
+		case failed(f.run.Conclusion) && !red:
+			redSince[f.run.WorkflowName] = end
+		case f.run.Conclusion == types.ConclusionSuccess && red:
+			recovery += max(end.Sub(since), 0)
+			d.Recoveries++
+			delete(redSince, f.run.WorkflowName)
+		}
+	}
+	if d.Recoveries > 0 {
+		d.MTTR = recovery / time.Duration(d.Recoveries)
+	}
+
+	for _, r := range workflows {
+		d.Workflows = append(d.Workflows, *r)
+	}
+	for _, r := range branches {
+		d.Branches = append(d.Branches, *r)
+	}
+	sort.Slice(d.Workflows, func(i, j int) bool { return d.Workflows[i].Workflow < d.Workflows[j].Workflow })
+	// Busiest branches first, over the widest window.
+	last := len(windows) - 1
+	sort.Slice(d.Branches, func(i, j int) bool {
+		a, b := d.Branches[i], d.Branches[j]
+		if last >= 0 && a.Windows[last].Runs != b.Windows[last].Runs {
+			return a.Windows[last].Runs > b.Windows[last].Runs
+		}
+		return a.Branch < b.Branch
+	})
+	return d
+}
+
+// MaxRuns caps how many runs are fetched to compute rates over.
+const MaxRuns = 1000
+
+// Since returns the start of the widest window ending at now.
+func Since(windows []Window, now time.Time) time.Time {
+	var widest time.Duration
+	for _, w := range windows {
+		widest = max(widest, w.Dur)
+	}
+	return now.Add(-widest)
+}
@@ -0,0 +1,107 @@
+package stats
+
+import (
+	"testing"
+	"time"
+
+	"github.com/dzoba/github-actions-watcher/internal/types"
+)
+
+func TestParseWindows(t *testing.T) {
+	ws, err := ParseWindows("24h, 7d,2w")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []time.Duration{24 * time.Hour, 7 * 24 * time.Hour, 14 * 24 * time.Hour}
+	if len(ws) != len(want) {
+		t.Fatalf("got %d windows, want %d", len(ws), len(want))
+	}
+	for i, w := range ws {
+		if w.Dur != want[i] {
+			t.Errorf("window %s = %v, want %v", w.Label, w.Dur, want[i])
+		}
+	}
+	for _, bad := range []string{"", "7x", "-1d", "0h"} {
+		if _, err := ParseWindows(bad); err == nil {
+			t.Errorf("ParseWindows(%q) succeeded, want error", bad)
+		}
+	}
+}
+
+func TestBuildDashboard(t *testing.T) {
+	now := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	run := func(id int, wf, branch string, ago time.Duration, c types.RunConclusion) types.WorkflowRun {
+		r := finished(id, wf, now.Add(-ago), 10*time.Minute, c)
+		r.HeadBranch = branch
+		return r
+	}
+	day := 24 * time.Hour
+	runs := []types.WorkflowRun{
+		run(1, "CI", "main", 20*day, types.ConclusionSuccess),
+		run(2, "CI", "main", 5*day, types.ConclusionFailure),
+		run(3, "CI", "main", 5*day-time.Hour, types.ConclusionFailure),
+		run(4, "CI", "main", 5*day-3*time.Hour, types.ConclusionSuccess),
+		run(5, "CI", "main", 2*time.Hour, types.ConclusionSuccess),
+		run(6, "CI", "dev", time.Hour, types.ConclusionCancelled),
+		run(7, "Lint", "main", time.Hour, types.ConclusionFailure),
+		{DatabaseID: 8, WorkflowName: "CI", Status: types.StatusInProgress, CreatedAt: now.Format(time.RFC3339)},
+	}
+	d := BuildDashboard(runs, DefaultWindows, "main", now)
+
+	if len(d.Workflows) != 2 || d.Workflows[0].Workflow != "CI" {
+		t.Fatalf("workflows = %+v", d.Workflows)
+	}
+	ci := d.Workflows[0]
+	want := []Rates{
+		{Runs: 2, Success: 1, Cancelled: 1},
+		{Runs: 5, Success: 2, Failure: 2, Cancelled: 1},
+		{Runs: 6, Success: 3, Failure: 2, Cancelled: 1},
+	}
+	for i, w := range want {
+		if ci.Windows[i] != w {
+			t.Errorf("CI %s = %+v, want %+v", d.Windows[i].Label, ci.Windows[i], w)
+		}
+	}
+	// The cancelled run on dev doesn't break the streak on main.
+	if ci.Streak != (Streak{Green: true, Runs: 2}) {
+		t.Errorf("CI streak = %+v, want 2 green", ci.Streak)
+	}
+	if lint := d.Workflows[1]; lint.Streak != (Streak{Green: false, Runs: 1}) {
+		t.Errorf("Lint streak = %+v, want 1 red", lint.Streak)
+	}
+
+	// Red from the end of run 2 to the end of run 4.
+	if d.Recoveries != 1 || d.MTTR != 3*time.Hour {
+		t.Errorf("MTTR = %v over %d, want 3h over 1", d.MTTR, d.Recoveries)
+	}
+
+	if len(d.Branches) != 2 || d.Branches[0].Branch != "main" {
+		t.Errorf("branches = %+v, want main first", d.Branches)
+	}
+	if rate := d.Branches[0].Windows[2].SuccessRate(); rate != 0.5 {
+		t.Errorf("main 30d success rate = %v, want 0.5", rate)
+	}
+}
+
+func TestBuildDashboardPartial(t *testing.T) {
+	now := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	// MaxRuns runs fetched, two minutes apart: the last 24h is complete,
+	// older runs of the week were left out.
+	var runs []types.WorkflowRun
+	for i := range MaxRuns {
+		runs = append(runs, finished(i+1, "CI", now.Add(-time.Duration(2*i)*time.Minute), time.Minute, types.ConclusionSuccess))
+	}
+	windows := DefaultWindows[:2]
+	d := BuildDashboard(runs, windows, "main", now)
+	if d.Partial[0] || !d.Partial[1] {
+		t.Errorf("partial = %v, want only 7d", d.Partial)
+	}
+	if want := now.Add(-2 * (MaxRuns - 1) * time.Minute); !d.Since.Equal(want) {
+		t.Errorf("since = %v, want %v", d.Since, want)
+	}
+
+	d = BuildDashboard(runs[:MaxRuns-1], windows, "main", now)
+	if d.Partial[1] || !d.Since.IsZero() {
+		t.Errorf("partial = %v since %v with fewer than MaxRuns runs", d.Partial, d.Since)
+	}
+}
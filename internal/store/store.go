@@ -0,0 +1,455 @@
+// Package store keeps a local history of the workflow runs ghaw has seen, so
+// later features can look back further than the latest poll.
+//
+// Each repo's history is a JSON file in the store directory. Records are
+// keyed by run DatabaseID and rewritten when a run changes, or at most every
+// seenEvery to note that unchanged runs were seen again.
+//
+// Several ghaw processes may share a store, e.g. a terminal and ghaw serve.
+// Writes take a lock file next to the history and re-read it first if
+// another process replaced it, so that they add to each other's records
+// rather than overwrite them. Every write re-encodes the whole file, which
+// seenEvery keeps rare while polling.
+package store
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/dzoba/github-actions-watcher/internal/types"
+)
+
+// Defaults for how much history is kept per repo.
+const (
+	DefaultMaxRuns = 2000
+	DefaultMaxAge  = 90 * 24 * time.Hour
+)
+
+// Record is everything known about one run.
+type Record struct {
+	Run types.WorkflowRun `json:"run"`
+	// Detail is the latest jobs and steps seen for the run, if it was
+	// ever opened or fetched in detail.
+	Detail *types.RunDetail `json:"detail,omitempty"`
+	// Attempts holds the details of earlier attempts of a re-run run.
+	Attempts []types.RunDetail `json:"attempts,omitempty"`
+	// Timing is the billable time of a finished run, once fetched.
+	Timing *types.RunTiming `json:"timing,omitempty"`
+	// When the run was first and last seen in a run list or detail fetch,
+	// whether or not it had changed, and when its detail was last fetched.
+	// Sightings are recorded to within seenEvery.
+	FirstSeen  time.Time `json:"firstSeen"`
+	LastSeen   time.Time `json:"lastSeen"`
+	DetailSeen time.Time `json:"detailSeen,omitzero"`
+}
+
+// seenEvery is how often sightings of unchanged runs are written, so that
+// polling doesn't rewrite the history every few seconds.
+const seenEvery = time.Minute
+
+// Options configures retention. Zero values use the defaults.
+type Options struct {
+	MaxRuns int           // runs kept per repo, newest first
+	MaxAge  time.Duration // runs created longer ago than this are dropped
+}
+
+// Store is a directory of per-repo run histories. It is safe for concurrent
+// use.
+type Store struct {
+	dir     string
+	maxRuns int
+	maxAge  time.Duration
+	now     func() time.Time
+
+	mu    sync.Mutex
+	repos map[string]*history
+}
+
+// history is a repo's records as last read or written, and the file they
+// were read from or written to.
+type history struct {
+	records map[int]*Record
+	file    os.FileInfo // nil if there was no file
+}
+
+// DefaultDir returns the directory history is kept in by default.
+func DefaultDir() (string, error) {
+	cache, err := os.UserCacheDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(cache, "ghaw", "history"), nil
+}
+
+// Open returns a store in dir, creating the directory if needed.
+func Open(dir string, opts Options) (*Store, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, fmt.Errorf("failed to create history dir: %w", err)
+	}
+	s := &Store{
+		dir:     dir,
+		maxRuns: opts.MaxRuns,
+		maxAge:  opts.MaxAge,
+		now:     time.Now,
+		repos:   make(map[string]*history),
+	}
+	if s.maxRuns <= 0 {
+		s.maxRuns = DefaultMaxRuns
+	}
+	if s.maxAge <= 0 {
+		s.maxAge = DefaultMaxAge
+	}
+	return s, nil
+}
+
+// RecordRuns upserts runs from a run list.
+func (s *Store) RecordRuns(repo string, runs []types.WorkflowRun) error {
+	return s.update(repo, func(records map[int]*Record) bool {
+		changed := false
+		for _, run := range runs {
+			if s.upsertRun(records, run) {
+				changed = true
+			}
+		}
+		return changed
+	})
+}
+
+// RecordDetail upserts a run together with its jobs. If the run was re-run
+// since its detail was last recorded, the earlier attempt is kept.
+func (s *Store) RecordDetail(repo string, d *types.RunDetail) error {
+	if d.Attempt == 0 {
+		// Without an attempt number it can't be told apart from earlier
+		// attempts, so it would spoil them.
+		return nil
+	}
+	return s.update(repo, func(records map[int]*Record) bool {
+		changed := s.upsertRun(records, d.WorkflowRun)
+		rec := records[d.DatabaseID]
+		if now := s.now(); now.Sub(rec.DetailSeen) >= seenEvery {
+			rec.DetailSeen = now
+			changed = true
+		}
+		switch {
+		case rec.Detail == nil:
+			changed = true
+		case rec.Detail.Attempt != d.Attempt:
+			rec.Attempts = append(rec.Attempts, *rec.Detail)
+			changed = true
+		default:
+			old, _ := json.Marshal(rec.Detail)
+			cur, _ := json.Marshal(d)
+			changed = changed || string(old) != string(cur)
+		}
+		if changed {
+			detail := *d
+			rec.Detail = &detail
+		}
+		return changed
+	})
+}
+
+// RecordAttempt keeps the detail of an earlier attempt of a run, for runs
+// whose history was recorded after they were re-run.
+func (s *Store) RecordAttempt(repo string, d *types.RunDetail) error {
+	return s.update(repo, func(records map[int]*Record) bool {
+		rec, ok := records[d.DatabaseID]
+		if !ok || rec.HasAttempt(d.Attempt) {
+			return false
+		}
+		rec.Attempts = append(rec.Attempts, *d)
+		sort.Slice(rec.Attempts, func(i, j int) bool {
+			return rec.Attempts[i].Attempt < rec.Attempts[j].Attempt
+		})
+		return true
+	})
+}
+
+// RecordTiming keeps the billable time of a recorded run. Timings of runs
+// the store hasn't seen are ignored.
+func (s *Store) RecordTiming(repo string, runID int, t *types.RunTiming) error {
+	return s.update(repo, func(records map[int]*Record) bool {
+		rec, ok := records[runID]
+		if !ok {
+			return false
+		}
+		timing := *t
+		rec.Timing = &timing
+		return true
+	})
+}
+
+// update applies fn to a repo's records and saves them if fn reports a
+// change. It holds the repo's lock file throughout, so fn sees what other
+// processes wrote before it.
+func (s *Store) update(repo string, fn func(records map[int]*Record) bool) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	unlock, err := lockFile(s.path(repo) + ".lock")
+	if err != nil {
+		return err
+	}
+	defer unlock()
+	records, err := s.load(repo)
+	if err != nil {
+		return err
+	}
+	if !fn(records) {
+		return nil
+	}
+	return s.save(repo, records)
+}
+
+// How long a write waits for another process's lock, and how old a lock
+// must be to be taken for one left behind by a process that died. Writes
+// hold it for milliseconds.
+const (
+	lockWait  = 2 * time.Second
+	lockStale = 10 * time.Second
+)
+
+// lockFile creates the lock file at path and returns a func that removes
+// it, waiting up to lockWait while another process holds it.
+func lockFile(path string) (unlock func(), err error) {
+	deadline := time.Now().Add(lockWait)
+	for {
+		f, err := os.OpenFile(path, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0o644)
+		if err == nil {
+			f.Close()
+			return func() { os.Remove(path) }, nil
+		}
+		if !errors.Is(err, fs.ErrExist) {
+			return nil, fmt.Errorf("failed to lock history: %w", err)
+		}
+		if fi, err := os.Stat(path); err == nil && time.Since(fi.ModTime()) > lockStale {
+			os.Remove(path)
+			continue
+		}
+		if time.Now().After(deadline) {
+			return nil, fmt.Errorf("failed to lock history: %s is held by another ghaw", path)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+// HasAttempt reports whether the detail of an attempt has been recorded.
+func (r Record) HasAttempt(n int) bool {
+	if r.Detail != nil && r.Detail.Attempt == n {
+		return true
+	}
+	for _, a := range r.Attempts {
+		if a.Attempt == n {
+			return true
+		}
+	}
+	return false
+}
+
+// AllAttempts returns the recorded details of every attempt, oldest first.
+func (r Record) AllAttempts() []types.RunDetail {
+	out := append([]types.RunDetail(nil), r.Attempts...)
+	if r.Detail != nil {
+		out = append(out, *r.Detail)
+	}
+	return out
+}
+
+// upsertRun adds or updates a run and reports whether anything changed.
+func (s *Store) upsertRun(records map[int]*Record, run types.WorkflowRun) bool {
+	now := s.now()
+	rec, ok := records[run.DatabaseID]
+	if !ok {
+		records[run.DatabaseID] = &Record{Run: run, FirstSeen: now, LastSeen: now}
+		return true
+	}
+	if run.Attempt == 0 && rec.Run.Attempt != 0 {
+		// The backend couldn't tell; keep what was recorded of the attempt.
+		run.Attempt = rec.Run.Attempt
+		run.StartedAt = rec.Run.StartedAt
+	}
+	if rec.Run == run {
+		if now.Sub(rec.LastSeen) < seenEvery {
+			return false
+		}
+		rec.LastSeen = now
+		return true
+	}
+	rec.Run = run
+	rec.LastSeen = now
+	return true
+}
+
+// Runs returns the recorded runs of a repo, newest first.
+func (s *Store) Runs(repo string) ([]Record, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	records, err := s.load(repo)
+	if err != nil {
+		return nil, err
+	}
+	return sorted(records), nil
+}
+
+// Get returns the record of a single run.
+func (s *Store) Get(repo string, runID int) (Record, bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	records, err := s.load(repo)
+	if err != nil {
+		return Record{}, false, err
+	}
+	rec, ok := records[runID]
+	if !ok {
+		return Record{}, false, nil
+	}
+	return *rec, true, nil
+}
+
+// Repos returns the repos that have history, in name order.
+func (s *Store) Repos() ([]string, error) {
+	entries, err := os.ReadDir(s.dir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read history dir: %w", err)
+	}
+	var repos []string
+	for _, e := range entries {
+		name, ok := repoFromFile(e.Name())
+		if ok {
+			repos = append(repos, name)
+		}
+	}
+	sort.Strings(repos)
+	return repos, nil
+}
+
+func sorted(records map[int]*Record) []Record {
+	out := make([]Record, 0, len(records))
+	for _, rec := range records {
+		out = append(out, *rec)
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].Run.CreatedAt != out[j].Run.CreatedAt {
+			return out[i].Run.CreatedAt > out[j].Run.CreatedAt
+		}
+		return out[i].Run.DatabaseID > out[j].Run.DatabaseID
+	})
+	return out
+}
+
+// load returns the cached records of a repo, reading its file on first use
+// and again whenever another process has replaced it since. Callers must
+// hold s.mu.
+func (s *Store) load(repo string) (map[int]*Record, error) {
+	h, cached := s.repos[repo]
+	f, err := os.Open(s.path(repo))
+	switch {
+	case errors.Is(err, fs.ErrNotExist):
+		if cached && h.file == nil {
+			return h.records, nil
+		}
+		s.repos[repo] = &history{records: make(map[int]*Record)}
+		return s.repos[repo].records, nil
+	case err != nil:
+		return nil, fmt.Errorf("failed to read history: %w", err)
+	}
+	defer f.Close()
+	fi, err := f.Stat()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read history: %w", err)
+	}
+	if cached && sameFile(h.file, fi) {
+		return h.records, nil
+	}
+	data, err := io.ReadAll(f)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read history: %w", err)
+	}
+	var list []Record
+	if err := json.Unmarshal(data, &list); err != nil {
+		return nil, fmt.Errorf("failed to parse history for %s: %w", repo, err)
+	}
+	records := make(map[int]*Record, len(list))
+	for i := range list {
+		records[list[i].Run.DatabaseID] = &list[i]
+	}
+	s.repos[repo] = &history{records: records, file: fi}
+	return records, nil
+}
+
+// sameFile reports whether a history file is still the one last read or
+// written. Saves replace the file, so another process's save makes it a
+// different one.
+func sameFile(a, b os.FileInfo) bool {
+	return a != nil && b != nil && os.SameFile(a, b) && a.ModTime().Equal(b.ModTime()) && a.Size() == b.Size()
+}
+
+// save applies retention and writes a repo's records. The file is replaced
+// atomically so a crash never leaves a truncated history. Callers must hold
+// s.mu.
+func (s *Store) save(repo string, records map[int]*Record) error {
+	cutoff := s.now().Add(-s.maxAge)
+	list := sorted(records)
+	kept := list[:0]
+	for _, rec := range list {
+		if created, err := time.Parse(time.RFC3339, rec.Run.CreatedAt); err == nil && created.Before(cutoff) {
+			delete(records, rec.Run.DatabaseID)
+			continue
+		}
+		if len(kept) >= s.maxRuns {
+			delete(records, rec.Run.DatabaseID)
+			continue
+		}
+		kept = append(kept, rec)
+	}
+
+	data, err := json.Marshal(kept)
+	if err != nil {
+		return fmt.Errorf("failed to encode history: %w", err)
+	}
+	tmp, err := os.CreateTemp(s.dir, ".history-*")
+	if err != nil {
+		return fmt.Errorf("failed to write history: %w", err)
+	}
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return fmt.Errorf("failed to write history: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return fmt.Errorf("failed to write history: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), s.path(repo)); err != nil {
+		os.Remove(tmp.Name())
+		return fmt.Errorf("failed to write history: %w", err)
+	}
+	// Note the file written, so the next load doesn't take it for another
+	// process's.
+	fi, _ := os.Stat(s.path(repo))
+	s.repos[repo] = &history{records: records, file: fi}
+	return nil
+}
+
+func (s *Store) path(repo string) string {
+	return filepath.Join(s.dir, url.PathEscape(repo)+".json")
+}
+
+func repoFromFile(name string) (string, bool) {
+	if filepath.Ext(name) != ".json" || name[0] == '.' {
+		return "", false
+	}
+	repo, err := url.PathUnescape(name[:len(name)-len(".json")])
+	if err != nil {
+		return "", false
+	}
+	return repo, true
+}
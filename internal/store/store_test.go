@@ -0,0 +1,306 @@
+package store
+
+import (
+	"os"
+	"testing"
+	"time"
+
+	"github.com/dzoba/github-actions-watcher/internal/types"
+)
+
+func run(id int, created time.Time, status types.RunStatus, conclusion types.RunConclusion) types.WorkflowRun {
+	return types.WorkflowRun{
+		DatabaseID: id,
+		Status:     status,
+		Conclusion: conclusion,
+		CreatedAt:  created.Format(time.RFC3339),
+		Attempt:    1,
+	}
+}
+
+func TestRecordRunsUpsert(t *testing.T) {
+	dir := t.TempDir()
+	s, err := Open(dir, Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	now := time.Now()
+	if err := s.RecordRuns("o/r", []types.WorkflowRun{
+		run(1, now.Add(-time.Hour), types.StatusCompleted, types.ConclusionSuccess),
+		run(2, now, types.StatusInProgress, ""),
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.RecordRuns("o/r", []types.WorkflowRun{
+		run(2, now, types.StatusCompleted, types.ConclusionFailure),
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	// A fresh store reads the same history back from disk.
+	s, err = Open(dir, Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	recs, err := s.Runs("o/r")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(recs) != 2 {
+		t.Fatalf("got %d records, want 2", len(recs))
+	}
+	if recs[0].Run.DatabaseID != 2 || recs[0].Run.Conclusion != types.ConclusionFailure {
+		t.Errorf("newest record = %+v, want run 2 failed", recs[0].Run)
+	}
+	repos, err := s.Repos()
+	if err != nil || len(repos) != 1 || repos[0] != "o/r" {
+		t.Errorf("Repos() = %v, %v", repos, err)
+	}
+}
+
+func TestSharedDir(t *testing.T) {
+	dir := t.TempDir()
+	a, err := Open(dir, Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err := Open(dir, Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	now := time.Now()
+	// Both have read the history before either writes, like a terminal and
+	// ghaw serve watching the same repo.
+	for _, s := range []*Store{a, b} {
+		if _, err := s.Runs("o/r"); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := a.RecordRuns("o/r", []types.WorkflowRun{run(1, now, types.StatusCompleted, types.ConclusionSuccess)}); err != nil {
+		t.Fatal(err)
+	}
+	if err := b.RecordRuns("o/r", []types.WorkflowRun{run(2, now, types.StatusInProgress, "")}); err != nil {
+		t.Fatal(err)
+	}
+	if err := a.RecordTiming("o/r", 2, &types.RunTiming{}); err != nil {
+		t.Fatal(err)
+	}
+	for _, s := range []*Store{a, b} {
+		recs, err := s.Runs("o/r")
+		if err != nil || len(recs) != 2 {
+			t.Fatalf("Runs() = %+v, %v; want both processes' runs", recs, err)
+		}
+		if recs[0].Run.DatabaseID != 2 || recs[0].Timing == nil {
+			t.Errorf("run 2 = %+v, want the timing the other store recorded", recs[0])
+		}
+	}
+
+	// A lock left behind by a process that died doesn't stop writes for
+	// good.
+	lock := a.path("o/r") + ".lock"
+	if err := os.WriteFile(lock, nil, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	old := now.Add(-time.Minute)
+	if err := os.Chtimes(lock, old, old); err != nil {
+		t.Fatal(err)
+	}
+	if err := a.RecordRuns("o/r", []types.WorkflowRun{run(3, now, types.StatusQueued, "")}); err != nil {
+		t.Errorf("RecordRuns() with a stale lock = %v", err)
+	}
+}
+
+func TestRecordDetailAttempts(t *testing.T) {
+	s, err := Open(t.TempDir(), Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	now := time.Now()
+	d := &types.RunDetail{
+		WorkflowRun: run(7, now, types.StatusCompleted, types.ConclusionFailure),
+		Jobs:        []types.Job{{Name: "test", Conclusion: types.ConclusionFailure}},
+	}
+	if err := s.RecordDetail("o/r", d); err != nil {
+		t.Fatal(err)
+	}
+
+	rerun := *d
+	rerun.Attempt = 2
+	rerun.Conclusion = types.ConclusionSuccess
+	rerun.Jobs = []types.Job{{Name: "test", Conclusion: types.ConclusionSuccess}}
+	if err := s.RecordDetail("o/r", &rerun); err != nil {
+		t.Fatal(err)
+	}
+
+	rec, ok, err := s.Get("o/r", 7)
+	if err != nil || !ok {
+		t.Fatalf("Get() = %v, %v", ok, err)
+	}
+	if rec.Detail.Attempt != 2 || rec.Run.Conclusion != types.ConclusionSuccess {
+		t.Errorf("latest detail = attempt %d %s", rec.Detail.Attempt, rec.Run.Conclusion)
+	}
+	if len(rec.Attempts) != 1 || rec.Attempts[0].Jobs[0].Conclusion != types.ConclusionFailure {
+		t.Errorf("earlier attempts = %+v", rec.Attempts)
+	}
+}
+
+func TestUnknownAttempt(t *testing.T) {
+	s, err := Open(t.TempDir(), Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	d := &types.RunDetail{
+		WorkflowRun: run(7, time.Now(), types.StatusCompleted, types.ConclusionFailure),
+		Jobs:        []types.Job{{Name: "test", Conclusion: types.ConclusionFailure}},
+	}
+	d.Attempt = 2
+	d.StartedAt = "2024-01-01T12:00:00Z"
+	if err := s.RecordDetail("o/r", d); err != nil {
+		t.Fatal(err)
+	}
+
+	// A backend that can't tell the attempt reports 0, and the start of
+	// the first one.
+	unknown := *d
+	unknown.Attempt = 0
+	unknown.StartedAt = unknown.CreatedAt
+	unknown.Conclusion = types.ConclusionSuccess
+	if err := s.RecordRuns("o/r", []types.WorkflowRun{unknown.WorkflowRun}); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.RecordDetail("o/r", &unknown); err != nil {
+		t.Fatal(err)
+	}
+
+	rec, _, err := s.Get("o/r", 7)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rec.Run.Attempt != 2 || rec.Run.Conclusion != types.ConclusionSuccess || rec.Run.StartedAt != d.StartedAt {
+		t.Errorf("run = attempt %d %s started %s, want attempt 2 success started %s", rec.Run.Attempt, rec.Run.Conclusion, rec.Run.StartedAt, d.StartedAt)
+	}
+	if len(rec.Attempts) != 0 || rec.Detail.Attempt != 2 {
+		t.Errorf("attempts = %+v, detail attempt %d", rec.Attempts, rec.Detail.Attempt)
+	}
+}
+
+func TestRecordAttempt(t *testing.T) {
+	s, err := Open(t.TempDir(), Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	latest := &types.RunDetail{WorkflowRun: run(7, time.Now(), types.StatusCompleted, types.ConclusionSuccess)}
+	latest.Attempt = 3
+	if err := s.RecordDetail("o/r", latest); err != nil {
+		t.Fatal(err)
+	}
+	for _, n := range []int{2, 1, 2, 3} {
+		d := *latest
+		d.Attempt = n
+		if err := s.RecordAttempt("o/r", &d); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	rec, _, err := s.Get("o/r", 7)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []int
+	for _, d := range rec.AllAttempts() {
+		got = append(got, d.Attempt)
+	}
+	if len(got) != 3 || got[0] != 1 || got[1] != 2 || got[2] != 3 {
+		t.Errorf("attempts = %v, want [1 2 3]", got)
+	}
+}
+
+func TestRecordTiming(t *testing.T) {
+	dir := t.TempDir()
+	s, err := Open(dir, Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := s.RecordRuns("o/r", []types.WorkflowRun{run(1, time.Now(), types.StatusCompleted, types.ConclusionSuccess)}); err != nil {
+		t.Fatal(err)
+	}
+	timing := &types.RunTiming{Billable: map[string]types.OSTiming{"UBUNTU": {TotalMS: 60_000, Jobs: 1}}}
+	if err := s.RecordTiming("o/r", 1, timing); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.RecordTiming("o/r", 2, timing); err != nil {
+		t.Fatal(err)
+	}
+
+	s, err = Open(dir, Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	recs, err := s.Runs("o/r")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(recs) != 1 || recs[0].Timing == nil || recs[0].Timing.Billable["UBUNTU"].TotalMS != 60_000 {
+		t.Errorf("records = %+v", recs)
+	}
+}
+
+func TestRetention(t *testing.T) {
+	s, err := Open(t.TempDir(), Options{MaxRuns: 2, MaxAge: 24 * time.Hour})
+	if err != nil {
+		t.Fatal(err)
+	}
+	now := time.Now()
+	if err := s.RecordRuns("o/r", []types.WorkflowRun{
+		run(1, now.Add(-48*time.Hour), types.StatusCompleted, types.ConclusionSuccess),
+		run(2, now.Add(-3*time.Hour), types.StatusCompleted, types.ConclusionSuccess),
+		run(3, now.Add(-2*time.Hour), types.StatusCompleted, types.ConclusionSuccess),
+		run(4, now.Add(-1*time.Hour), types.StatusCompleted, types.ConclusionSuccess),
+	}); err != nil {
+		t.Fatal(err)
+	}
+	recs, _ := s.Runs("o/r")
+	if len(recs) != 2 || recs[0].Run.DatabaseID != 4 || recs[1].Run.DatabaseID != 3 {
+		t.Errorf("kept %d records: %+v", len(recs), recs)
+	}
+}
+
+func TestLastSeen(t *testing.T) {
+	dir := t.TempDir()
+	s, err := Open(dir, Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	clock := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	s.now = func() time.Time { return clock }
+	r := run(1, clock, types.StatusCompleted, types.ConclusionSuccess)
+	seen := func() time.Time {
+		t.Helper()
+		// From disk, to see what was written.
+		fresh, err := Open(dir, Options{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		rec, _, err := fresh.Get("o/r", 1)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return rec.LastSeen
+	}
+	if err := s.RecordRuns("o/r", []types.WorkflowRun{r}); err != nil {
+		t.Fatal(err)
+	}
+
+	// Unchanged runs seen again aren't written every poll...
+	clock = clock.Add(10 * time.Second)
+	s.RecordRuns("o/r", []types.WorkflowRun{r})
+	if got := seen(); !got.Equal(clock.Add(-10 * time.Second)) {
+		t.Errorf("last seen %v after 10s", got)
+	}
+	// ...but at least every seenEvery.
+	clock = clock.Add(time.Hour)
+	s.RecordRuns("o/r", []types.WorkflowRun{r})
+	if got := seen(); !got.Equal(clock) {
+		t.Errorf("last seen %v, want %v", got, clock)
+	}
+}
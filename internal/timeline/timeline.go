@@ -0,0 +1,198 @@
+// Package timeline places the jobs and steps of a run on a shared time axis,
+// to see where a run spends its time: queued, running, or waiting on the
+// critical path.
+package timeline
+
+import (
+	"time"
+
+	"github.com/dzoba/github-actions-watcher/internal/types"
+)
+
+// Span is a job or step on the time axis. Offsets are from the start of the
+// run's attempt.
+type Span struct {
+	Name       string
+	Status     types.RunStatus
+	Conclusion types.RunConclusion
+	Start      time.Duration
+	End        time.Duration
+	// Ready is when the span could have started: when the attempt started or
+	// the jobs it waits on finished. Start-Ready is time spent queued.
+	Ready    time.Duration
+	Critical bool
+	Started  bool // false for jobs and steps that haven't started yet
+	Steps    []Span
+}
+
+// Queue returns how long the span waited between being ready and starting.
+func (s Span) Queue() time.Duration {
+	if !s.Started || s.Start < s.Ready {
+		return 0
+	}
+	return s.Start - s.Ready
+}
+
+// Timeline is a run's jobs on a shared time axis from 0 to Total.
+type Timeline struct {
+	Total time.Duration
+	Jobs  []Span
+}
+
+// Build lays out the jobs of a run. deps lists, for each job, the indices of
+// the jobs it needs; when nil, a job is assumed to wait on the job that
+// finished last before it started. Unfinished jobs and steps extend to now;
+// jobs that haven't started don't stretch the axis.
+//
+// The axis starts when the attempt started: a re-run's jobs start long after
+// the run was created.
+func Build(d *types.RunDetail, deps [][]int, now time.Time) Timeline {
+	origin, ok := parse(d.StartedAt)
+	if !ok {
+		origin, ok = parse(d.CreatedAt)
+	}
+	if !ok {
+		origin = now
+		for _, job := range d.Jobs {
+			if t, ok := parse(job.StartedAt); ok && t.Before(origin) {
+				origin = t
+			}
+		}
+	}
+	offset := func(s string, fallback time.Duration) time.Duration {
+		t, ok := parse(s)
+		if !ok {
+			return fallback
+		}
+		if t.Before(origin) {
+			return 0
+		}
+		return t.Sub(origin)
+	}
+	nowOffset := max(now.Sub(origin), 0)
+
+	var tl Timeline
+	for _, job := range d.Jobs {
+		span := Span{Name: job.Name, Status: job.Status, Conclusion: job.Conclusion}
+		if _, ok := parse(job.StartedAt); ok {
+			span.Started = true
+			span.Start = offset(job.StartedAt, 0)
+			span.End = offset(job.CompletedAt, nowOffset)
+		} else {
+			span.Start, span.End = nowOffset, nowOffset
+		}
+		if span.End < span.Start {
+			span.End = span.Start
+		}
+		// A step is ready once the step before it finished.
+		ready := span.Start
+		for _, step := range job.Steps {
+			s := Span{Name: step.Name, Status: step.Status, Conclusion: step.Conclusion, Ready: ready}
+			if _, ok := parse(step.StartedAt); ok {
+				s.Started = true
+				s.Start = offset(step.StartedAt, 0)
+				s.End = offset(step.CompletedAt, nowOffset)
+			} else {
+				s.Start, s.End = span.End, span.End
+			}
+			if s.End < s.Start {
+				s.End = s.Start
+			}
+			if s.Started {
+				ready = s.End
+			}
+			span.Steps = append(span.Steps, s)
+		}
+		if span.Started {
+			tl.Total = max(tl.Total, span.End)
+		}
+		tl.Jobs = append(tl.Jobs, span)
+	}
+
+	if deps == nil {
+		deps = inferDeps(tl.Jobs)
+	}
+	for i := range tl.Jobs {
+		for _, p := range deps[i] {
+			if tl.Jobs[p].Started {
+				tl.Jobs[i].Ready = max(tl.Jobs[i].Ready, tl.Jobs[p].End)
+			}
+		}
+	}
+	markCritical(tl.Jobs, deps)
+	return tl
+}
+
+// inferDeps guesses, without the workflow file, that each job waited on
+// whichever started job finished last before it started.
+func inferDeps(jobs []Span) [][]int {
+	deps := make([][]int, len(jobs))
+	for i, job := range jobs {
+		if !job.Started {
+			continue
+		}
+		best := -1
+		for j, other := range jobs {
+			if j == i || !other.Started || other.End > job.Start || other.Start >= job.Start {
+				continue
+			}
+			if best < 0 || other.End > jobs[best].End {
+				best = j
+			}
+		}
+		if best >= 0 {
+			deps[i] = []int{best}
+		}
+	}
+	return deps
+}
+
+// markCritical flags the chain of jobs that determined when the run finished:
+// starting from the job that ended last, repeatedly step to the dependency
+// that finished last.
+func markCritical(jobs []Span, deps [][]int) {
+	cur := -1
+	for i, job := range jobs {
+		if job.Started && (cur < 0 || job.End > jobs[cur].End) {
+			cur = i
+		}
+	}
+	seen := make(map[int]bool)
+	for cur >= 0 && !seen[cur] {
+		seen[cur] = true
+		jobs[cur].Critical = true
+		next := -1
+		for _, p := range deps[cur] {
+			if jobs[p].Started && (next < 0 || jobs[p].End > jobs[next].End) {
+				next = p
+			}
+		}
+		cur = next
+	}
+}
+
+// Col maps an offset on the time axis to one of width columns.
+func (tl Timeline) Col(d time.Duration, width int) int {
+	if tl.Total <= 0 || width <= 0 {
+		return 0
+	}
+	c := int(int64(d) * int64(width) / int64(tl.Total))
+	if c >= width {
+		c = width - 1
+	}
+	if c < 0 {
+		c = 0
+	}
+	return c
+}
+
+func parse(s string) (time.Time, bool) {
+	if s == "" {
+		return time.Time{}, false
+	}
+	t, err := time.Parse(time.RFC3339, s)
+	if err != nil {
+		return time.Time{}, false
+	}
+	return t, true
+}
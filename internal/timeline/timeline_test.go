@@ -0,0 +1,103 @@
+package timeline
+
+import (
+	"testing"
+	"time"
+
+	"github.com/dzoba/github-actions-watcher/internal/types"
+)
+
+func at(min, sec int) string {
+	return time.Date(2024, 1, 1, 0, min, sec, 0, time.UTC).Format(time.RFC3339)
+}
+
+func TestBuildInferred(t *testing.T) {
+	d := &types.RunDetail{
+		WorkflowRun: types.WorkflowRun{CreatedAt: at(0, 0)},
+		Jobs: []types.Job{
+			{Name: "build", Status: types.StatusCompleted, StartedAt: at(0, 30), CompletedAt: at(3, 0),
+				Steps: []types.Step{
+					{Name: "compile", StartedAt: at(0, 40), CompletedAt: at(2, 50)},
+					{Name: "upload", StartedAt: at(2, 52), CompletedAt: at(3, 0)},
+				}},
+			{Name: "lint", Status: types.StatusCompleted, StartedAt: at(0, 20), CompletedAt: at(1, 0)},
+			{Name: "test", Status: types.StatusCompleted, StartedAt: at(5, 0), CompletedAt: at(10, 0)},
+			{Name: "deploy", Status: types.StatusQueued},
+		},
+	}
+	tl := Build(d, nil, time.Date(2024, 1, 1, 0, 12, 0, 0, time.UTC))
+
+	if tl.Total != 10*time.Minute {
+		t.Errorf("Total = %v, want 10m", tl.Total)
+	}
+	build, test := tl.Jobs[0], tl.Jobs[2]
+	if build.Queue() != 30*time.Second {
+		t.Errorf("build queue = %v, want 30s", build.Queue())
+	}
+	if test.Ready != 3*time.Minute || test.Queue() != 2*time.Minute {
+		t.Errorf("test ready = %v queue = %v, want 3m and 2m", test.Ready, test.Queue())
+	}
+	if len(build.Steps) != 2 || build.Steps[0].Start != 40*time.Second {
+		t.Errorf("build steps = %+v", build.Steps)
+	}
+	// The compile step doesn't count as time the upload step spent queued.
+	if q := build.Steps[1].Queue(); q != 2*time.Second {
+		t.Errorf("upload queue = %v, want 2s", q)
+	}
+	if !build.Critical || !test.Critical || tl.Jobs[1].Critical {
+		t.Errorf("critical path = %v %v %v, want build and test only", build.Critical, tl.Jobs[1].Critical, test.Critical)
+	}
+	if tl.Jobs[3].Started || tl.Jobs[3].Critical {
+		t.Errorf("queued job = %+v", tl.Jobs[3])
+	}
+}
+
+func TestBuildRerun(t *testing.T) {
+	// Attempt 2 was started an hour after the run was created.
+	d := &types.RunDetail{
+		WorkflowRun: types.WorkflowRun{CreatedAt: at(0, 0), StartedAt: at(60, 0), Attempt: 2},
+		Jobs: []types.Job{
+			{Name: "build", Status: types.StatusCompleted, StartedAt: at(60, 20), CompletedAt: at(62, 0)},
+			{Name: "test", Status: types.StatusCompleted, StartedAt: at(62, 10), CompletedAt: at(65, 0)},
+		},
+	}
+	tl := Build(d, nil, time.Date(2024, 1, 1, 1, 10, 0, 0, time.UTC))
+
+	if tl.Total != 5*time.Minute {
+		t.Errorf("Total = %v, want the 5m of attempt 2", tl.Total)
+	}
+	if build := tl.Jobs[0]; build.Start != 20*time.Second || build.Queue() != 20*time.Second {
+		t.Errorf("build start = %v queue = %v, want 20s from the re-run", build.Start, build.Queue())
+	}
+}
+
+func TestBuildDeps(t *testing.T) {
+	d := &types.RunDetail{
+		WorkflowRun: types.WorkflowRun{CreatedAt: at(0, 0)},
+		Jobs: []types.Job{
+			{Name: "a", StartedAt: at(0, 0), CompletedAt: at(4, 0)},
+			{Name: "b", StartedAt: at(0, 0), CompletedAt: at(2, 0)},
+			{Name: "c", StartedAt: at(5, 0), CompletedAt: at(6, 0)},
+		},
+	}
+	// c only needs b, so the critical path skips a even though it ended later.
+	tl := Build(d, [][]int{nil, nil, {1}}, time.Date(2024, 1, 1, 0, 6, 0, 0, time.UTC))
+	if tl.Jobs[2].Queue() != 3*time.Minute {
+		t.Errorf("c queue = %v, want 3m", tl.Jobs[2].Queue())
+	}
+	if tl.Jobs[0].Critical || !tl.Jobs[1].Critical || !tl.Jobs[2].Critical {
+		t.Errorf("critical = %v %v %v, want b and c", tl.Jobs[0].Critical, tl.Jobs[1].Critical, tl.Jobs[2].Critical)
+	}
+}
+
+func TestCol(t *testing.T) {
+	tl := Timeline{Total: 100 * time.Second}
+	for _, tt := range []struct {
+		d    time.Duration
+		want int
+	}{{0, 0}, {50 * time.Second, 25}, {100 * time.Second, 49}, {-time.Second, 0}} {
+		if got := tl.Col(tt.d, 50); got != tt.want {
+			t.Errorf("Col(%v) = %d, want %d", tt.d, got, tt.want)
+		}
+	}
+}
@@ -8,6 +8,14 @@ const (
 	ViewDetail
 	ViewRepoPicker
 	ViewWelcome
+	ViewGraph
+	ViewTimeline
+	ViewWorkflows
+	ViewFlaky
+	ViewStats
+	ViewQueue
+	ViewCompare
+	ViewCost
 )
 
 // RunStatus is the status of a workflow run.
@@ -42,11 +50,14 @@ type WorkflowRun struct {
 	DisplayTitle string        `json:"displayTitle"`
 	Event        string        `json:"event"`
 	HeadBranch   string        `json:"headBranch"`
+	HeadSha      string        `json:"headSha"`
 	Name         string        `json:"name"`
 	Number       int           `json:"number"`
+	Attempt      int           `json:"attempt"`
 	Status       RunStatus     `json:"status"`
 	Conclusion   RunConclusion `json:"conclusion"`
 	CreatedAt    string        `json:"createdAt"`
+	StartedAt    string        `json:"startedAt"`
 	UpdatedAt    string        `json:"updatedAt"`
 	URL          string        `json:"url"`
 	WorkflowName string        `json:"workflowName"`
@@ -72,6 +83,11 @@ type Job struct {
 	Steps       []Step        `json:"steps"`
 	URL         string        `json:"url"`
 	DatabaseID  int           `json:"databaseId"`
+	// CreatedAt, Labels and RunnerName come from the REST jobs API; they
+	// are empty when it couldn't be reached.
+	CreatedAt  string   `json:"createdAt,omitempty"`
+	Labels     []string `json:"labels,omitempty"`
+	RunnerName string   `json:"runnerName,omitempty"`
 }
 
 // RunDetail is a WorkflowRun with nested jobs.
@@ -80,6 +96,42 @@ type RunDetail struct {
 	Jobs []Job `json:"jobs"`
 }
 
+// OSTiming is the billable time a run spent on one runner OS.
+type OSTiming struct {
+	TotalMS int64   `json:"totalMs"`
+	Jobs    int     `json:"jobs"`
+	JobMS   []int64 `json:"jobMs"` // per job, which GitHub rounds up to minutes
+}
+
+// RunTiming is the billable time of a run per runner OS ("UBUNTU", "MACOS",
+// "WINDOWS"). Self-hosted runners are not billed and not included.
+type RunTiming struct {
+	Billable      map[string]OSTiming `json:"billable"`
+	RunDurationMS int64               `json:"runDurationMs"`
+}
+
+// Commit is a commit in a Comparison.
+type Commit struct {
+	SHA     string `json:"sha"`
+	Message string `json:"message"`
+	Author  string `json:"author"`
+}
+
+// Comparison is the commit range between two SHAs.
+type Comparison struct {
+	Status   string   `json:"status"` // ahead, behind, diverged or identical
+	AheadBy  int      `json:"aheadBy"`
+	BehindBy int      `json:"behindBy"`
+	Commits  []Commit `json:"commits"`
+}
+
+// OrgRepo is a repo found when watching an org.
+type OrgRepo struct {
+	NameWithOwner string `json:"nameWithOwner"`
+	PushedAt      string `json:"pushedAt"`
+	DefaultBranch string `json:"defaultBranch"`
+}
+
 // PickerRepo is a repo entry for the repo picker.
 type PickerRepo struct {
 	NameWithOwner string `json:"nameWithOwner"`
@@ -3,10 +3,70 @@ package ui
 import "github.com/charmbracelet/bubbles/key"
 
 type ListKeyMap struct {
+	Up        key.Binding
+	Down      key.Binding
+	PageUp    key.Binding
+	PageDown  key.Binding
+	Home      key.Binding
+	End       key.Binding
+	Enter     key.Binding
+	Workflows key.Binding
+	Flaky     key.Binding
+	Stats     key.Binding
+	Queue     key.Binding
+	Mark      key.Binding
+	Compare   key.Binding
+	Cost      key.Binding
+	Overview  key.Binding
+	Here      key.Binding
+	Switch    key.Binding
+	Refresh   key.Binding
+	Quit      key.Binding
+	Tab       key.Binding
+	ShiftTab  key.Binding
+	CloseTab  key.Binding
+}
+
+var ListKeys = ListKeyMap{
+	Up:        key.NewBinding(key.WithKeys("up", "k")),
+	Down:      key.NewBinding(key.WithKeys("down", "j")),
+	PageUp:    key.NewBinding(key.WithKeys("pgup")),
+	PageDown:  key.NewBinding(key.WithKeys("pgdown")),
+	Home:      key.NewBinding(key.WithKeys("home")),
+	End:       key.NewBinding(key.WithKeys("end")),
+	Enter:     key.NewBinding(key.WithKeys("enter")),
+	Workflows: key.NewBinding(key.WithKeys("f")),
+	Flaky:     key.NewBinding(key.WithKeys("F")),
+	Stats:     key.NewBinding(key.WithKeys("d")),
+	Queue:     key.NewBinding(key.WithKeys("u")),
+	Mark:      key.NewBinding(key.WithKeys("m")),
+	Compare:   key.NewBinding(key.WithKeys("c")),
+	Cost:      key.NewBinding(key.WithKeys("$")),
+	Overview:  key.NewBinding(key.WithKeys("O")),
+	Here:      key.NewBinding(key.WithKeys("h")),
+	Switch:    key.NewBinding(key.WithKeys("s")),
+	Refresh:   key.NewBinding(key.WithKeys("r")),
+	Quit:      key.NewBinding(key.WithKeys("q")),
+	Tab:       key.NewBinding(key.WithKeys("tab")),
+	ShiftTab:  key.NewBinding(key.WithKeys("shift+tab")),
+	CloseTab:  key.NewBinding(key.WithKeys("w")),
+}
+
+type DetailKeyMap struct {
 	Up       key.Binding
 	Down     key.Binding
-	Enter    key.Binding
-	Switch   key.Binding
+	PageUp   key.Binding
+	PageDown key.Binding
+	Home     key.Binding
+	End      key.Binding
+	Select   key.Binding
+	Toggle   key.Binding
+	Expand   key.Binding
+	Collapse key.Binding
+	Back     key.Binding
+	Open     key.Binding
+	Graph    key.Binding
+	Timeline key.Binding
 	Refresh  key.Binding
 	Quit     key.Binding
 	Tab      key.Binding
@@ -14,11 +74,21 @@ type ListKeyMap struct {
 	CloseTab key.Binding
 }
 
-var ListKeys = ListKeyMap{
+var DetailKeys = DetailKeyMap{
 	Up:       key.NewBinding(key.WithKeys("up", "k")),
 	Down:     key.NewBinding(key.WithKeys("down", "j")),
-	Enter:    key.NewBinding(key.WithKeys("enter")),
-	Switch:   key.NewBinding(key.WithKeys("s")),
+	PageUp:   key.NewBinding(key.WithKeys("pgup")),
+	PageDown: key.NewBinding(key.WithKeys("pgdown")),
+	Home:     key.NewBinding(key.WithKeys("home")),
+	End:      key.NewBinding(key.WithKeys("end")),
+	Select:   key.NewBinding(key.WithKeys("enter")),
+	Toggle:   key.NewBinding(key.WithKeys(" ")),
+	Expand:   key.NewBinding(key.WithKeys("right", "l")),
+	Collapse: key.NewBinding(key.WithKeys("left", "h")),
+	Back:     key.NewBinding(key.WithKeys("esc")),
+	Open:     key.NewBinding(key.WithKeys("o")),
+	Graph:    key.NewBinding(key.WithKeys("g")),
+	Timeline: key.NewBinding(key.WithKeys("t")),
 	Refresh:  key.NewBinding(key.WithKeys("r")),
 	Quit:     key.NewBinding(key.WithKeys("q")),
 	Tab:      key.NewBinding(key.WithKeys("tab")),
@@ -26,9 +96,15 @@ var ListKeys = ListKeyMap{
 	CloseTab: key.NewBinding(key.WithKeys("w")),
 }
 
-type DetailKeyMap struct {
+// ScrollKeyMap is shared by the scroll-only views: the job graph and timeline
+// of a run, and the summaries of a repo.
+type ScrollKeyMap struct {
 	Up       key.Binding
 	Down     key.Binding
+	PageUp   key.Binding
+	PageDown key.Binding
+	Home     key.Binding
+	End      key.Binding
 	Back     key.Binding
 	Open     key.Binding
 	Refresh  key.Binding
@@ -38,9 +114,13 @@ type DetailKeyMap struct {
 	CloseTab key.Binding
 }
 
-var DetailKeys = DetailKeyMap{
+var ScrollKeys = ScrollKeyMap{
 	Up:       key.NewBinding(key.WithKeys("up", "k")),
 	Down:     key.NewBinding(key.WithKeys("down", "j")),
+	PageUp:   key.NewBinding(key.WithKeys("pgup")),
+	PageDown: key.NewBinding(key.WithKeys("pgdown")),
+	Home:     key.NewBinding(key.WithKeys("home")),
+	End:      key.NewBinding(key.WithKeys("end")),
 	Back:     key.NewBinding(key.WithKeys("esc")),
 	Open:     key.NewBinding(key.WithKeys("o")),
 	Refresh:  key.NewBinding(key.WithKeys("r")),
@@ -50,20 +130,55 @@ var DetailKeys = DetailKeyMap{
 	CloseTab: key.NewBinding(key.WithKeys("w")),
 }
 
+// OverviewKeyMap is for the overview of every tab's runs.
+type OverviewKeyMap struct {
+	Up        key.Binding
+	Down      key.Binding
+	PageUp    key.Binding
+	PageDown  key.Binding
+	Home      key.Binding
+	End       key.Binding
+	Enter     key.Binding
+	Sort      key.Binding
+	Attention key.Binding
+	Back      key.Binding
+	Refresh   key.Binding
+	Quit      key.Binding
+}
+
+var OverviewKeys = OverviewKeyMap{
+	Up:        key.NewBinding(key.WithKeys("up", "k")),
+	Down:      key.NewBinding(key.WithKeys("down", "j")),
+	PageUp:    key.NewBinding(key.WithKeys("pgup")),
+	PageDown:  key.NewBinding(key.WithKeys("pgdown")),
+	Home:      key.NewBinding(key.WithKeys("home")),
+	End:       key.NewBinding(key.WithKeys("end")),
+	Enter:     key.NewBinding(key.WithKeys("enter")),
+	Sort:      key.NewBinding(key.WithKeys("s")),
+	Attention: key.NewBinding(key.WithKeys("a")),
+	Back:      key.NewBinding(key.WithKeys("esc", "O")),
+	Refresh:   key.NewBinding(key.WithKeys("r")),
+	Quit:      key.NewBinding(key.WithKeys("q")),
+}
+
 type PickerKeyMap struct {
-	Up      key.Binding
-	Down    key.Binding
-	Enter   key.Binding
-	Cancel  key.Binding
-	Remove  key.Binding
+	Up       key.Binding
+	Down     key.Binding
+	PageUp   key.Binding
+	PageDown key.Binding
+	Enter    key.Binding
+	Cancel   key.Binding
+	Remove   key.Binding
 }
 
 var PickerKeys = PickerKeyMap{
-	Up:     key.NewBinding(key.WithKeys("up")),
-	Down:   key.NewBinding(key.WithKeys("down")),
-	Enter:  key.NewBinding(key.WithKeys("enter")),
-	Cancel: key.NewBinding(key.WithKeys("esc")),
-	Remove: key.NewBinding(key.WithKeys("x")),
+	Up:       key.NewBinding(key.WithKeys("up")),
+	Down:     key.NewBinding(key.WithKeys("down")),
+	PageUp:   key.NewBinding(key.WithKeys("pgup")),
+	PageDown: key.NewBinding(key.WithKeys("pgdown")),
+	Enter:    key.NewBinding(key.WithKeys("enter")),
+	Cancel:   key.NewBinding(key.WithKeys("esc")),
+	Remove:   key.NewBinding(key.WithKeys("x")),
 }
 
 type RepoInputKeyMap struct {
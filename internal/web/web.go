@@ -0,0 +1,323 @@
+// Package web serves a read-only HTML dashboard of the repos a poller
+// watches: a tab per repo with its runs, and run details with jobs and steps.
+// Pages update live over Server-Sent Events after every poll; the details of
+// runs being viewed are fetched by the poller, once per poll however many
+// pages show them.
+package web
+
+import (
+	"bytes"
+	"context"
+	"embed"
+	"fmt"
+	"html/template"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/dzoba/github-actions-watcher/internal/format"
+	"github.com/dzoba/github-actions-watcher/internal/poller"
+	"github.com/dzoba/github-actions-watcher/internal/types"
+)
+
+//go:embed templates.html
+var templateFS embed.FS
+
+var templates = template.Must(template.ParseFS(templateFS, "templates.html"))
+
+// keepAlive is how often an idle event stream gets a comment, so proxies
+// don't close it between polls.
+const keepAlive = 30 * time.Second
+
+type server struct {
+	poller *poller.Poller
+}
+
+// Handler returns the dashboard of what p polls.
+func Handler(p *poller.Poller) http.Handler {
+	s := &server{poller: p}
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /{$}", s.handleRuns)
+	mux.HandleFunc("GET /runs/{owner}/{repo}/{id}", s.handleDetail)
+	mux.HandleFunc("GET /events", s.handleEvents)
+	return mux
+}
+
+type tab struct {
+	Repo   string
+	Href   string
+	Active bool
+}
+
+type page struct {
+	Title   string
+	Tabs    []tab
+	Content template.HTML
+	Events  string
+}
+
+func (s *server) tabs(active string) []tab {
+	var tabs []tab
+	for _, rs := range s.poller.State().Repos {
+		tabs = append(tabs, tab{
+			Repo:   rs.Repo,
+			Href:   "/?repo=" + url.QueryEscape(rs.Repo),
+			Active: rs.Repo == active,
+		})
+	}
+	return tabs
+}
+
+func (s *server) handleRuns(w http.ResponseWriter, r *http.Request) {
+	state := s.poller.State()
+	if len(state.Repos) == 0 {
+		http.Error(w, "no repos", http.StatusNotFound)
+		return
+	}
+	repo := r.URL.Query().Get("repo")
+	if repo == "" {
+		repo = state.Repos[0].Repo
+	}
+	content, ok := s.renderRuns(repo)
+	if !ok {
+		http.NotFound(w, r)
+		return
+	}
+	s.writePage(w, page{
+		Title:   repo,
+		Tabs:    s.tabs(repo),
+		Content: content,
+		Events:  "/events?repo=" + url.QueryEscape(repo),
+	})
+}
+
+func (s *server) handleDetail(w http.ResponseWriter, r *http.Request) {
+	repo := r.PathValue("owner") + "/" + r.PathValue("repo")
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil || !s.watches(repo) || !s.poller.Known(repo, id) {
+		http.NotFound(w, r)
+		return
+	}
+	s.writePage(w, page{
+		Title:   fmt.Sprintf("%s run %d", repo, id),
+		Tabs:    s.tabs(repo),
+		Content: s.renderDetail(r.Context(), repo, id),
+		Events:  fmt.Sprintf("/events?repo=%s&run=%d", url.QueryEscape(repo), id),
+	})
+}
+
+// handleEvents streams the content of a runs page, or of a run's detail
+// page with ?run=ID, as "update" events after every poll.
+func (s *server) handleEvents(w http.ResponseWriter, r *http.Request) {
+	repo := r.URL.Query().Get("repo")
+	if !s.watches(repo) {
+		http.NotFound(w, r)
+		return
+	}
+	var render func() template.HTML
+	if run := r.URL.Query().Get("run"); run != "" {
+		id, err := strconv.Atoi(run)
+		if err != nil {
+			http.Error(w, "invalid run", http.StatusBadRequest)
+			return
+		}
+		if !s.poller.Known(repo, id) {
+			http.NotFound(w, r)
+			return
+		}
+		unwatch := s.poller.Watch(repo, id)
+		defer unwatch()
+		render = func() template.HTML { return s.renderDetail(r.Context(), repo, id) }
+	} else {
+		render = func() template.HTML {
+			html, _ := s.renderRuns(repo)
+			return html
+		}
+	}
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, "streaming unsupported", http.StatusInternalServerError)
+		return
+	}
+
+	updates, stop := s.poller.Subscribe()
+	defer stop()
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	// Start with the current content, which also catches a page up after
+	// it reconnects.
+	writeEvent(w, "update", string(render()))
+	flusher.Flush()
+	ticker := time.NewTicker(keepAlive)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case <-ticker.C:
+			fmt.Fprint(w, ": keep-alive\n\n")
+		case <-updates:
+			writeEvent(w, "update", string(render()))
+		}
+		flusher.Flush()
+	}
+}
+
+// writeEvent writes one Server-Sent Event; every line of data needs its own
+// data field.
+func writeEvent(w http.ResponseWriter, event, data string) {
+	var b strings.Builder
+	fmt.Fprintf(&b, "event: %s\n", event)
+	for _, line := range strings.Split(data, "\n") {
+		fmt.Fprintf(&b, "data: %s\n", line)
+	}
+	b.WriteByte('\n')
+	fmt.Fprint(w, b.String())
+}
+
+func (s *server) watches(repo string) bool {
+	for _, rs := range s.poller.State().Repos {
+		if rs.Repo == repo {
+			return true
+		}
+	}
+	return false
+}
+
+func (s *server) writePage(w http.ResponseWriter, p page) {
+	var buf bytes.Buffer
+	if err := templates.ExecuteTemplate(&buf, "page", p); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.Write(buf.Bytes())
+}
+
+func render(name string, data any) template.HTML {
+	var buf bytes.Buffer
+	if err := templates.ExecuteTemplate(&buf, name, data); err != nil {
+		return template.HTML(template.HTMLEscapeString(err.Error()))
+	}
+	return template.HTML(buf.String())
+}
+
+// badge is a status badge as shown in the terminal.
+type badge struct {
+	Badge string
+	Color string
+}
+
+func newBadge(status types.RunStatus, conclusion types.RunConclusion) badge {
+	text, color := format.StatusBadge(status, conclusion)
+	return badge{text, color}
+}
+
+type runRow struct {
+	types.WorkflowRun
+	badge
+	Href     string
+	Age      string
+	Duration string
+	Running  bool
+}
+
+func newRunRow(repo string, run types.WorkflowRun) runRow {
+	row := runRow{
+		WorkflowRun: run,
+		badge:       newBadge(run.Status, run.Conclusion),
+		Href:        fmt.Sprintf("/runs/%s/%d", repo, run.DatabaseID),
+		Age:         format.RelativeTime(run.CreatedAt),
+		Running:     run.Status == types.StatusInProgress,
+	}
+	switch {
+	case row.Running:
+		row.Duration = format.Elapsed(run.StartedAt)
+	case run.Status == types.StatusCompleted:
+		row.Duration = format.Duration(run.StartedAt, run.UpdatedAt)
+	}
+	return row
+}
+
+func (s *server) renderRuns(repo string) (template.HTML, bool) {
+	for _, rs := range s.poller.State().Repos {
+		if rs.Repo != repo {
+			continue
+		}
+		data := struct {
+			Runs      []runRow
+			FetchedAt time.Time
+			LastError string
+		}{FetchedAt: rs.FetchedAt, LastError: rs.LastError}
+		for _, run := range rs.Runs {
+			data.Runs = append(data.Runs, newRunRow(repo, run))
+		}
+		return render("runs", data), true
+	}
+	return "", false
+}
+
+type stepRow struct {
+	types.Step
+	badge
+	Duration string
+}
+
+type jobRow struct {
+	types.Job
+	badge
+	Duration string
+	Open     bool
+	Steps    []stepRow
+}
+
+type detailView struct {
+	runRow
+	Back string
+	Jobs []jobRow
+}
+
+func (s *server) renderDetail(ctx context.Context, repo string, id int) template.HTML {
+	d, err := s.poller.Detail(ctx, repo, id)
+	if err != nil {
+		return render("detail", struct {
+			Error string
+			Run   *detailView
+		}{Error: err.Error()})
+	}
+	v := &detailView{runRow: newRunRow(repo, d.WorkflowRun), Back: "/?repo=" + url.QueryEscape(repo)}
+	for _, job := range d.Jobs {
+		jr := jobRow{
+			Job:      job,
+			badge:    newBadge(job.Status, job.Conclusion),
+			Duration: format.Duration(job.StartedAt, job.CompletedAt),
+			Open:     open(job),
+		}
+		for _, step := range job.Steps {
+			jr.Steps = append(jr.Steps, stepRow{
+				Step:     step,
+				badge:    newBadge(step.Status, step.Conclusion),
+				Duration: format.Duration(step.StartedAt, step.CompletedAt),
+			})
+		}
+		v.Jobs = append(v.Jobs, jr)
+	}
+	return render("detail", struct {
+		Error string
+		Run   *detailView
+	}{Run: v})
+}
+
+// open reports whether a job's steps are shown expanded, which like in the
+// terminal are those of running and failed jobs.
+func open(job types.Job) bool {
+	switch job.Status {
+	case types.StatusInProgress:
+		return true
+	case types.StatusCompleted:
+		return job.Conclusion == types.ConclusionFailure || job.Conclusion == types.ConclusionTimedOut
+	}
+	return false
+}
@@ -0,0 +1,129 @@
+package web
+
+import (
+	"bufio"
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/dzoba/github-actions-watcher/internal/poller"
+	"github.com/dzoba/github-actions-watcher/internal/poller/pollertest"
+	"github.com/dzoba/github-actions-watcher/internal/types"
+)
+
+func setup(t *testing.T) (*pollertest.Backend, *poller.Poller, *httptest.Server) {
+	run := types.WorkflowRun{
+		DatabaseID: 7, WorkflowName: "CI", HeadBranch: "main", DisplayTitle: "Fix <b>parser</b>",
+		Status: types.StatusCompleted, Conclusion: types.ConclusionFailure, Attempt: 1,
+	}
+	b := &pollertest.Backend{
+		Runs: map[string][]types.WorkflowRun{"o/a": {run}, "o/b": {run}},
+		Details: map[int]*types.RunDetail{7: {WorkflowRun: run, Jobs: []types.Job{
+			{Name: "lint", Status: types.StatusCompleted, Conclusion: types.ConclusionSuccess},
+			{Name: "test", Status: types.StatusCompleted, Conclusion: types.ConclusionFailure,
+				Steps: []types.Step{{Name: "go test", Status: types.StatusCompleted, Conclusion: types.ConclusionFailure}}},
+		}}},
+	}
+	p := poller.New(b, []string{"o/a", "o/b"}, time.Minute)
+	p.Poll(context.Background())
+	srv := httptest.NewServer(Handler(p))
+	t.Cleanup(srv.Close)
+	return b, p, srv
+}
+
+func get(t *testing.T, url string) (int, string) {
+	t.Helper()
+	resp, err := http.Get(url)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	body, _ := io.ReadAll(resp.Body)
+	return resp.StatusCode, string(body)
+}
+
+func TestPages(t *testing.T) {
+	_, _, srv := setup(t)
+
+	code, body := get(t, srv.URL+"/?repo=o/b")
+	if code != http.StatusOK {
+		t.Fatalf("runs page: %d", code)
+	}
+	for _, want := range []string{`class="active">o/b<`, "x failed", "Fix &lt;b&gt;parser&lt;/b&gt;", `href="/runs/o/b/7"`} {
+		if !strings.Contains(body, want) {
+			t.Errorf("runs page lacks %q", want)
+		}
+	}
+
+	code, body = get(t, srv.URL+"/runs/o/a/7")
+	if code != http.StatusOK {
+		t.Fatalf("detail page: %d", code)
+	}
+	if !strings.Contains(body, "<details open>") || !strings.Contains(body, "go test") {
+		t.Errorf("detail page doesn't expand the failed job:\n%s", body)
+	}
+
+	if code, _ := get(t, srv.URL+"/?repo=o/other"); code != http.StatusNotFound {
+		t.Errorf("unwatched repo: %d", code)
+	}
+	// Runs not in the run list aren't fetched on request.
+	if code, _ := get(t, srv.URL+"/runs/o/a/8"); code != http.StatusNotFound {
+		t.Errorf("unlisted run: %d", code)
+	}
+	if code, _ := get(t, srv.URL+"/events?repo=o/a&run=8"); code != http.StatusNotFound {
+		t.Errorf("events of an unlisted run: %d", code)
+	}
+}
+
+func TestEvents(t *testing.T) {
+	b, p, srv := setup(t)
+
+	resp, err := http.Get(srv.URL + "/events?repo=o/a")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	if ct := resp.Header.Get("Content-Type"); ct != "text/event-stream" {
+		t.Errorf("Content-Type = %q", ct)
+	}
+
+	r := bufio.NewReader(resp.Body)
+	readEvent := func() []string {
+		var event []string
+		for {
+			line, err := r.ReadString('\n')
+			if err != nil {
+				t.Fatal(err)
+			}
+			line = strings.TrimSuffix(line, "\n")
+			if line == "" {
+				return event
+			}
+			event = append(event, line)
+		}
+	}
+	if first := strings.Join(readEvent(), "\n"); !strings.Contains(first, "Fix &lt;b&gt;parser") {
+		t.Errorf("first update lacks the current run list:\n%s", first)
+	}
+
+	run := b.Runs["o/a"][0]
+	run.DisplayTitle = "Second try"
+	b.Runs["o/a"] = []types.WorkflowRun{run}
+	p.Poll(context.Background())
+	event := readEvent()
+	if event[0] != "event: update" {
+		t.Errorf("event = %q", event[0])
+	}
+	for _, line := range event[1:] {
+		if !strings.HasPrefix(line, "data: ") {
+			t.Errorf("line %q is not data", line)
+		}
+	}
+	if !strings.Contains(strings.Join(event, "\n"), "Second try") {
+		t.Errorf("update lacks the new run list:\n%s", strings.Join(event, "\n"))
+	}
+}